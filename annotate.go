@@ -0,0 +1,127 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// annotatePrompt asks for the detailed explanation of a commit that is
+// stored as its note, next to its short message.
+const annotatePrompt = "Write a detailed explanation of the git commit below, to be stored as a git note next to its commit message for developers who later find it in the history. Cover what the commit changes and how, why it was likely made, and anything a future reader should know: side effects, trade-offs, follow-up work, and how it could be tested. Don't repeat the commit message; build on it. Mark reasons the diff doesn't show as inferred. Write plain text in short paragraphs, using '- ' lists where they help, with no Markdown headings or code fences. {{.LanguageInstructions}}Do not include any preamble.\n\n" + systemSeparator + "Commit {{.SHA}}:\n```\n{{.Message}}\n```\n\n" + changesSection
+
+// annotateData is what annotatePrompt is executed against.
+type annotateData struct {
+	*promptData
+	// SHA is the full name of the commit.
+	SHA string
+	// Message is the commit's full message.
+	Message string
+}
+
+// runAnnotate implements `git-commit-message annotate <commit>`: a detailed
+// explanation of commit, added to it with `git notes` so the commit
+// message itself can stay short.
+func runAnnotate(args []string) error {
+	flags := flag.NewFlagSet("annotate", flag.ExitOnError)
+	ref := flags.String("ref", "", "the notes ref to write to, e.g. ai-explanations (default: notes_ref, or git's refs/notes/commits)")
+	force := flags.Bool("force", false, "replace the commit's existing note")
+	dryRun := flags.Bool("dry-run", false, "print the note without adding it")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message annotate [flags] <commit>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	if *ref != "" {
+		config.NotesRef = *ref
+	}
+	sha, err := runGit("rev-parse", "--verify", "--quiet", flags.Arg(0)+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("unknown commit %q", flags.Arg(0))
+	}
+	sha = strings.TrimSpace(sha)
+	notes := []string{"notes"}
+	if config.NotesRef != "" {
+		notes = append(notes, "--ref", config.NotesRef)
+	}
+	if !*force && !*dryRun {
+		// `git notes show` fails when there is no note.
+		if _, err := runGit(append(notes, "show", sha)...); err == nil {
+			return fmt.Errorf("commit %s already has a note; use --force to replace it", shortSHA(sha))
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "🤖 Explaining commit %s...\n", shortSHA(sha))
+	note, err := annotateCommit(appCtx, config, sha)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Println(note)
+		return nil
+	}
+	if _, err := runGitInput(note+"\n", append(notes, "add", "--force", "--file=-", sha)...); err != nil {
+		return err
+	}
+	fmt.Printf("📝 Added the explanation as a note on %s. Show it with `git log --notes%s`.\n", shortSHA(sha), notesFlagRef(config.NotesRef))
+	return nil
+}
+
+// notesFlagRef returns the "=ref" suffix for `git log --notes` for ref, or
+// "" for git's default ref.
+func notesFlagRef(ref string) string {
+	if ref == "" {
+		return ""
+	}
+	return "=" + ref
+}
+
+// annotateCommit writes the note explaining the commit sha, wrapped like a
+// commit message body.
+func annotateCommit(ctx context.Context, config *Config, sha string) (string, error) {
+	message, err := runGit("log", "-1", "--format=%B", sha)
+	if err != nil {
+		return "", err
+	}
+	diff, err := commitDiff(sha)
+	if err != nil {
+		return "", err
+	}
+	diff = prepareDiff(config, diff)
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	prompt, err := renderPrompt(annotatePrompt, &annotateData{
+		promptData: newPromptData(config, diff, summaries),
+		SHA:        sha,
+		Message:    strings.TrimSpace(message),
+	})
+	if err != nil {
+		return "", err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	var paragraphs []string
+	for _, paragraph := range splitParagraphs(stripOuterFence(config.runCleaners(reply))) {
+		paragraphs = append(paragraphs, wrapParagraph(paragraph, bodyWidth))
+	}
+	if len(paragraphs) == 0 {
+		return "", fmt.Errorf("the model returned an empty explanation")
+	}
+	return strings.Join(paragraphs, "\n\n"), nil
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+)
+
+// readSecret prompts for a secret without echoing it when stdin is a
+// terminal. Piped input is read as is, so `auth login openai < key.txt` works.
+func readSecret(prompt string) (string, error) {
+	if !isTerminal(os.Stdin) {
+		return readLine()
+	}
+	fmt.Fprint(os.Stderr, prompt)
+	if runtime.GOOS != "windows" {
+		if stty("-echo") == nil {
+			echoOff.Store(true)
+			defer func() {
+				stty("echo")
+				echoOff.Store(false)
+				fmt.Fprintln(os.Stderr)
+			}()
+		}
+	}
+	return readLine()
+}
+
+// isTerminal reports whether f is connected to a terminal. /dev/null is a
+// character device too, so it is ruled out explicitly.
+func isTerminal(f *os.File) bool {
+	info, err := f.Stat()
+	if err != nil || info.Mode()&os.ModeCharDevice == 0 {
+		return false
+	}
+	null, err := os.Stat(os.DevNull)
+	return err != nil || !os.SameFile(info, null)
+}
+
+// stty changes the terminal settings of stdin.
+func stty(setting string) error {
+	cmd := exec.Command("stty", setting)
+	cmd.Stdin = os.Stdin
+	return cmd.Run()
+}
+
+// runAuth implements `git-commit-message auth login|logout <provider>`,
+// which stores API keys in the OS keyring instead of config.yaml.
+func runAuth(args []string) error {
+	flags := flag.NewFlagSet("auth", flag.ExitOnError)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message auth login|logout <provider|platform>")
+		fmt.Fprintf(flags.Output(), "Accounts: %s\n", strings.Join(keyringAccounts(), ", "))
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	action, name := flags.Arg(0), strings.ToLower(flags.Arg(1))
+	if !slices.Contains(keyringAccounts(), name) {
+		return fmt.Errorf("%q does not use an API key (expected one of %s)", name, strings.Join(keyringAccounts(), ", "))
+	}
+
+	switch action {
+	case "login":
+		key, err := readSecret(fmt.Sprintf("%s API key: ", name))
+		if err != nil {
+			return fmt.Errorf("could not read the API key: %w", err)
+		}
+		if key == "" {
+			return fmt.Errorf("no API key entered")
+		}
+		if err := keyringSet(name, key); err != nil {
+			return fmt.Errorf("could not store the key in the keyring: %w", err)
+		}
+		fmt.Printf("🔑 Stored the %s API key in the system keyring.\n", name)
+	case "logout":
+		if err := keyringDelete(name); err != nil {
+			return fmt.Errorf("could not remove the key from the keyring: %w", err)
+		}
+		fmt.Printf("🔑 Removed the %s API key from the system keyring.\n", name)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// branchPrompt asks for a branch name describing either a task or a diff.
+const branchPrompt = "Suggest a git branch name for the {{if .Task}}following task{{else}}changes below{{end}}. Use the form '<type>/<short-description>', where type is one of feat, fix, docs, refactor, perf, test, build, ci or chore, and the description is two to five lowercase English words separated by hyphens, e.g. 'feat/add-user-login' or 'fix/race-in-cache'. Reply with only the branch name.\n\n" + systemSeparator + "{{if .Task}}Task: {{.Task}}{{else}}" + changesSection + "{{end}}"
+
+// branchData is what branchPrompt is executed against.
+type branchData struct {
+	*promptData
+	// Task is the --task description, or "" to describe the diff.
+	Task string
+}
+
+// maxBranchDescription caps the part of the branch name after the prefix.
+const maxBranchDescription = 50
+
+// branchUnsafe matches runs of characters that don't belong in a branch name.
+var branchUnsafe = regexp.MustCompile(`[^a-z0-9]+`)
+
+// sanitizeBranch turns the model's reply into a kebab-case branch name with
+// a conventional type prefix, defaulting the prefix to feat/.
+func sanitizeBranch(reply string) string {
+	name := strings.ToLower(cleanMessage(reply))
+	prefix, description, ok := strings.Cut(name, "/")
+	if !ok || validateConventional(prefix+": x") != nil {
+		// Some models answer with a commit subject instead.
+		prefix, description = "feat", name
+		if commit, ok := parseConventional(name); ok && validateConventional(name) == nil {
+			prefix, description = commit.Type, commit.Subject
+		}
+	}
+	description = strings.Trim(branchUnsafe.ReplaceAllString(description, "-"), "-")
+	if len(description) > maxBranchDescription {
+		description = description[:maxBranchDescription]
+		if cut := strings.LastIndex(description, "-"); cut > 0 {
+			description = description[:cut]
+		}
+	}
+	if description == "" {
+		return ""
+	}
+	return prefix + "/" + description
+}
+
+// runBranch implements `git-commit-message branch`: suggest a branch name
+// for the working tree changes or a described task, and optionally switch
+// to it.
+func runBranch(args []string) error {
+	flags := flag.NewFlagSet("branch", flag.ExitOnError)
+	task := flags.String("task", "", "describe the task instead of reading the working tree diff")
+	create := flags.Bool("create", false, "create the branch and switch to it")
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+
+	data := &branchData{promptData: newPromptData(config, "", ""), Task: strings.TrimSpace(*task)}
+	ctx := appCtx
+	if data.Task == "" {
+		diff, err := collectDiff(config, diffAll)
+		if err != nil {
+			return err
+		}
+		if strings.TrimSpace(diff) == "" {
+			return fmt.Errorf("no changes to name a branch after; pass --task \"...\" to describe the work instead")
+		}
+		data.Summaries, err = summarizeDiff(ctx, config, diff)
+		if err != nil {
+			return err
+		}
+	}
+	prompt, err := renderPrompt(branchPrompt, data)
+	if err != nil {
+		return err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return err
+	}
+	name := sanitizeBranch(reply)
+	if _, err := runGit("check-ref-format", "--branch", name); name == "" || err != nil {
+		return fmt.Errorf("the model suggested an unusable branch name %q", strings.TrimSpace(reply))
+	}
+
+	fmt.Println(name)
+	if !*create {
+		return nil
+	}
+	// Uncommitted changes carry over to the new branch.
+	if _, err := runGit("switch", "-c", name); err != nil {
+		return fmt.Errorf("could not create branch %s: %w", name, err)
+	}
+	fmt.Fprintf(os.Stderr, "🌿 Switched to a new branch '%s'\n", name)
+	return nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// breakingPrompt asks for the BREAKING CHANGE footer text, given what
+// detectBreaking found and the diff.
+const breakingPrompt = "The following git diff makes changes that can break existing users:\n%s\nIn one or two short sentences, explain what breaks and what users have to change. Do not include any preamble, footer key or markdown formatting.\n\n" + systemSeparator + "```diff\n%s\n```"
+
+// declaration is a public API element declared on a line of a diff.
+type declaration struct {
+	// key identifies the element across files, e.g. "pkg/provider.Ollama.Generate".
+	key string
+	// name is how it is reported, e.g. "Ollama.Generate".
+	name string
+	kind string
+	// params is the normalised parameter and result list, "" for types.
+	params string
+	file   string
+}
+
+// Declarations of exported Go functions, methods and types, public
+// JavaScript/TypeScript exports and top-level public Python definitions.
+var (
+	goFunc   = regexp.MustCompile(`^func\s+(?:\(\s*(?:\w+\s+)?\*?([A-Z]\w*)(?:\[[^\]]*\])?\s*\)\s*)?([A-Z]\w*)\s*(\(.*?)\s*\{?\s*$`)
+	goType   = regexp.MustCompile(`^type\s+([A-Z]\w*)\b`)
+	jsExport = regexp.MustCompile(`^export\s+(?:default\s+)?(?:declare\s+)?(?:async\s+)?(function\*?|class|const|let|var|interface|type|enum)\s+(\w+)(.*?)\s*\{?\s*$`)
+	pyDef    = regexp.MustCompile(`^(def|class)\s+([A-Za-z]\w*)(.*?)\s*:?\s*$`)
+)
+
+// configTag matches the key in a Go struct tag such as `yaml:"api_key"`.
+// JSON tags are left out, since they mostly describe wire formats.
+var configTag = regexp.MustCompile(`(?:yaml|toml|mapstructure):"([^",]+)`)
+
+// configKey matches the key of a YAML, TOML or JSON line in a config file.
+var configKey = regexp.MustCompile(`^\s*(?:"([^"]+)"\s*:|([A-Za-z_][\w.-]*)\s*[:=])`)
+
+// detectBreaking looks for changes to diff that can break users of the
+// code: removed, renamed or re-signatured public functions, removed types
+// and removed config keys. It returns one line per finding, or nil.
+func detectBreaking(diff string) []string {
+	var removed, added []declaration
+	removedKeys, addedKeys := make(map[string]string), make(map[string]bool)
+	for _, f := range splitDiff(diff) {
+		if isTestFile(f.Path) || isDocFile(f.Path) {
+			continue
+		}
+		public := publicFile(f)
+		for _, line := range strings.Split(f.Text, "\n") {
+			if strings.HasPrefix(line, "---") || strings.HasPrefix(line, "+++") || line == "" {
+				continue
+			}
+			sign, content := line[0], line[1:]
+			if sign != '-' && sign != '+' {
+				continue
+			}
+			if public {
+				if d, ok := parseDeclaration(f.Path, content); ok {
+					if sign == '-' {
+						removed = append(removed, d)
+					} else {
+						added = append(added, d)
+					}
+				}
+			}
+			for _, key := range configKeys(f.Path, content) {
+				if sign == '-' {
+					removedKeys[key] = f.Path
+				} else {
+					addedKeys[key] = true
+				}
+			}
+		}
+	}
+
+	var findings []string
+	for _, d := range removed {
+		i := slices.IndexFunc(added, func(a declaration) bool { return a.key == d.key })
+		switch {
+		case i >= 0 && added[i].params != d.params:
+			findings = append(findings, fmt.Sprintf("changed the signature of %s %s (%s)", d.kind, d.name, d.file))
+		case i >= 0:
+		case renamedTo(d, removed, added) != "":
+			findings = append(findings, fmt.Sprintf("renamed %s %s to %s (%s)", d.kind, d.name, renamedTo(d, removed, added), d.file))
+		default:
+			findings = append(findings, fmt.Sprintf("removed %s %s (%s)", d.kind, d.name, d.file))
+		}
+	}
+	for key, file := range removedKeys {
+		if !addedKeys[key] {
+			findings = append(findings, fmt.Sprintf("removed config key %q (%s)", key, file))
+		}
+	}
+	slices.Sort(findings)
+	return slices.Compact(findings)
+}
+
+// publicFile reports whether declarations in f are visible to other
+// code: Go files outside package main and internal/ directories, and
+// JavaScript, TypeScript and Python sources.
+func publicFile(f fileDiff) bool {
+	switch path.Ext(f.Path) {
+	case ".go":
+		if slices.Contains(strings.Split(path.Dir(f.Path), "/"), "internal") {
+			return false
+		}
+		return goPackage(f) != "main"
+	case ".js", ".mjs", ".cjs", ".jsx", ".ts", ".mts", ".tsx", ".py":
+		return true
+	default:
+		return false
+	}
+}
+
+// goPackage returns the package name of the Go file in f, from the file
+// on disk or else from the diff.
+func goPackage(f fileDiff) string {
+	text := f.Text
+	if data, err := os.ReadFile(f.Path); err == nil {
+		text = string(data)
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimLeft(line, "+- ")
+		if name, ok := strings.CutPrefix(line, "package "); ok {
+			return strings.TrimSpace(name)
+		}
+	}
+	return ""
+}
+
+// parseDeclaration parses a public declaration on a line of file.
+func parseDeclaration(file, line string) (declaration, bool) {
+	scope := path.Dir(file)
+	switch path.Ext(file) {
+	case ".go":
+		if m := goFunc.FindStringSubmatch(line); m != nil {
+			name, kind := m[2], "function"
+			if m[1] != "" {
+				name, kind = m[1]+"."+m[2], "method"
+			}
+			return declaration{key: scope + "." + name, name: name, kind: kind, params: normalizeParams(m[3]), file: file}, true
+		}
+		if m := goType.FindStringSubmatch(line); m != nil {
+			return declaration{key: scope + "." + m[1], name: m[1], kind: "type", file: file}, true
+		}
+	case ".py":
+		if m := pyDef.FindStringSubmatch(line); m != nil && !strings.HasPrefix(m[2], "_") {
+			kind := map[string]string{"def": "function", "class": "class"}[m[1]]
+			return declaration{key: file + "." + m[2], name: m[2], kind: kind, params: normalizeParams(m[3]), file: file}, true
+		}
+	default:
+		if m := jsExport.FindStringSubmatch(line); m != nil {
+			kind, params := strings.TrimSuffix(m[1], "*"), ""
+			if kind == "function" {
+				params = normalizeParams(m[3])
+			}
+			return declaration{key: file + "." + m[2], name: m[2], kind: kind, params: params, file: file}, true
+		}
+	}
+	return declaration{}, false
+}
+
+// normalizeParams collapses the whitespace in a parameter list so that
+// reformatting isn't reported as a signature change.
+func normalizeParams(params string) string {
+	return strings.Join(strings.Fields(params), " ")
+}
+
+// renamedTo returns the name of the declaration d was renamed to: a new
+// one of the same kind and signature in the same file, or "".
+func renamedTo(d declaration, removed, added []declaration) string {
+	for _, a := range added {
+		isNew := !slices.ContainsFunc(removed, func(r declaration) bool { return r.key == a.key })
+		if isNew && a.file == d.file && a.kind == d.kind && a.params == d.params {
+			return a.name
+		}
+	}
+	return ""
+}
+
+// configKeys returns the config keys declared on a line of file: Go
+// struct tags, or the keys of YAML, TOML and JSON files named like config.
+func configKeys(file, line string) []string {
+	var keys []string
+	if path.Ext(file) == ".go" {
+		for _, m := range configTag.FindAllStringSubmatch(line, -1) {
+			if m[1] != "-" {
+				keys = append(keys, m[1])
+			}
+		}
+		return keys
+	}
+	base := strings.ToLower(path.Base(file))
+	switch path.Ext(base) {
+	case ".yaml", ".yml", ".toml", ".json":
+	default:
+		return nil
+	}
+	if !strings.Contains(base, "config") && !strings.Contains(base, "settings") {
+		return nil
+	}
+	if m := configKey.FindStringSubmatch(line); m != nil {
+		keys = append(keys, m[1]+m[2])
+	}
+	return keys
+}
+
+// markBreaking flags message as a breaking change when findings is not
+// empty: the conventional header gets a "!" and, unless the model already
+// wrote one, a BREAKING CHANGE footer explaining the break is added.
+func markBreaking(ctx context.Context, config *Config, message, diff string, findings []string) (string, error) {
+	if len(findings) == 0 {
+		return message, nil
+	}
+	msg := parseCommitMessage(message)
+	if commit, ok := parseConventional(msg.Subject); ok {
+		commit.Breaking = true
+		msg.Subject = commit.String()
+	}
+	if slices.ContainsFunc(msg.Footers, func(f footer) bool { return f.Token == "BREAKING CHANGE" }) {
+		return msg.String(), nil
+	}
+	list := "- " + strings.Join(findings, "\n- ") + "\n"
+	if overBudget(config, diff) {
+		diff = truncateToTokens(diff, config.diffBudget())
+	}
+	prompt := fmt.Sprintf(breakingPrompt, list, diff)
+	explanation, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	explanation = strings.Join(strings.Fields(stripQuotes(stripFences(explanation))), " ")
+	if explanation == "" {
+		explanation = strings.Join(findings, "; ")
+	}
+	msg.Footers = append(msg.Footers, footer{Token: "BREAKING CHANGE", Separator: ": ", Value: explanation})
+	return msg.String(), nil
+}
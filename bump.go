@@ -0,0 +1,133 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// semverTag matches tags like v1.2.3 or 1.2.3-rc.1, capturing the prefix
+// and the three numbers.
+var semverTag = regexp.MustCompile(`^([^0-9]*)(\d+)\.(\d+)\.(\d+)(?:[-+].*)?$`)
+
+// version is a parsed semantic version with the tag prefix it came with.
+type version struct {
+	Prefix              string
+	Major, Minor, Patch int
+}
+
+// parseVersion parses a semver tag; ok is false for anything else.
+func parseVersion(tag string) (v version, ok bool) {
+	m := semverTag.FindStringSubmatch(tag)
+	if m == nil {
+		return version{}, false
+	}
+	v.Prefix = m[1]
+	v.Major, _ = strconv.Atoi(m[2])
+	v.Minor, _ = strconv.Atoi(m[3])
+	v.Patch, _ = strconv.Atoi(m[4])
+	return v, true
+}
+
+// String formats v with its prefix.
+func (v version) String() string {
+	return fmt.Sprintf("%s%d.%d.%d", v.Prefix, v.Major, v.Minor, v.Patch)
+}
+
+// bumpResult is the recommendation printed by `bump`, and its --json form.
+type bumpResult struct {
+	Current  string `json:"current"`
+	Next     string `json:"next"`
+	Bump     string `json:"bump"`
+	Commits  int    `json:"commits"`
+	Breaking int    `json:"breaking"`
+	Features int    `json:"features"`
+	Fixes    int    `json:"fixes"`
+}
+
+// recommendBump applies the conventional commit rules: breaking changes
+// bump major, features minor and fixes or performance work patch. Before
+// 1.0.0 a breaking change only bumps minor, as the API is not yet stable.
+func recommendBump(current version, commits []logCommit) bumpResult {
+	result := bumpResult{Current: current.String(), Commits: len(commits)}
+	for _, commit := range commits {
+		parsed, _ := parseConventional(commit.Subject)
+		switch {
+		case isBreaking(commit.Subject, commit.Body):
+			result.Breaking++
+		case parsed.Type == "feat":
+			result.Features++
+		case parsed.Type == "fix" || parsed.Type == "perf":
+			result.Fixes++
+		}
+	}
+
+	next := current
+	switch {
+	case result.Breaking > 0 && current.Major > 0:
+		result.Bump = "major"
+		next = version{Prefix: current.Prefix, Major: current.Major + 1}
+	case result.Breaking > 0 || result.Features > 0:
+		result.Bump = "minor"
+		next = version{Prefix: current.Prefix, Major: current.Major, Minor: current.Minor + 1}
+	case result.Fixes > 0:
+		result.Bump = "patch"
+		next.Patch++
+	default:
+		result.Bump = "none"
+	}
+	result.Next = next.String()
+	return result
+}
+
+// latestVersionTag returns the most recent semver tag reachable from HEAD.
+func latestVersionTag() (string, version, bool) {
+	output, err := runGit("tag", "--merged", "HEAD", "--sort=-creatordate")
+	if err != nil {
+		return "", version{}, false
+	}
+	for _, tag := range strings.Fields(output) {
+		if v, ok := parseVersion(tag); ok {
+			return tag, v, true
+		}
+	}
+	return "", version{}, false
+}
+
+// runBump implements `git-commit-message bump`: recommend the next semantic
+// version from the commits since the last version tag.
+func runBump(args []string) error {
+	flags := flag.NewFlagSet("bump", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "print the recommendation as JSON")
+	flags.Parse(args)
+
+	revRange := "HEAD"
+	tag, current, ok := latestVersionTag()
+	if ok {
+		revRange = tag + "..HEAD"
+	} else {
+		current = version{Prefix: "v"}
+	}
+	commits, err := rangeCommits(revRange)
+	if err != nil {
+		return err
+	}
+	result := recommendBump(current, commits)
+
+	if *asJSON {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(result)
+	}
+	if result.Bump == "none" {
+		fmt.Printf("%s: no features or fixes in %d commit(s), no release needed\n", result.Current, result.Commits)
+		return nil
+	}
+	fmt.Printf("%s → %s (%s: %d breaking, %d feature(s), %d fix(es) in %d commit(s))\n",
+		result.Current, result.Next, result.Bump, result.Breaking, result.Features, result.Fixes, result.Commits)
+	return nil
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// cacheEntry is one cached suggestion on disk.
+type cacheEntry struct {
+	Message string    `json:"message"`
+	Created time.Time `json:"created"`
+}
+
+// defaultCacheDir returns $XDG_CACHE_HOME/git_commit_message, falling back
+// to %LOCALAPPDATA% on Windows and ~/.cache everywhere else.
+func defaultCacheDir() (string, error) {
+	if xdg := os.Getenv("XDG_CACHE_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, appDirName), nil
+	}
+	if localAppData := os.Getenv("LOCALAPPDATA"); runtime.GOOS == "windows" && localAppData != "" {
+		return filepath.Join(localAppData, appDirName), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".cache", appDirName), nil
+}
+
+// normalizeDiff makes the cache key insensitive to line endings and
+// trailing whitespace, which don't change what the message should say.
+func normalizeDiff(diff string) string {
+	lines := strings.Split(strings.ReplaceAll(diff, "\r\n", "\n"), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimRight(line, " \t")
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// cacheKey hashes everything that shapes the suggestion for diff: the
+// diff itself, the model, the prompt template and the settings that
+// commit-time decoration depends on.
+func cacheKey(config *Config, diff string) (string, error) {
+	config = config.forPackage(diff)
+	template, err := promptTemplate(config)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	for _, part := range []string{
+		normalizeDiff(diff),
+		providerLabel(config.providerConfig()),
+		template,
+		config.Convention,
+		config.packageScope,
+		config.Heuristics,
+		fmt.Sprint(config.DetectBreaking),
+		config.Language,
+		fmt.Sprint(config.Body),
+		fmt.Sprint(config.subjectLimit()),
+		config.ImperativeMood,
+		config.TicketPosition,
+		config.TicketPattern,
+		currentBranch(),
+		fmt.Sprint(config.Signoff),
+		strings.Join(config.CoAuthors, "\n"),
+		strings.Join(config.Closes, "\n"),
+		fmt.Sprint(config.ClosesFromBranch),
+		config.IssueBranchPattern,
+		config.IssuePlatform,
+		fmt.Sprint(config.IssueContext),
+		fmt.Sprint(config.Trailers),
+		fmt.Sprint(config.RiskTrailer),
+		fmt.Sprint(config.riskAreas()),
+		fmt.Sprint(config.Examples),
+	} {
+		h.Write([]byte(part))
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// cachedSuggestion returns a cached message for diff if one younger than
+// config.CacheTTL exists, otherwise it generates one and stores it. The
+// cache is best effort: failing to read or write it never fails the run.
+func cachedSuggestion(ctx context.Context, config *Config, diff string) (string, error) {
+	if config.CacheTTL <= 0 {
+		return suggestMessage(ctx, config, diff)
+	}
+	dir, err := defaultCacheDir()
+	if err != nil {
+		return suggestMessage(ctx, config, diff)
+	}
+	key, err := cacheKey(config, diff)
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, key+".json")
+
+	if data, err := os.ReadFile(path); err == nil {
+		var entry cacheEntry
+		if json.Unmarshal(data, &entry) == nil && entry.Message != "" && time.Since(entry.Created) < config.CacheTTL {
+			slog.Info("cache hit", "key", key[:12], "age", time.Since(entry.Created).Round(time.Second))
+			return entry.Message, nil
+		}
+	}
+
+	message, err := suggestMessage(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	data, err := json.Marshal(cacheEntry{Message: message, Created: time.Now()})
+	if err == nil {
+		if err := os.MkdirAll(dir, 0o700); err == nil {
+			err = os.WriteFile(path, data, 0o600)
+		}
+	}
+	if err != nil {
+		slog.Warn("could not write cache", "path", path, "err", err)
+	}
+	return message, nil
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// changelogSections are the Keep a Changelog headings in the order they
+// are printed, with the conventional types that go under each.
+var changelogSections = []struct {
+	Heading string
+	Types   []string
+}{
+	{"Added", []string{"feat"}},
+	{"Changed", []string{"perf", "refactor"}},
+	{"Removed", []string{"revert"}},
+	{"Fixed", []string{"fix"}},
+}
+
+// changelogInternalTypes describe changes users don't see. They are left
+// out unless --all is given, in which case they are listed under Changed.
+var changelogInternalTypes = []string{"docs", "style", "test", "build", "ci", "chore"}
+
+// changelogSummaryPrompt asks the model to tidy one section's entries.
+const changelogSummaryPrompt = "The following entries come from commit messages and belong in the '%s' section of a changelog. Rewrite them as concise, user-facing changelog entries: merge duplicates, drop purely internal details and keep any **BREAKING** markers. %sReply with one entry per line, each starting with '- ', and nothing else.\n\n" + systemSeparator + "%s"
+
+// changelogEntry formats a commit as a changelog bullet without its type.
+func changelogEntry(commit logCommit) string {
+	text := commit.Subject
+	if parsed, ok := parseConventional(commit.Subject); ok {
+		text = parsed.Subject
+		if parsed.Scope != "" {
+			text = fmt.Sprintf("**%s:** %s", parsed.Scope, text)
+		}
+	}
+	if isBreaking(commit.Subject, commit.Body) {
+		text = "**BREAKING** " + text
+	}
+	return text
+}
+
+// changelogSection returns the heading commit belongs under, or "" if it
+// is left out.
+func changelogSection(commit logCommit, all bool) string {
+	parsed, ok := parseConventional(commit.Subject)
+	if !ok {
+		// Without a type there is no telling, so it is listed as a change.
+		return "Changed"
+	}
+	for _, section := range changelogSections {
+		for _, t := range section.Types {
+			if parsed.Type == t {
+				return section.Heading
+			}
+		}
+	}
+	if !all && !isBreaking(commit.Subject, commit.Body) {
+		for _, t := range changelogInternalTypes {
+			if parsed.Type == t {
+				return ""
+			}
+		}
+	}
+	return "Changed"
+}
+
+// summarizeEntries asks the model to rewrite a section's entries. The
+// originals are kept if the reply has no usable bullets.
+func summarizeEntries(ctx context.Context, config *Config, heading string, entries []string) ([]string, error) {
+	var list strings.Builder
+	for _, entry := range entries {
+		list.WriteString("- " + entry + "\n")
+	}
+	language := ""
+	if config.Language != "" {
+		language = fmt.Sprintf("Write them in %s. ", languageName(config.Language))
+	}
+	reply, err := generateCommitMessage(ctx, config, fmt.Sprintf(changelogSummaryPrompt, heading, language, list.String()))
+	if err != nil {
+		return nil, err
+	}
+	var summarized []string
+	for _, line := range strings.Split(stripOuterFence(reply), "\n") {
+		if entry, ok := strings.CutPrefix(strings.TrimSpace(line), "- "); ok && strings.TrimSpace(entry) != "" {
+			summarized = append(summarized, strings.TrimSpace(entry))
+		}
+	}
+	if len(summarized) == 0 {
+		return entries, nil
+	}
+	return summarized, nil
+}
+
+// buildChangelog groups commits into Keep a Changelog sections under a
+// "## [release] - date" heading.
+func buildChangelog(ctx context.Context, config *Config, commits []logCommit, release string, all, summarize bool) (string, error) {
+	grouped := make(map[string][]string)
+	for _, commit := range commits {
+		if heading := changelogSection(commit, all); heading != "" {
+			grouped[heading] = append(grouped[heading], changelogEntry(commit))
+		}
+	}
+
+	var b strings.Builder
+	if release == "Unreleased" {
+		b.WriteString("## [Unreleased]\n")
+	} else {
+		fmt.Fprintf(&b, "## [%s] - %s\n", release, time.Now().Format("2006-01-02"))
+	}
+	for _, section := range changelogSections {
+		entries := grouped[section.Heading]
+		if len(entries) == 0 {
+			continue
+		}
+		if summarize {
+			var err error
+			if entries, err = summarizeEntries(ctx, config, section.Heading, entries); err != nil {
+				return "", fmt.Errorf("could not summarize %s: %w", section.Heading, err)
+			}
+		}
+		fmt.Fprintf(&b, "\n### %s\n\n", section.Heading)
+		for _, entry := range entries {
+			b.WriteString("- " + entry + "\n")
+		}
+	}
+	return b.String(), nil
+}
+
+// runChangelog implements `git-commit-message changelog <rev-range>`.
+func runChangelog(args []string) error {
+	flags := flag.NewFlagSet("changelog", flag.ExitOnError)
+	release := flags.String("release", "Unreleased", "version for the section heading, e.g. 1.3.0")
+	all := flags.Bool("all", false, "also list docs, test, build, ci, style and chore commits")
+	summarize := flags.Bool("summarize", false, "have the model rewrite each section's entries")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message changelog [flags] <rev-range>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	commits, err := rangeCommits(flags.Arg(0))
+	if err != nil {
+		return fmt.Errorf("invalid revision range %q: %w", flags.Arg(0), err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits in %s", flags.Arg(0))
+	}
+
+	// The config is only needed to reach the model.
+	config := defaultConfig()
+	if *summarize {
+		if config, err = resolveConfig(overrides); err != nil {
+			return err
+		}
+	}
+	changelog, err := buildChangelog(appCtx, config, commits, *release, *all, *summarize)
+	if err != nil {
+		return err
+	}
+	fmt.Print(changelog)
+	return nil
+}
@@ -0,0 +1,115 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// cleaner is one step of the pipeline that turns the model's reply into a
+// commit message.
+type cleaner struct {
+	name  string
+	apply func(string) string
+}
+
+// cleaners are the available steps, in the order they run.
+var cleaners = []cleaner{
+	{"fences", stripFences},
+	{"preamble", stripPreamble},
+	{"bullets", stripBullet},
+	{"quotes", stripQuotes},
+}
+
+// cleanerNames returns the names of every cleaner, the default pipeline.
+func cleanerNames() []string {
+	var names []string
+	for _, c := range cleaners {
+		names = append(names, c.name)
+	}
+	return names
+}
+
+// checkCleaners reports a cleaners entry that doesn't exist.
+func checkCleaners(names []string) error {
+	for _, name := range names {
+		if !slices.Contains(cleanerNames(), name) {
+			return fmt.Errorf("unknown cleaner %q (expected %s)", name, strings.Join(cleanerNames(), ", "))
+		}
+	}
+	return nil
+}
+
+// runCleaners applies the configured cleaners to msg, in pipeline order.
+func (c *Config) runCleaners(msg string) string {
+	for _, step := range cleaners {
+		if slices.Contains(c.Cleaners, step.name) {
+			msg = step.apply(msg)
+		}
+	}
+	return strings.TrimSpace(msg)
+}
+
+// thinkingBlock matches the reasoning that models such as deepseek-r1 and
+// qwen3 emit before their answer.
+var thinkingBlock = regexp.MustCompile(`(?is)<(think|thinking|reasoning)>.*?</(think|thinking|reasoning)>`)
+
+// stripThinking removes reasoning blocks from a reply. Some chat templates
+// drop the opening tag, so anything before a stray closing tag goes too.
+// It runs on every reply, since the reasoning is never part of the answer.
+func stripThinking(reply string) string {
+	reply = thinkingBlock.ReplaceAllString(reply, "")
+	lower := strings.ToLower(reply)
+	for _, tag := range []string{"</think>", "</thinking>", "</reasoning>"} {
+		if i := strings.LastIndex(lower, tag); i >= 0 {
+			reply, lower = reply[i+len(tag):], lower[i+len(tag):]
+		}
+	}
+	return strings.TrimSpace(reply)
+}
+
+// stripFences drops markdown fence lines such as ``` or ```text.
+func stripFences(msg string) string {
+	var lines []string
+	for _, line := range strings.Split(msg, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+// preamble matches chatty introductions such as "Here is the commit
+// message:" or "**Commit message:**", up to and including the colon.
+var preamble = regexp.MustCompile(`(?i)^\**\s*(?:(?:sure|okay|ok|certainly)[,.!]?\s*)?(?:here(?:'s| is| are)\b[^:\n]*|(?:the |a |suggested |proposed |git )*commit message(?: would be| is)?)\s*:\s*\**\s*`)
+
+// stripPreamble removes an introduction before the message, whether it is
+// on a line of its own or in front of the subject.
+func stripPreamble(msg string) string {
+	msg = strings.TrimSpace(msg)
+	for range 2 {
+		loc := preamble.FindStringIndex(msg)
+		if loc == nil {
+			break
+		}
+		msg = strings.TrimSpace(msg[loc[1]:])
+	}
+	return msg
+}
+
+// listMarker matches a bullet or number in front of the subject.
+var listMarker = regexp.MustCompile(`^(?:[-*•+]|\d+[.)])\s+`)
+
+// stripBullet removes a list marker from the subject line. Lists in the
+// body are left alone.
+func stripBullet(msg string) string {
+	return listMarker.ReplaceAllString(strings.TrimSpace(msg), "")
+}
+
+// stripQuotes removes quotes wrapped around the message. This includes
+// the typographic quotes models use when writing in other languages.
+func stripQuotes(msg string) string {
+	return strings.Trim(strings.TrimSpace(msg), messageQuotes)
+}
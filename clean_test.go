@@ -0,0 +1,139 @@
+package main
+
+import "testing"
+
+func TestStripThinking(t *testing.T) {
+	tests := []struct {
+		name, reply, want string
+	}{
+		{"no reasoning", "feat: add login", "feat: add login"},
+		{"think block", "<think>\nThe diff adds a login form.\n</think>\n\nfeat: add login", "feat: add login"},
+		{"thinking block", "<thinking>hmm</thinking>feat: add login", "feat: add login"},
+		{"reasoning block", "<Reasoning>hmm</Reasoning>\nfeat: add login", "feat: add login"},
+		{"opening tag dropped", "The diff adds a login form.\n</think>\nfeat: add login", "feat: add login"},
+		{"several blocks", "<think>a</think>\n<think>b</think>\nfeat: add login", "feat: add login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripThinking(tt.reply); got != tt.want {
+				t.Errorf("stripThinking(%q) = %q, want %q", tt.reply, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripPreamble(t *testing.T) {
+	tests := []struct {
+		name, msg, want string
+	}{
+		{"none", "feat: add login", "feat: add login"},
+		{"same line", "Here is the commit message: feat: add login", "feat: add login"},
+		{"own line", "Here's a commit message for these changes:\n\nfeat: add login", "feat: add login"},
+		{"bold", "**Commit message:**\nfeat: add login", "feat: add login"},
+		{"polite", "Sure! Here is the commit message:\nfeat: add login", "feat: add login"},
+		{"suggested", "Suggested commit message: fix: handle nil user", "fix: handle nil user"},
+		{"later mention kept", "fix: handle nil user\n\nThe commit message: was wrong.", "fix: handle nil user\n\nThe commit message: was wrong."},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripPreamble(tt.msg); got != tt.want {
+				t.Errorf("stripPreamble(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripFences(t *testing.T) {
+	tests := []struct {
+		name, msg, want string
+	}{
+		{"none", "feat: add login", "feat: add login"},
+		{"plain fence", "```\nfeat: add login\n```", "feat: add login"},
+		{"fence with language", "```text\nfeat: add login\n\nAdd a form.\n```\n", "feat: add login\n\nAdd a form."},
+		{"indented fence", "  ```\nfeat: add login\n  ```", "feat: add login"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripFences(tt.msg); got != tt.want {
+				t.Errorf("stripFences(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripBullet(t *testing.T) {
+	tests := []struct {
+		name, msg, want string
+	}{
+		{"none", "feat: add login", "feat: add login"},
+		{"dash", "- feat: add login", "feat: add login"},
+		{"star", "* feat: add login", "feat: add login"},
+		{"bullet", "• feat: add login", "feat: add login"},
+		{"number", "1. feat: add login", "feat: add login"},
+		{"number with parenthesis", "2) feat: add login", "feat: add login"},
+		{"body list kept", "feat: add login\n\n- add the form\n- add the route", "feat: add login\n\n- add the form\n- add the route"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripBullet(tt.msg); got != tt.want {
+				t.Errorf("stripBullet(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStripQuotes(t *testing.T) {
+	tests := []struct {
+		name, msg, want string
+	}{
+		{"none", "feat: add login", "feat: add login"},
+		{"double quotes", `"feat: add login"`, "feat: add login"},
+		{"backticks", "`feat: add login`", "feat: add login"},
+		{"typographic quotes", "“feat: add login”", "feat: add login"},
+		{"guillemets", "«feat: ajoute la connexion»", "feat: ajoute la connexion"},
+		{"corner brackets", "「feat: ログインを追加」", "feat: ログインを追加"},
+		{"inner quotes kept", `fix: handle "null" users`, `fix: handle "null" users`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripQuotes(tt.msg); got != tt.want {
+				t.Errorf("stripQuotes(%q) = %q, want %q", tt.msg, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRunCleaners(t *testing.T) {
+	reply := "Here is the commit message:\n```\n- \"feat: add login\"\n```"
+	tests := []struct {
+		name     string
+		cleaners []string
+		want     string
+	}{
+		{"all", cleanerNames(), "feat: add login"},
+		{"none", nil, reply},
+		{"fences only", []string{"fences"}, "Here is the commit message:\n- \"feat: add login\""},
+		{"fences and preamble", []string{"preamble", "fences"}, "- \"feat: add login\""},
+		{"without quotes", []string{"fences", "preamble", "bullets"}, "\"feat: add login\""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := &Config{Cleaners: tt.cleaners}
+			if got := config.runCleaners(reply); got != tt.want {
+				t.Errorf("runCleaners with %v = %q, want %q", tt.cleaners, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCheckCleaners(t *testing.T) {
+	if err := checkCleaners(cleanerNames()); err != nil {
+		t.Errorf("checkCleaners(%v) = %v, want nil", cleanerNames(), err)
+	}
+	if err := checkCleaners(nil); err != nil {
+		t.Errorf("checkCleaners(nil) = %v, want nil", err)
+	}
+	if err := checkCleaners([]string{"fences", "emoji"}); err == nil {
+		t.Error("checkCleaners accepted the unknown cleaner \"emoji\"")
+	}
+}
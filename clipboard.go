@@ -0,0 +1,53 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// psSetClipboard reads UTF-8 text from stdin and puts it on the Windows clipboard.
+const psSetClipboard = `[Console]::InputEncoding = [Text.Encoding]::UTF8; Set-Clipboard -Value ([Console]::In.ReadToEnd())`
+
+// clipboardCommand returns the command that copies its stdin to the
+// clipboard: pbcopy on macOS, PowerShell on Windows, and wl-copy, xclip or
+// xsel elsewhere, whichever is installed.
+func clipboardCommand() ([]string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return []string{"pbcopy"}, nil
+	case "windows":
+		return []string{"powershell", "-NoProfile", "-NonInteractive", "-Command", psSetClipboard}, nil
+	}
+	candidates := [][]string{
+		{"xclip", "-selection", "clipboard"},
+		{"xsel", "--clipboard", "--input"},
+		// WSL can reach the Windows clipboard.
+		{"clip.exe"},
+	}
+	if os.Getenv("WAYLAND_DISPLAY") != "" {
+		candidates = append([][]string{{"wl-copy"}}, candidates...)
+	}
+	for _, command := range candidates {
+		if _, err := exec.LookPath(command[0]); err == nil {
+			return command, nil
+		}
+	}
+	return nil, fmt.Errorf("no clipboard tool found (install wl-copy, xclip or xsel)")
+}
+
+// copyToClipboard puts text on the system clipboard.
+func copyToClipboard(text string) error {
+	command, err := clipboardCommand()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(command[0], command[1:]...)
+	cmd.Stdin = strings.NewReader(text)
+	if output, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s failed: %w: %s", command[0], err, strings.TrimSpace(string(output)))
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// closingKeyword starts the footers that close issues when the commit
+// reaches the default branch.
+const closingKeyword = "Closes"
+
+// defaultIssueBranchPattern finds the issue number in branch names such
+// as 123-fix-login, fix/123-login, issue-123 or gh-123.
+const defaultIssueBranchPattern = `(?:^|/)(?:issues?[-_]|gh-)?([0-9]+)(?:[-_]|$)`
+
+// issueReference matches "123", "#123" and references to issues in other
+// repositories such as "owner/repo#123".
+var issueReference = regexp.MustCompile(`^(?:([\w.-]+(?:/[\w.-]+)+)#|#)?([0-9]+)$`)
+
+// issueFromBranch extracts the issue number from branch using pattern.
+// If the pattern has a capture group, the first group is the number;
+// otherwise the whole match is.
+func issueFromBranch(branch, pattern string) (string, error) {
+	if pattern == "" {
+		pattern = defaultIssueBranchPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid issue_branch_pattern %q: %w", pattern, err)
+	}
+	m := re.FindStringSubmatch(branch)
+	switch {
+	case m == nil:
+		return "", nil
+	case len(m) > 1:
+		return m[1], nil
+	default:
+		return m[0], nil
+	}
+}
+
+// closingFooter returns the footer closing the issue ref on platform:
+// "Closes #123" for issues in the same repository, or "Closes
+// owner/repo#123" where the platform supports closing issues elsewhere.
+func closingFooter(platform, ref string) (footer, error) {
+	m := issueReference.FindStringSubmatch(strings.TrimSpace(ref))
+	if m == nil {
+		return footer{}, fmt.Errorf("invalid issue reference %q (expected 123, #123 or owner/repo#123)", ref)
+	}
+	repo, number := m[1], m[2]
+	if repo == "" {
+		return footer{Token: closingKeyword, Separator: " #", Value: number}, nil
+	}
+	switch {
+	case platform == platformBitbucket:
+		return footer{}, fmt.Errorf("bitbucket can only close issues in the same repository, not %q", ref)
+	case platform == platformGitHub && strings.Count(repo, "/") != 1:
+		return footer{}, fmt.Errorf("invalid GitHub issue reference %q (expected owner/repo#123)", ref)
+	}
+	return footer{Token: closingKeyword, Separator: " " + repo + "#", Value: number}, nil
+}
+
+// addClosingFooters adds a closing footer for each issue given with
+// --closes and, if enabled, the one named in the branch.
+func addClosingFooters(config *Config, message string) (string, error) {
+	refs := slices.Clone(config.Closes)
+	if config.ClosesFromBranch {
+		number, err := issueFromBranch(currentBranch(), config.IssueBranchPattern)
+		if err != nil {
+			return "", err
+		}
+		if number != "" && !slices.ContainsFunc(refs, func(ref string) bool { return strings.TrimPrefix(ref, "#") == number }) {
+			refs = append(refs, number)
+		}
+	}
+	if len(refs) == 0 {
+		return message, nil
+	}
+	platform := issuePlatform(config)
+	for _, ref := range refs {
+		f, err := closingFooter(platform, ref)
+		if err != nil {
+			return "", err
+		}
+		message = appendFooter(message, f)
+	}
+	return message, nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// codeReviewPrompt asks for a review of a diff as the JSON object
+// codeReviewReply decodes.
+const codeReviewPrompt = "You are reviewing the {{.Target}} below. Look for potential bugs (logic errors, unhandled errors, nil or out-of-range access, races, leaks, security problems), code that changed without tests covering it, and style issues (unclear names, dead code, inconsistencies with the surrounding code). Only report real, specific problems in the changed code, each once; don't praise the change or restate what it does. It is fine to report nothing.\n\nReply with only a JSON object with a \"findings\" array, where each finding has these keys:\n- \"category\": one of bug, tests, style\n- \"severity\": one of high, medium, low\n- \"file\": the path of the file, as in the diff\n- \"line\": the line number in the new version of the file, counted from the hunk headers, or 0 for the file as a whole\n- \"message\": the problem and how to fix it, in one or two sentences\n\n{{.LanguageInstructions}}\n\n" + systemSeparator + changesSection
+
+// codeReviewData is what codeReviewPrompt is executed against.
+type codeReviewData struct {
+	*promptData
+	// Target describes the diff, e.g. "staged changes".
+	Target string
+}
+
+// reviewFinding is one problem found by `review`.
+type reviewFinding struct {
+	Category string `json:"category"`
+	Severity string `json:"severity"`
+	File     string `json:"file"`
+	Line     int    `json:"line"`
+	Message  string `json:"message"`
+}
+
+// codeReviewReply is the JSON object codeReviewPrompt asks for.
+type codeReviewReply struct {
+	Findings []reviewFinding `json:"findings"`
+}
+
+// reviewCategory is a kind of finding and its heading in the text output.
+type reviewCategory struct{ name, heading string }
+
+// reviewCategories are the finding categories in the order they are printed.
+var reviewCategories = []reviewCategory{
+	{"bug", "🐛 Potential bugs"},
+	{"tests", "🧪 Missing tests"},
+	{"style", "🎨 Style"},
+}
+
+// codeReviewSchema is the JSON schema of codeReviewReply, for providers
+// that can constrain their output to one.
+func codeReviewSchema() json.RawMessage {
+	categories := make([]string, len(reviewCategories))
+	for i, c := range reviewCategories {
+		categories[i] = c.name
+	}
+	finding := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"category": map[string]any{"type": "string", "enum": categories},
+			"severity": map[string]any{"type": "string", "enum": []string{"high", "medium", "low"}},
+			"file":     map[string]any{"type": "string"},
+			"line":     map[string]any{"type": "integer"},
+			"message":  map[string]any{"type": "string"},
+		},
+		"required":             []string{"category", "severity", "file", "line", "message"},
+		"additionalProperties": false,
+	}
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"findings": map[string]any{"type": "array", "items": finding},
+		},
+		"required":             []string{"findings"},
+		"additionalProperties": false,
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+// runCodeReview implements `git-commit-message review [<commit>]`: a
+// review of the staged changes, a range or a commit, listing potential
+// bugs, missing tests and style issues.
+func runCodeReview(args []string) error {
+	flags := flag.NewFlagSet("review", flag.ExitOnError)
+	target := registerDiffTarget(flags)
+	jsonOutput := flags.Bool("json", false, "print the findings as JSON, with file and line")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message review [flags] [<commit>]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	diff, label, err := target.collect(config, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🤖 Reviewing the %s...\n", label)
+	findings, err := reviewDiff(appCtx, config, diff, label)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		data, err := json.MarshalIndent(codeReviewReply{Findings: findings}, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	printFindings(findings, label)
+	return nil
+}
+
+// reviewDiff asks the model to review diff, described by label, and
+// returns its findings, never nil.
+func reviewDiff(ctx context.Context, config *Config, diff, label string) ([]reviewFinding, error) {
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := renderPrompt(codeReviewPrompt, &codeReviewData{
+		promptData: newPromptData(config, diff, summaries),
+		Target:     label,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reply, err := generateJSON(ctx, config, prompt, codeReviewSchema())
+	if err != nil {
+		return nil, err
+	}
+	return parseCodeReview(reply)
+}
+
+// parseCodeReview decodes the JSON object in reply, dropping empty
+// findings and filing those in an unknown category under style.
+func parseCodeReview(reply string) ([]reviewFinding, error) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the model's review is not a JSON object")
+	}
+	var r codeReviewReply
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &r); err != nil {
+		return nil, fmt.Errorf("failed to decode the model's review: %w", err)
+	}
+	findings := []reviewFinding{}
+	for _, f := range r.Findings {
+		f.Message = strings.TrimSpace(f.Message)
+		if f.Message == "" {
+			continue
+		}
+		f.Category = strings.ToLower(strings.TrimSpace(f.Category))
+		if !slices.ContainsFunc(reviewCategories, func(c reviewCategory) bool { return c.name == f.Category }) {
+			f.Category = "style"
+		}
+		f.Severity = strings.ToLower(strings.TrimSpace(f.Severity))
+		f.Line = max(f.Line, 0)
+		findings = append(findings, f)
+	}
+	return findings, nil
+}
+
+// printFindings lists findings under their category's heading.
+func printFindings(findings []reviewFinding, label string) {
+	if len(findings) == 0 {
+		fmt.Printf("✅ No issues found in the %s.\n", label)
+		return
+	}
+	first := true
+	for _, c := range reviewCategories {
+		var lines []string
+		for _, f := range findings {
+			if f.Category != c.name {
+				continue
+			}
+			location := f.File
+			if f.Line > 0 {
+				location = fmt.Sprintf("%s:%d", f.File, f.Line)
+			}
+			line := "  - "
+			if location != "" {
+				line += location + " "
+			}
+			if f.Severity != "" {
+				line += "(" + f.Severity + ") "
+			}
+			lines = append(lines, line+f.Message)
+		}
+		if len(lines) == 0 {
+			continue
+		}
+		if !first {
+			fmt.Println()
+		}
+		first = false
+		fmt.Println(c.heading)
+		fmt.Println(strings.Join(lines, "\n"))
+	}
+}
@@ -0,0 +1,147 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// fixMessagePrompt asks the model to correct a hand-written message that
+// failed lint, keeping what its author meant.
+const fixMessagePrompt = "A developer wrote the git commit message below for the diff that follows, but it breaks the project's rules. Rewrite it so that it fixes every listed problem and has a subject line {{.Convention}}. Keep the author's meaning and any details the diff doesn't show, such as why the change was made. {{.LanguageInstructions}}Do not include any explanation, preamble, or markdown formatting. Just the corrected commit message itself.\n\n" + systemSeparator + "Problems:\n{{range .Problems}}- {{.}}\n{{end}}\nCommit message:\n```\n{{.Message}}\n```\n\n" + changesSection
+
+// errMessageRejected is returned by the commit-msg hook for messages that
+// fail lint, which makes git abort the commit.
+var errMessageRejected = errors.New("commit message rejected")
+
+// commitMsg implements the commit-msg hook: it lints the message git passes
+// in a file and rejects it, or with --fix (or hook_fix) corrects it, first
+// locally and then with the model's help.
+func commitMsg(args []string) error {
+	flags := flag.NewFlagSet("hook commit-msg", flag.ExitOnError)
+	fix := flags.Bool("fix", false, "correct a failing message instead of rejecting it")
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		return fmt.Errorf(hookUsage)
+	}
+	messageFile := flags.Arg(0)
+	data, err := os.ReadFile(messageFile)
+	if err != nil {
+		return fmt.Errorf("could not read commit message file %s: %w", messageFile, err)
+	}
+	// An empty message makes git abort by itself.
+	message := stripComments(string(data))
+	if message == "" {
+		return nil
+	}
+
+	config, err := resolveSettings(nil)
+	if err != nil {
+		return err
+	}
+	problems := lintMessage(config, message)
+	if len(problems) == 0 {
+		return nil
+	}
+	if !*fix && !config.HookFix {
+		reportProblems("The commit message does not follow the rules:", problems)
+		return errMessageRejected
+	}
+
+	fixed := fixLocally(config, message)
+	if remaining := lintMessage(config, fixed); len(remaining) > 0 {
+		if fixed, err = fixWithModel(appCtx, fixed, remaining); err != nil {
+			reportProblems("The commit message does not follow the rules:", problems)
+			fmt.Fprintf(os.Stderr, "git-commit-message: could not fix it: %v\n", err)
+			return errMessageRejected
+		}
+		if remaining = lintMessage(config, fixed); len(remaining) > 0 {
+			reportProblems("The commit message still does not follow the rules after fixing it:", remaining)
+			return errMessageRejected
+		}
+	}
+	if err := os.WriteFile(messageFile, []byte(fixed+"\n"), 0o644); err != nil {
+		return fmt.Errorf("could not write commit message file %s: %w", messageFile, err)
+	}
+	fmt.Fprintf(os.Stderr, "✏️  Fixed the commit message:\n%s\n", fixed)
+	return nil
+}
+
+// reportProblems prints lint problems the way `lint` does, to stderr.
+func reportProblems(title string, problems []string) {
+	fmt.Fprintln(os.Stderr, "❌ "+title)
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "   - %s\n", problem)
+	}
+}
+
+// fixLocally makes the corrections that need no model: the subject is put
+// in the imperative mood and, for gitmoji, given its emoji, a blank line is
+// put after the subject, and body paragraphs with lines over the limit are
+// rewrapped. The rest of the body is left as its author wrote it.
+func fixLocally(config *Config, message string) string {
+	if config.ImperativeMood != moodOff && config.english() {
+		message = imperativeSubject(message)
+	}
+	if config.Convention == conventionGitmoji {
+		message = toGitmoji(message)
+	}
+	subject, rest, ok := strings.Cut(message, "\n")
+	if !ok {
+		return message
+	}
+	body := rest
+	if line, after, _ := strings.Cut(rest, "\n"); strings.TrimSpace(line) == "" {
+		body = after
+	}
+	if limit := config.Lint.MaxBodyLineLength; limit > 0 {
+		paragraphs := strings.Split(body, "\n\n")
+		for i, paragraph := range paragraphs {
+			if _, footers := parseFooters(strings.TrimSpace(paragraph)); footers || strings.Contains(paragraph, "```") {
+				continue
+			}
+			if slices.ContainsFunc(strings.Split(paragraph, "\n"), func(line string) bool {
+				return displayWidth(line) > limit && !strings.Contains(line, "://")
+			}) {
+				paragraphs[i] = wrapParagraph(strings.Trim(paragraph, "\n"), limit)
+			}
+		}
+		body = strings.Join(paragraphs, "\n\n")
+	}
+	return subject + "\n\n" + body
+}
+
+// fixWithModel asks the model to correct message, which has problems,
+// using the staged diff. Only then is the provider config needed.
+func fixWithModel(ctx context.Context, message string, problems []string) (string, error) {
+	config, err := resolveConfig(nil)
+	if err != nil {
+		return "", err
+	}
+	diff, err := collectDiff(config, diffStaged)
+	if err != nil {
+		return "", err
+	}
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	prompt, err := renderPrompt(fixMessagePrompt, &reviewMessageData{
+		promptData: newPromptData(config, diff, summaries),
+		Message:    message,
+		Problems:   problems,
+	})
+	if err != nil {
+		return "", err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	fixed := cleanMultilineMessage(config.runCleaners(reply))
+	return fixLocally(config, fixed), nil
+}
@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// subcommands lists what can follow git-commit-message, for completion.
+// Each child list holds the words accepted right after that subcommand.
+var subcommands = map[string][]string{
+	"": {
+		"annotate", "auth", "branch", "bump", "changelog", "completion", "config", "daemon", "doctor", "explain", "fixup",
+		"history", "hook", "install-hook", "lint", "mcp", "models", "pr", "release-notes", "review", "review-message",
+		"revert", "risk", "reword", "serve", "split", "squash", "stash", "tag-message", "translate", "uninstall-hook",
+		"version",
+	},
+	"auth":        {"login", "logout"},
+	"auth login":  keyringAccounts(),
+	"auth logout": keyringAccounts(),
+	"completion":  {"bash", "zsh", "fish", "powershell"},
+	"config":      {"init", "validate", "show"},
+	"history":     {"show", "use"},
+	"models":      {"use"},
+}
+
+// flagTypes are the type names flag.PrintDefaults shows for flags that
+// take a value.
+var flagTypes = []string{"string", "int", "uint", "int64", "uint64", "float", "duration", "value"}
+
+// completionFlag is a flag parsed from a subcommand's -h output.
+type completionFlag struct {
+	name       string
+	takesValue bool
+}
+
+// commandFlags lists the flags of the subcommand at path by running it with
+// -h, so completions always match the flag sets the commands really parse.
+func commandFlags(path []string) []completionFlag {
+	self, err := os.Executable()
+	if err != nil {
+		return nil
+	}
+	// -h prints the usage and exits, with a non-zero status for some commands.
+	output, _ := exec.Command(self, append(slices.Clone(path), "-h")...).CombinedOutput()
+
+	var flags []completionFlag
+	scanner := bufio.NewScanner(strings.NewReader(string(output)))
+	for scanner.Scan() {
+		line, ok := strings.CutPrefix(scanner.Text(), "  -")
+		if !ok {
+			continue
+		}
+		head, _, _ := strings.Cut(line, "\t")
+		fields := strings.Fields(head)
+		if len(fields) == 0 {
+			continue
+		}
+		takesValue := len(fields) > 1 && slices.Contains(flagTypes, fields[1])
+		flags = append(flags, completionFlag{name: fields[0], takesValue: takesValue})
+	}
+	return flags
+}
+
+// flagValues completes the value of flag name, or returns nil to let the
+// shell complete file names.
+func flagValues(name string) []string {
+	switch name {
+	case "provider":
+		return wizardProviders
+	case "output":
+		return []string{"text", "json"}
+	case "model":
+		config, err := resolveSettings(nil)
+		if err != nil {
+			return nil
+		}
+		if name := strings.ToLower(config.Provider); name != "" && name != "ollama" {
+			return nil
+		}
+		ctx, cancel := context.WithTimeout(appCtx, 2*time.Second)
+		defer cancel()
+		models, _ := provider.OllamaModels(ctx, config.providerConfig())
+		return modelNames(models)
+	}
+	return nil
+}
+
+// completeWords returns the candidates for the last of words, which are the
+// command line after the program name up to the cursor.
+func completeWords(words []string) []string {
+	if len(words) == 0 {
+		words = []string{""}
+	}
+	current, before := words[len(words)-1], words[:len(words)-1]
+
+	// Subcommands only come first, so the path is a prefix of the words.
+	var path []string
+	for _, word := range before {
+		if !slices.Contains(subcommands[strings.Join(path, " ")], word) {
+			break
+		}
+		path = append(path, word)
+	}
+
+	var candidates []string
+	switch {
+	case strings.HasPrefix(current, "-"):
+		for _, f := range commandFlags(path) {
+			if len(f.name) == 1 {
+				candidates = append(candidates, "-"+f.name)
+			} else {
+				candidates = append(candidates, "--"+f.name)
+			}
+		}
+	case len(before) > len(path) && strings.HasPrefix(before[len(before)-1], "-"):
+		name := strings.TrimLeft(before[len(before)-1], "-")
+		for _, f := range commandFlags(path) {
+			if f.name == name && f.takesValue {
+				candidates = flagValues(name)
+			}
+		}
+	case len(before) == len(path):
+		candidates = subcommands[strings.Join(path, " ")]
+	}
+
+	var matches []string
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, current) {
+			matches = append(matches, candidate)
+		}
+	}
+	return matches
+}
+
+// completionScripts call the hidden `__complete` subcommand with the words
+// up to the cursor and fall back to file names when it prints nothing.
+var completionScripts = map[string]string{
+	"bash": `# bash completion for git-commit-message
+_git_commit_message() {
+    local IFS=$'\n'
+    COMPREPLY=($(git-commit-message __complete "${COMP_WORDS[@]:1:COMP_CWORD}" 2>/dev/null))
+}
+complete -o default -F _git_commit_message git-commit-message
+`,
+	"zsh": `#compdef git-commit-message
+# zsh completion for git-commit-message
+_git_commit_message() {
+    local -a candidates
+    candidates=("${(@f)$(git-commit-message __complete "${(@)words[2,CURRENT]}" 2>/dev/null)}")
+    if [[ -n "${candidates[1]}" ]]; then
+        compadd -a candidates
+    else
+        _files
+    fi
+}
+compdef _git_commit_message git-commit-message
+`,
+	"fish": `# fish completion for git-commit-message
+function __git_commit_message_complete
+    set -l tokens (commandline -opc) (commandline -ct)
+    git-commit-message __complete $tokens[2..-1] 2>/dev/null
+end
+complete -c git-commit-message -a '(__git_commit_message_complete)'
+`,
+	"powershell": `# PowerShell completion for git-commit-message
+Register-ArgumentCompleter -Native -CommandName git-commit-message -ScriptBlock {
+    param($wordToComplete, $commandAst, $cursorPosition)
+    $words = @($commandAst.CommandElements | Select-Object -Skip 1 | ForEach-Object { $_.ToString() })
+    if ($wordToComplete -eq '') { $words += '""' }
+    & git-commit-message __complete @words 2>$null | ForEach-Object {
+        [System.Management.Automation.CompletionResult]::new($_, $_, 'ParameterValue', $_)
+    }
+}
+`,
+}
+
+// runCompletion implements `git-commit-message completion <shell>`.
+func runCompletion(args []string) error {
+	shells := subcommands["completion"]
+	if len(args) != 1 || !slices.Contains(shells, args[0]) {
+		fmt.Fprintf(os.Stderr, "Usage: git-commit-message completion %s\n", strings.Join(shells, "|"))
+		os.Exit(2)
+	}
+	_, err := fmt.Print(completionScripts[args[0]])
+	return err
+}
+
+// runComplete implements the hidden `__complete` subcommand used by the
+// completion scripts.
+func runComplete(args []string) error {
+	// PowerShell can't pass an empty argument, so it passes "" quoted.
+	if n := len(args); n > 0 && args[n-1] == `""` {
+		args[n-1] = ""
+	}
+	for _, candidate := range completeWords(args) {
+		fmt.Println(candidate)
+	}
+	return nil
+}
@@ -0,0 +1,1111 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// Config struct mirrors the structure of our config.yaml file.
+type Config struct {
+	Provider    string  `yaml:"provider"`
+	OllamaURL   string  `yaml:"ollama_url"`
+	BaseURL     string  `yaml:"base_url"`
+	APIKey      string  `yaml:"api_key"`
+	Model       string  `yaml:"model"`
+	Temperature float64 `yaml:"temperature"`
+	MaxTokens   int     `yaml:"max_tokens"`
+
+	// SystemPrompt sends the instructions as a system message, separate
+	// from the diff. Turn it off for models that ignore system prompts.
+	SystemPrompt bool `yaml:"system_prompt"`
+
+	// TopP, TopK, RepeatPenalty, NumCtx and Seed are passed to Ollama as
+	// sampling options (max_tokens is its num_predict). Unset keys keep the
+	// model's defaults.
+	TopP          float64 `yaml:"top_p"`
+	TopK          int     `yaml:"top_k"`
+	RepeatPenalty float64 `yaml:"repeat_penalty"`
+	NumCtx        int     `yaml:"num_ctx"`
+	Seed          *int    `yaml:"seed"`
+
+	// Deterministic makes the same diff always give the same message:
+	// temperature 0, a fixed seed unless one is set, and no cache.
+	Deterministic bool `yaml:"deterministic"`
+
+	// StructuredOutput asks the model for the message as JSON fields and
+	// assembles the conventional commit from them, instead of cleaning up
+	// free text. It is ignored when a custom prompt template is set.
+	StructuredOutput bool `yaml:"structured_output"`
+
+	// SafetySettings is only used by the gemini provider.
+	SafetySettings []provider.SafetySetting `yaml:"safety_settings"`
+
+	// Deployment and APIVersion are only used by the azure provider.
+	Deployment string `yaml:"deployment"`
+	APIVersion string `yaml:"api_version"`
+
+	// AWSRegion and AWSProfile are only used by the bedrock provider.
+	AWSRegion  string `yaml:"aws_region"`
+	AWSProfile string `yaml:"aws_profile"`
+
+	// ConventionalRetries is how many times the model is re-prompted when its
+	// reply isn't a valid conventional commit message. 0 disables validation.
+	ConventionalRetries int `yaml:"conventional_retries"`
+
+	// ImperativeMood is how subject lines that don't start with an
+	// imperative verb are fixed: "rewrite" (the default) rewrites known
+	// verbs locally, "model" also asks the model again, "off" leaves them.
+	ImperativeMood string `yaml:"imperative_mood"`
+
+	// StrictSubject caps the subject line at strictSubjectLength columns
+	// instead of lint.max_subject_length, for both generated and linted messages.
+	StrictSubject bool `yaml:"strict_subject"`
+
+	// Body asks for a subject line plus a wrapped body and footers instead
+	// of a single line.
+	Body bool `yaml:"body"`
+
+	// PromptTemplate replaces the built-in prompt with a Go text/template.
+	// PromptTemplateFile reads the template from a file instead.
+	PromptTemplate     string `yaml:"prompt_template"`
+	PromptTemplateFile string `yaml:"prompt_template_file"`
+
+	// Convention is the message style: "conventional" (the default) or "gitmoji".
+	Convention string `yaml:"convention"`
+
+	// Heuristics decides what happens with diffs whose commit type is
+	// obvious, such as docs-only or whitespace-only changes: "constrain"
+	// (the default) tells the model the type and enforces it, "skip"
+	// writes the message without the model and "off" leaves it all to
+	// the model.
+	Heuristics string `yaml:"heuristics"`
+	// DetectBreaking looks for removed or changed public functions and
+	// removed config keys, and marks such commits as breaking changes.
+	DetectBreaking bool `yaml:"detect_breaking"`
+	// WorkspaceScopes uses the name of the package a change is confined to
+	// as its scope, for monorepos with a go.work, package.json workspaces
+	// or a Cargo workspace. Packages overrides settings per package; its
+	// keys are package names or directories relative to the repository root.
+	WorkspaceScopes bool                     `yaml:"workspace_scopes"`
+	Packages        map[string]PackageConfig `yaml:"packages"`
+	// packageScope is the scope forPackage found for the diff at hand.
+	packageScope string
+	// MergeMessages describes the incoming changes and conflict
+	// resolutions in the message of a merge in progress.
+	MergeMessages bool `yaml:"merge_messages"`
+
+	// Language is the language messages are written in, e.g. "de" or "pt-BR".
+	// Empty leaves it to the model, which normally means English.
+	Language string `yaml:"language"`
+
+	// TicketPosition enables ticket key injection from the branch name:
+	// "prefix" ("JIRA-1: feat: ..."), "suffix" ("feat: ... (JIRA-1)") or
+	// "footer" ("Refs: JIRA-1"). Empty disables it.
+	TicketPosition string `yaml:"ticket_position"`
+	// TicketPattern is the regexp that finds the key in the branch name.
+	TicketPattern string `yaml:"ticket_pattern"`
+	// TicketFooter is the footer token used with position "footer".
+	TicketFooter string `yaml:"ticket_footer"`
+	// Jira, when its URL is set, looks up the ticket from the branch and
+	// includes its summary and description in the prompt.
+	Jira JiraConfig `yaml:"jira"`
+
+	// Exclude lists gitignore-style globs for files left out of the diff
+	// sent to the model, e.g. lock files and generated code.
+	Exclude []string `yaml:"exclude"`
+
+	// TokenBudget is the estimated diff size, in tokens, above which each
+	// file is summarised separately before the message is written. 0 disables it.
+	TokenBudget int `yaml:"token_budget"`
+
+	// ContextWindow is how many tokens the model accepts, for models
+	// contextWindows doesn't know or an Ollama server with a larger num_ctx.
+	// Budget splits it between the diff, style examples and the reply.
+	ContextWindow int          `yaml:"context_window"`
+	Budget        BudgetConfig `yaml:"budget"`
+
+	// Redact masks API keys, tokens, private keys and .env values in the
+	// diff before it is sent anywhere. On by default.
+	Redact bool `yaml:"redact"`
+
+	// Timeout bounds each request to the provider and ConnectTimeout the
+	// connection within it, e.g. "90s". Retries is how many times transient
+	// failures (timeouts, 429, 5xx) are retried with exponential backoff.
+	Timeout        time.Duration `yaml:"timeout"`
+	ConnectTimeout time.Duration `yaml:"connect_timeout"`
+	Retries        int           `yaml:"retries"`
+
+	// CABundle is a PEM file of extra certificate authorities to trust, for
+	// endpoints behind a TLS-intercepting proxy. InsecureSkipVerify turns
+	// certificate verification off; only use it for testing.
+	CABundle           string `yaml:"ca_bundle"`
+	InsecureSkipVerify bool   `yaml:"insecure_skip_verify"`
+
+	// AuthToken (a bearer token), BasicAuth and Headers authenticate against
+	// servers behind a reverse proxy, e.g. a remote Ollama behind nginx or
+	// Cloudflare Access. $VARIABLES in header values are expanded.
+	AuthToken string              `yaml:"auth_token"`
+	BasicAuth *provider.BasicAuth `yaml:"basic_auth"`
+	Headers   map[string]string   `yaml:"headers"`
+
+	// HistoryExamples is how many recent commit subjects are shown to the
+	// model as style examples. 0 disables it.
+	HistoryExamples int `yaml:"history_examples"`
+
+	// Cleaners are the steps that tidy the model's reply, see cleaners.
+	// Reasoning blocks are always removed.
+	Cleaners []string `yaml:"cleaners"`
+
+	// Examples are sample changes with their commit message, sent to the
+	// model as earlier chat turns.
+	Examples []Example `yaml:"examples"`
+
+	// Fallbacks are tried in order when the primary provider fails.
+	Fallbacks []FallbackConfig `yaml:"fallbacks"`
+
+	// KeepAlive tells Ollama how long to keep the model loaded after a
+	// request, e.g. "30m". Empty uses the server default.
+	KeepAlive string `yaml:"keep_alive"`
+
+	// AutoPull pulls a missing Ollama model without asking. Otherwise the
+	// pull is offered when running in a terminal.
+	AutoPull bool `yaml:"auto_pull"`
+
+	// CacheTTL is how long generated messages are reused for an identical
+	// diff, model and template. 0 disables the cache.
+	CacheTTL time.Duration `yaml:"cache_ttl"`
+
+	// Lint holds the rules checked by `lint`.
+	Lint LintConfig `yaml:"lint"`
+	// HookFix makes the commit-msg hook correct messages that fail lint
+	// instead of rejecting them.
+	HookFix bool `yaml:"hook_fix"`
+
+	// Signoff adds a Signed-off-by trailer with your git identity, and
+	// CoAuthors a Co-authored-by trailer for each "Name <email>".
+	Signoff   bool     `yaml:"signoff"`
+	CoAuthors []string `yaml:"co_authors"`
+	// Trailers are extra trailers appended to every message.
+	Trailers []TrailerConfig `yaml:"trailers"`
+	// RiskTrailer adds a "Risk: low|medium|high" trailer with the change's
+	// rating from `risk`. RiskAreas adds to or replaces, by name, the glob
+	// patterns of the sensitive areas the rating looks for.
+	RiskTrailer bool                `yaml:"risk_trailer"`
+	RiskAreas   map[string][]string `yaml:"risk_areas"`
+	// riskLevel is the Risk trailer's value for the diff being described,
+	// set by withRisk.
+	riskLevel string
+	// KeepHistory logs each run's suggestions and whether they were
+	// committed as they were, for `history`. It is off unless turned on.
+	KeepHistory bool `yaml:"keep_history"`
+	// HistoryPrompts also logs the prompt, which holds the diff, with each
+	// entry.
+	HistoryPrompts bool `yaml:"history_prompts"`
+	// NotesRef is the notes ref `annotate` adds its explanations to, e.g.
+	// "ai-explanations"; by default git's own, refs/notes/commits.
+	NotesRef string `yaml:"notes_ref"`
+
+	// Closes adds a "Closes #123" footer for each issue, and
+	// ClosesFromBranch one for the issue number in the branch name, found
+	// with IssueBranchPattern. IssuePlatform ("github", "gitlab" or
+	// "bitbucket") decides the syntax; by default it is guessed from the
+	// host of IssueRemote ("origin" unless set).
+	Closes             []string `yaml:"closes"`
+	ClosesFromBranch   bool     `yaml:"closes_from_branch"`
+	IssueBranchPattern string   `yaml:"issue_branch_pattern"`
+	IssueRemote        string   `yaml:"issue_remote"`
+	IssuePlatform      string   `yaml:"issue_platform"`
+	// IssueContext fetches the title and labels of the issue in the branch
+	// name (and of --closes issues) and includes them in the prompt.
+	IssueContext bool `yaml:"issue_context"`
+	// IssueAPIURL overrides the platform's API base URL, for self-hosted
+	// instances that don't serve it at the usual place.
+	IssueAPIURL string `yaml:"issue_api_url"`
+
+	// LogLevel is the stderr log level: debug, info, warn (the default) or error.
+	LogLevel string `yaml:"log_level"`
+}
+
+// FallbackConfig describes one entry of the `fallbacks:` list. Timeouts,
+// retries and sampling options are shared with the primary provider.
+type FallbackConfig struct {
+	Provider   string `yaml:"provider"`
+	BaseURL    string `yaml:"base_url"`
+	APIKey     string `yaml:"api_key"`
+	Model      string `yaml:"model"`
+	Deployment string `yaml:"deployment"`
+	APIVersion string `yaml:"api_version"`
+	AWSRegion  string `yaml:"aws_region"`
+	AWSProfile string `yaml:"aws_profile"`
+	// AuthToken, BasicAuth and Headers are not shared with the primary
+	// provider, so its credentials are never sent to another host.
+	AuthToken string              `yaml:"auth_token"`
+	BasicAuth *provider.BasicAuth `yaml:"basic_auth"`
+	Headers   map[string]string   `yaml:"headers"`
+	// SystemPrompt overrides the primary provider's system_prompt.
+	SystemPrompt *bool `yaml:"system_prompt"`
+}
+
+// Supported values for Config.Convention.
+const (
+	conventionConventional = "conventional"
+	conventionGitmoji      = "gitmoji"
+)
+
+// defaultConfig returns the values used for keys missing from every config layer.
+func defaultConfig() *Config {
+	return &Config{
+		SystemPrompt:        true,
+		DetectBreaking:      true,
+		WorkspaceScopes:     true,
+		MergeMessages:       true,
+		ConventionalRetries: 2,
+		TokenBudget:         8000,
+		Redact:              true,
+		Timeout:             provider.DefaultTimeout,
+		ConnectTimeout:      provider.DefaultConnectTimeout,
+		Retries:             2,
+		HistoryExamples:     10,
+		CacheTTL:            24 * time.Hour,
+		Lint:                defaultLintConfig(),
+		Budget:              defaultBudgetConfig(),
+		Cleaners:            cleanerNames(),
+	}
+}
+
+// providerConfig translates the user-facing config into what the backends need.
+func (c *Config) providerConfig() provider.Config {
+	url := c.BaseURL
+	// ollama_url predates base_url and is still the documented key for Ollama.
+	if url == "" && (c.Provider == "" || strings.EqualFold(c.Provider, "ollama")) {
+		url = c.OllamaURL
+	}
+	return provider.Config{
+		Provider:           c.Provider,
+		URL:                url,
+		APIKey:             c.APIKey,
+		Model:              c.Model,
+		SafetySettings:     c.SafetySettings,
+		Deployment:         c.Deployment,
+		APIVersion:         c.APIVersion,
+		AWSRegion:          c.AWSRegion,
+		AWSProfile:         c.AWSProfile,
+		Timeout:            c.Timeout,
+		ConnectTimeout:     c.ConnectTimeout,
+		Retries:            c.Retries,
+		KeepAlive:          c.KeepAlive,
+		NoSystemPrompt:     !c.SystemPrompt,
+		CABundle:           c.CABundle,
+		InsecureSkipVerify: c.InsecureSkipVerify,
+		AuthToken:          c.AuthToken,
+		BasicAuth:          c.BasicAuth,
+		Headers:            expandHeaders(c.Headers),
+	}
+}
+
+// expandHeaders returns headers with environment variables expanded in the
+// values, so secrets can stay out of the config file.
+func expandHeaders(headers map[string]string) map[string]string {
+	if headers == nil {
+		return nil
+	}
+	expanded := make(map[string]string, len(headers))
+	for key, value := range headers {
+		expanded[key] = os.ExpandEnv(value)
+	}
+	return expanded
+}
+
+// fallbackConfigs returns the provider configs for the primary provider
+// followed by each fallback.
+func (c *Config) fallbackConfigs() []provider.Config {
+	configs := []provider.Config{c.providerConfig()}
+	for _, f := range c.Fallbacks {
+		pc := c.providerConfig()
+		pc.Provider = f.Provider
+		pc.URL = f.BaseURL
+		pc.APIKey = f.APIKey
+		pc.Model = f.Model
+		pc.Deployment = f.Deployment
+		pc.APIVersion = f.APIVersion
+		pc.AWSRegion = f.AWSRegion
+		pc.AWSProfile = f.AWSProfile
+		pc.AuthToken = f.AuthToken
+		pc.BasicAuth = f.BasicAuth
+		pc.Headers = expandHeaders(f.Headers)
+		if f.SystemPrompt != nil {
+			pc.NoSystemPrompt = !*f.SystemPrompt
+		}
+		configs = append(configs, pc)
+	}
+	return configs
+}
+
+// generator returns the backend for this config. When a daemon is
+// listening, requests are forwarded to it; otherwise this is the primary
+// provider, or a provider.Chain when fallbacks are configured. API keys
+// not set in the config are looked up in the OS keyring.
+func (c *Config) generator() (provider.Generator, error) {
+	configs := withKeyringKeys(c.fallbackConfigs())
+	if client := dialDaemon(configs); client != nil {
+		return client, nil
+	}
+	return newGenerator(configs)
+}
+
+// newGenerator builds the backend for configs: a single provider, or a
+// provider.Chain trying them in order.
+func newGenerator(configs []provider.Config) (provider.Generator, error) {
+	if len(configs) == 1 {
+		return provider.New(configs[0])
+	}
+
+	chain := &provider.Chain{
+		OnFallback: func(failed, next string, err error) {
+			fmt.Fprintf(os.Stderr, "⚠️  %s failed (%v); falling back to %s\n", failed, err, next)
+		},
+	}
+	for _, pc := range configs {
+		generator, err := provider.New(pc)
+		if err != nil {
+			return nil, err
+		}
+		chain.Backends = append(chain.Backends, generator)
+		chain.Names = append(chain.Names, providerLabel(pc))
+	}
+	return chain, nil
+}
+
+// providerLabel names a provider config for messages, e.g. "ollama/llama3".
+func providerLabel(pc provider.Config) string {
+	name := pc.Provider
+	if name == "" {
+		name = "ollama"
+	}
+	model := pc.Model
+	if model == "" {
+		model = pc.Deployment
+	}
+	return name + "/" + model
+}
+
+// generateOptions returns the per-request tuning taken from the config.
+func (c *Config) generateOptions() provider.Options {
+	return provider.Options{
+		Temperature:   c.Temperature,
+		MaxTokens:     c.MaxTokens,
+		TopP:          c.TopP,
+		TopK:          c.TopK,
+		RepeatPenalty: c.RepeatPenalty,
+		NumCtx:        c.NumCtx,
+		Seed:          c.Seed,
+	}
+}
+
+// deterministicSeed is the seed used in deterministic mode when none is configured.
+const deterministicSeed = 42
+
+// makeDeterministic pins the sampling settings that vary replies. The cache
+// is skipped since its entries may come from non-deterministic runs.
+func (c *Config) makeDeterministic() {
+	c.Temperature = 0
+	if c.Seed == nil {
+		seed := deterministicSeed
+		c.Seed = &seed
+	}
+	c.CacheTTL = 0
+}
+
+// appDirName is the directory name used under the platform's config and
+// cache directories.
+const appDirName = "git_commit_message"
+
+// configDirs returns the directories searched for the user config, most
+// preferred first: $XDG_CONFIG_HOME, %APPDATA% on Windows, ~/.config and,
+// on macOS, ~/Library/Application Support.
+func configDirs() ([]string, error) {
+	var dirs []string
+	// The XDG spec says relative paths are to be ignored.
+	if xdg := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(xdg) {
+		dirs = append(dirs, filepath.Join(xdg, appDirName))
+	}
+	if appData := os.Getenv("APPDATA"); runtime.GOOS == "windows" && appData != "" {
+		dirs = append(dirs, filepath.Join(appData, appDirName))
+	}
+	if homeDir, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(homeDir, ".config", appDirName))
+	}
+	if dir, err := os.UserConfigDir(); err == nil && runtime.GOOS == "darwin" {
+		dirs = append(dirs, filepath.Join(dir, appDirName))
+	}
+	if len(dirs) == 0 {
+		return nil, fmt.Errorf("could not find a config directory: set $XDG_CONFIG_HOME or $HOME")
+	}
+	return dirs, nil
+}
+
+// defaultConfigPath returns the first config file (config.yaml, .yml,
+// .toml or .json) found in configDirs, or config.yaml in the most
+// preferred directory when there is none yet.
+func defaultConfigPath() (string, error) {
+	dirs, err := configDirs()
+	if err != nil {
+		return "", err
+	}
+	for _, dir := range dirs {
+		if path, ok := findConfigFile(dir, "config"); ok {
+			return path, nil
+		}
+	}
+	path, _ := findConfigFile(dirs[0], "config")
+	return path, nil
+}
+
+// loadConfig reads and parses the configuration from the YAML file at
+// configPath, or from the default location when configPath is empty. A
+// missing default file is not an error, so the tool can be configured from
+// the environment alone.
+func loadConfig(configPath string) (*Config, error) {
+	explicit := configPath != ""
+	if !explicit {
+		var err error
+		if configPath, err = defaultConfigPath(); err != nil {
+			return nil, err
+		}
+	}
+
+	configFile, err := os.ReadFile(configPath)
+	if os.IsNotExist(err) && !explicit {
+		return defaultConfig(), nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file at %s: %w", configPath, err)
+	}
+
+	config := defaultConfig()
+	if configFile, err = configAsYAML(configPath, configFile); err != nil {
+		return nil, fmt.Errorf("could not parse config file %s: %w", configPath, err)
+	}
+	if err := decodeYAML(configFile, config); err != nil {
+		return nil, fmt.Errorf("invalid config file %s: %w", configPath, err)
+	}
+
+	return config, nil
+}
+
+// unknownField rewrites yaml.v3's "field x not found in type main.Config".
+var unknownField = regexp.MustCompile(`field (\S+) not found in type \S+`)
+
+// decodeYAML decodes data into out, rejecting keys out doesn't have. Type
+// errors and unknown keys are reported together, one per line, each with
+// its line number.
+func decodeYAML(data []byte, out any) error {
+	decoder := yaml.NewDecoder(bytes.NewReader(data))
+	decoder.KnownFields(true)
+	err := decoder.Decode(out)
+	if errors.Is(err, io.EOF) {
+		// An empty file sets nothing.
+		return nil
+	}
+	var typeErr *yaml.TypeError
+	if errors.As(err, &typeErr) {
+		problems := make([]string, len(typeErr.Errors))
+		for i, problem := range typeErr.Errors {
+			problems[i] = unknownField.ReplaceAllString(problem, "unknown key \"$1\"")
+		}
+		return errors.New(strings.Join(problems, "\n  "))
+	}
+	return err
+}
+
+// configFlags holds command-line overrides for config.yaml values.
+type configFlags struct {
+	flags         *flag.FlagSet
+	path          string
+	provider      string
+	model         string
+	url           string
+	apiKey        string
+	temperature   float64
+	maxTokens     int
+	sampling      provider.Options
+	body          bool
+	language      string
+	noRedact      bool
+	timeout       time.Duration
+	retries       int
+	noCache       bool
+	deterministic bool
+	structured    bool
+	maxSubject    int
+	strict        bool
+	history       int
+	signoff       bool
+	risk          bool
+	coAuthors     []string
+	closes        []string
+	verbose       bool
+	veryVerbose   bool
+}
+
+// registerConfigFlags adds the config override flags to flags.
+func registerConfigFlags(flags *flag.FlagSet) *configFlags {
+	f := &configFlags{flags: flags}
+	flags.StringVar(&f.path, "config", "", "path to the config file (default config.yaml in $XDG_CONFIG_HOME/git_commit_message or ~/.config/git_commit_message)")
+	flags.StringVar(&f.provider, "provider", "", "override the provider (ollama, openai, azure, anthropic, gemini, bedrock)")
+	flags.StringVar(&f.model, "model", "", "override the model")
+	flags.StringVar(&f.url, "url", "", "override the provider base URL")
+	flags.StringVar(&f.apiKey, "api-key", "", "override the provider API key")
+	flags.Float64Var(&f.temperature, "temperature", 0, "override the sampling temperature")
+	flags.IntVar(&f.maxTokens, "max-tokens", 0, "override the maximum reply length in tokens")
+	flags.Float64Var(&f.sampling.TopP, "top-p", 0, "override Ollama's top_p")
+	flags.IntVar(&f.sampling.TopK, "top-k", 0, "override Ollama's top_k")
+	flags.Float64Var(&f.sampling.RepeatPenalty, "repeat-penalty", 0, "override Ollama's repeat_penalty")
+	flags.IntVar(&f.sampling.NumCtx, "num-ctx", 0, "override Ollama's context size (num_ctx) in tokens")
+	flags.Func("seed", "override Ollama's random seed, for reproducible replies", func(value string) error {
+		seed, err := strconv.Atoi(value)
+		f.sampling.Seed = &seed
+		return err
+	})
+	flags.BoolVar(&f.body, "body", false, "generate a subject line plus a wrapped body and footers")
+	flags.IntVar(&f.maxSubject, "max-subject-length", 0, "override the longest subject line accepted from the model, in columns (0 disables)")
+	flags.BoolVar(&f.strict, "strict", false, "keep the subject line within 50 columns")
+	flags.DurationVar(&f.timeout, "timeout", 0, "override the per-request timeout (e.g. 90s)")
+	flags.IntVar(&f.retries, "retries", 0, "override how many times failed requests are retried")
+	flags.BoolVar(&f.noRedact, "no-redact", false, "send the diff without masking secrets")
+	flags.StringVar(&f.language, "language", "", "write the message in this language (e.g. de, ja, pt-BR)")
+	flags.IntVar(&f.history, "history-examples", 0, "override how many recent commit subjects are shown as style examples (0 disables)")
+	flags.BoolVar(&f.signoff, "signoff", false, "add a Signed-off-by trailer with your git identity")
+	flags.BoolVar(&f.risk, "risk", false, "add a Risk trailer rating the change low, medium or high")
+	flags.Func("co-author", "add a Co-authored-by trailer for \"Name <email>\" (repeatable)", func(value string) error {
+		if err := validIdentity(value); err != nil {
+			return err
+		}
+		f.coAuthors = append(f.coAuthors, value)
+		return nil
+	})
+	flags.Func("closes", "add a \"Closes #123\" footer for this issue (repeatable, or comma-separated)", func(value string) error {
+		for _, ref := range splitList(value) {
+			if !issueReference.MatchString(ref) {
+				return fmt.Errorf("invalid issue reference %q (expected 123, #123 or owner/repo#123)", ref)
+			}
+			f.closes = append(f.closes, ref)
+		}
+		return nil
+	})
+	flags.BoolVar(&f.noCache, "no-cache", false, "always ask the model instead of reusing a cached message")
+	flags.BoolVar(&f.deterministic, "deterministic", false, "temperature 0, a fixed seed and no cache, so the same diff gives the same message")
+	flags.BoolVar(&f.structured, "structured", false, "ask the model for JSON fields and assemble the message from them")
+	flags.BoolVar(&f.verbose, "v", false, "log requests and timings to stderr")
+	flags.BoolVar(&f.veryVerbose, "vv", false, "also log git commands and the resolved config to stderr")
+	return f
+}
+
+// repoConfigName is the per-repository config file looked up at the work
+// tree root, without its extension.
+const repoConfigName = ".git-commit-message"
+
+// applyRepoConfig merges per-repository settings over config: first
+// .git-commit-message.yaml (or .yml, .toml, .json) at the repository root, then any keys in the
+// [commit-message] section of the repository's own .git/config. Outside a
+// repository this is a no-op.
+func applyRepoConfig(config *Config) error {
+	top, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return nil
+	}
+
+	repoPath, _ := findConfigFile(strings.TrimSpace(top), repoConfigName)
+	repoFile, err := os.ReadFile(repoPath)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read repository config at %s: %w", repoPath, err)
+	}
+	if err == nil {
+		if repoFile, err = configAsYAML(repoPath, repoFile); err != nil {
+			return fmt.Errorf("could not parse repository config %s: %w", repoPath, err)
+		}
+		if err := checkRepoConfig(repoFile); err != nil {
+			return fmt.Errorf("%s: %w", repoPath, err)
+		}
+		// Unmarshalling into the existing struct only touches keys present in the file.
+		if err := decodeYAML(repoFile, config); err != nil {
+			return fmt.Errorf("invalid repository config %s: %w", repoPath, err)
+		}
+	}
+
+	return applyGitConfigSection(config)
+}
+
+// repoConfigKeys are the keys a repository's .git-commit-message.yaml may
+// set: the model, prompts, convention and message format. The file comes
+// with whatever repository is cloned, so endpoints, credentials, headers,
+// commands and anything else that decides where diffs and keys are sent
+// or what runs on the user's machine are left to the user's own config.
+var repoConfigKeys = []string{
+	"model", "temperature", "max_tokens", "top_p", "top_k", "repeat_penalty", "num_ctx", "seed", "deterministic",
+	"structured_output", "system_prompt", "prompt_template", "examples", "history_examples", "language",
+	"convention", "conventional_retries", "imperative_mood", "strict_subject", "body", "heuristics",
+	"detect_breaking", "workspace_scopes", "packages", "merge_messages", "cleaners", "exclude",
+	"token_budget", "context_window", "budget", "lint", "hook_fix", "ticket_position", "ticket_pattern",
+	"ticket_footer", "closes_from_branch", "issue_branch_pattern", "trailers",
+	"risk_trailer", "risk_areas",
+}
+
+// checkRepoConfig rejects the keys of a repository config file that are
+// not in repoConfigKeys, and trailer commands and prompt template files in
+// the ones that are.
+func checkRepoConfig(data []byte) error {
+	var doc map[string]any
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return fmt.Errorf("invalid repository config: %w", err)
+	}
+	var rejected []string
+	for _, key := range slices.Sorted(maps.Keys(doc)) {
+		if !slices.Contains(repoConfigKeys, key) {
+			rejected = append(rejected, key)
+		}
+	}
+	if len(rejected) > 0 {
+		return fmt.Errorf("a repository config must not set %s; set them in your user config, the environment or `git config --local`", strings.Join(rejected, ", "))
+	}
+	var check Config
+	if err := decodeYAML(data, &check); err != nil {
+		return fmt.Errorf("invalid repository config: %w", err)
+	}
+	for _, trailer := range check.Trailers {
+		if trailer.Command != "" {
+			return fmt.Errorf("a repository config must not set trailer commands (trailer %s); use a value, or set the command in your user config", trailer.Token)
+		}
+	}
+	for name, pkg := range check.Packages {
+		if pkg.PromptTemplateFile != "" {
+			return fmt.Errorf("a repository config must not set prompt_template_file (package %s); use prompt_template", name)
+		}
+	}
+	return nil
+}
+
+// applyGitConfigSection merges `git config --local commit-message.*` keys
+// over config. Git doesn't allow underscores in key names, so `ollama-url`
+// maps to `ollama_url` and so on.
+func applyGitConfigSection(config *Config) error {
+	output, err := runGit("config", "--local", "--get-regexp", `^commit-message\.`)
+	if err != nil {
+		// git exits non-zero when no keys match.
+		return nil
+	}
+
+	// Decode each key as a one-entry YAML mapping so values are converted
+	// exactly as they would be in config.yaml, and errors name the git key.
+	for _, line := range strings.Split(strings.TrimSpace(output), "\n") {
+		name, value, _ := strings.Cut(line, " ")
+		key := strings.ReplaceAll(strings.TrimPrefix(name, "commit-message."), "-", "_")
+		mapping := &yaml.Node{Kind: yaml.MappingNode, Content: []*yaml.Node{
+			{Kind: yaml.ScalarNode, Value: key},
+			{Kind: yaml.ScalarNode, Value: value},
+		}}
+		data, err := yaml.Marshal(mapping)
+		if err != nil {
+			return fmt.Errorf("could not read %s from .git/config: %w", name, err)
+		}
+		if err := decodeYAML(data, config); err != nil {
+			return fmt.Errorf("invalid %s in .git/config: %s", name, strings.TrimPrefix(err.Error(), "line 1: "))
+		}
+	}
+	return nil
+}
+
+// envVars maps each GCM_* environment variable to the config field it sets.
+var envVars = []struct {
+	name string
+	set  func(c *Config, value string) error
+}{
+	{"GCM_PROVIDER", func(c *Config, v string) error { c.Provider = v; return nil }},
+	{"GCM_OLLAMA_URL", func(c *Config, v string) error { c.OllamaURL = v; return nil }},
+	{"GCM_BASE_URL", func(c *Config, v string) error { c.BaseURL = v; return nil }},
+	{"GCM_API_KEY", func(c *Config, v string) error { c.APIKey = v; return nil }},
+	{"GCM_MODEL", func(c *Config, v string) error { c.Model = v; return nil }},
+	{"GCM_TEMPERATURE", func(c *Config, v string) (err error) {
+		c.Temperature, err = strconv.ParseFloat(v, 64)
+		return err
+	}},
+	{"GCM_MAX_TOKENS", func(c *Config, v string) (err error) {
+		c.MaxTokens, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_TOP_P", func(c *Config, v string) (err error) {
+		c.TopP, err = strconv.ParseFloat(v, 64)
+		return err
+	}},
+	{"GCM_TOP_K", func(c *Config, v string) (err error) {
+		c.TopK, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_REPEAT_PENALTY", func(c *Config, v string) (err error) {
+		c.RepeatPenalty, err = strconv.ParseFloat(v, 64)
+		return err
+	}},
+	{"GCM_NUM_CTX", func(c *Config, v string) (err error) {
+		c.NumCtx, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_SEED", func(c *Config, v string) error {
+		seed, err := strconv.Atoi(v)
+		c.Seed = &seed
+		return err
+	}},
+	{"GCM_SYSTEM_PROMPT", func(c *Config, v string) (err error) {
+		c.SystemPrompt, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_DETERMINISTIC", func(c *Config, v string) (err error) {
+		c.Deterministic, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_STRUCTURED_OUTPUT", func(c *Config, v string) (err error) {
+		c.StructuredOutput, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_DEPLOYMENT", func(c *Config, v string) error { c.Deployment = v; return nil }},
+	{"GCM_API_VERSION", func(c *Config, v string) error { c.APIVersion = v; return nil }},
+	{"GCM_AWS_REGION", func(c *Config, v string) error { c.AWSRegion = v; return nil }},
+	{"GCM_AWS_PROFILE", func(c *Config, v string) error { c.AWSProfile = v; return nil }},
+	{"GCM_CONVENTION", func(c *Config, v string) error { c.Convention = v; return nil }},
+	{"GCM_HEURISTICS", func(c *Config, v string) error { c.Heuristics = v; return nil }},
+	{"GCM_DETECT_BREAKING", func(c *Config, v string) (err error) {
+		c.DetectBreaking, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_WORKSPACE_SCOPES", func(c *Config, v string) (err error) {
+		c.WorkspaceScopes, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_MERGE_MESSAGES", func(c *Config, v string) (err error) {
+		c.MergeMessages, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_LANGUAGE", func(c *Config, v string) error { c.Language = v; return nil }},
+	{"GCM_TICKET_POSITION", func(c *Config, v string) error { c.TicketPosition = v; return nil }},
+	{"GCM_TICKET_PATTERN", func(c *Config, v string) error { c.TicketPattern = v; return nil }},
+	{"GCM_JIRA_URL", func(c *Config, v string) error { c.Jira.URL = v; return nil }},
+	{"GCM_JIRA_USER", func(c *Config, v string) error { c.Jira.User = v; return nil }},
+	{"GCM_EXCLUDE", func(c *Config, v string) error { c.Exclude = splitList(v); return nil }},
+	{"GCM_CLEANERS", func(c *Config, v string) error { c.Cleaners = splitList(v); return nil }},
+	{"GCM_TOKEN_BUDGET", func(c *Config, v string) (err error) {
+		c.TokenBudget, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_CONTEXT_WINDOW", func(c *Config, v string) (err error) {
+		c.ContextWindow, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_REDACT", func(c *Config, v string) (err error) {
+		c.Redact, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_TIMEOUT", func(c *Config, v string) (err error) {
+		c.Timeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"GCM_CONNECT_TIMEOUT", func(c *Config, v string) (err error) {
+		c.ConnectTimeout, err = time.ParseDuration(v)
+		return err
+	}},
+	{"GCM_RETRIES", func(c *Config, v string) (err error) {
+		c.Retries, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_HISTORY_EXAMPLES", func(c *Config, v string) (err error) {
+		c.HistoryExamples, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_AUTH_TOKEN", func(c *Config, v string) error { c.AuthToken = v; return nil }},
+	{"GCM_CA_BUNDLE", func(c *Config, v string) error { c.CABundle = v; return nil }},
+	{"GCM_INSECURE_SKIP_VERIFY", func(c *Config, v string) (err error) {
+		c.InsecureSkipVerify, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_AUTO_PULL", func(c *Config, v string) (err error) {
+		c.AutoPull, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_KEEP_ALIVE", func(c *Config, v string) error {
+		c.KeepAlive = v
+		return nil
+	}},
+	{"GCM_CACHE_TTL", func(c *Config, v string) (err error) {
+		c.CacheTTL, err = time.ParseDuration(v)
+		return err
+	}},
+	{"GCM_SIGNOFF", func(c *Config, v string) (err error) {
+		c.Signoff, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_RISK_TRAILER", func(c *Config, v string) (err error) {
+		c.RiskTrailer, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_KEEP_HISTORY", func(c *Config, v string) (err error) {
+		c.KeepHistory, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_HISTORY_PROMPTS", func(c *Config, v string) (err error) {
+		c.HistoryPrompts, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_NOTES_REF", func(c *Config, v string) error { c.NotesRef = v; return nil }},
+	{"GCM_CO_AUTHORS", func(c *Config, v string) error {
+		c.CoAuthors = splitList(v)
+		return nil
+	}},
+	{"GCM_CLOSES_FROM_BRANCH", func(c *Config, v string) (err error) {
+		c.ClosesFromBranch, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_ISSUE_CONTEXT", func(c *Config, v string) (err error) {
+		c.IssueContext, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_ISSUE_REMOTE", func(c *Config, v string) error { c.IssueRemote = v; return nil }},
+	{"GCM_ISSUE_PLATFORM", func(c *Config, v string) error { c.IssuePlatform = v; return nil }},
+	{"GCM_LOG_LEVEL", func(c *Config, v string) error {
+		c.LogLevel = v
+		return nil
+	}},
+	{"GCM_BODY", func(c *Config, v string) (err error) {
+		c.Body, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_CONVENTIONAL_RETRIES", func(c *Config, v string) (err error) {
+		c.ConventionalRetries, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_IMPERATIVE_MOOD", func(c *Config, v string) error { c.ImperativeMood = v; return nil }},
+	{"GCM_HOOK_FIX", func(c *Config, v string) (err error) {
+		c.HookFix, err = strconv.ParseBool(v)
+		return err
+	}},
+	{"GCM_MAX_SUBJECT_LENGTH", func(c *Config, v string) (err error) {
+		c.Lint.MaxSubjectLength, err = strconv.Atoi(v)
+		return err
+	}},
+	{"GCM_STRICT_SUBJECT", func(c *Config, v string) (err error) {
+		c.StrictSubject, err = strconv.ParseBool(v)
+		return err
+	}},
+}
+
+// splitList splits a comma-separated environment value, dropping empty items.
+func splitList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// applyEnv overrides config with any GCM_* environment variables that are set.
+func applyEnv(config *Config) error {
+	for _, env := range envVars {
+		value, ok := os.LookupEnv(env.name)
+		if !ok {
+			continue
+		}
+		if err := env.set(config, value); err != nil {
+			return fmt.Errorf("invalid value %q for %s: %w", value, env.name, err)
+		}
+	}
+	return nil
+}
+
+// resolveConfig builds the effective configuration. Later layers win:
+//
+//	user config file < repository config < GCM_* environment variables < command-line flags
+//
+// overrides may be nil when there are no command-line flags (e.g. in hooks).
+func resolveConfig(overrides *configFlags) (*Config, error) {
+	config, err := resolveSettings(overrides)
+	if err != nil {
+		return nil, err
+	}
+	// Azure deployments pin the model, every other backend needs one.
+	if config.Model == "" && !strings.EqualFold(config.Provider, "azure") {
+		return nil, fmt.Errorf("no model configured; set `model` in config.yaml, $GCM_MODEL or --model, or run `git-commit-message config init`")
+	}
+	return config, nil
+}
+
+// resolveSettings is resolveConfig for commands that never call the model,
+// so a missing model is not an error.
+func resolveSettings(overrides *configFlags) (*Config, error) {
+	configPath := os.Getenv("GCM_CONFIG")
+	if overrides != nil && overrides.path != "" {
+		configPath = overrides.path
+	}
+
+	config, err := loadConfig(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if err := applyRepoConfig(config); err != nil {
+		return nil, err
+	}
+	if err := applyEnv(config); err != nil {
+		return nil, err
+	}
+	if overrides != nil {
+		overrides.apply(config)
+	}
+	if config.Deterministic {
+		config.makeDeterministic()
+	}
+
+	config.Convention = strings.ToLower(config.Convention)
+	switch config.Convention {
+	case "", conventionConventional, conventionGitmoji:
+	default:
+		return nil, fmt.Errorf("unknown convention %q (expected conventional or gitmoji)", config.Convention)
+	}
+	for name, p := range config.Packages {
+		switch strings.ToLower(p.Convention) {
+		case "", conventionConventional, conventionGitmoji:
+		default:
+			return nil, fmt.Errorf("unknown convention %q for package %s (expected conventional or gitmoji)", p.Convention, name)
+		}
+	}
+
+	config.Heuristics = strings.ToLower(config.Heuristics)
+	switch config.Heuristics {
+	case "", heuristicsConstrain, heuristicsSkip, heuristicsOff:
+	default:
+		return nil, fmt.Errorf("unknown heuristics %q (expected constrain, skip or off)", config.Heuristics)
+	}
+
+	config.ImperativeMood = strings.ToLower(config.ImperativeMood)
+	switch config.ImperativeMood {
+	case "", moodRewrite, moodModel, moodOff:
+	default:
+		return nil, fmt.Errorf("unknown imperative_mood %q (expected rewrite, model or off)", config.ImperativeMood)
+	}
+
+	config.IssuePlatform = strings.ToLower(config.IssuePlatform)
+	switch config.IssuePlatform {
+	case "", platformGitHub, platformGitLab, platformBitbucket:
+	default:
+		return nil, fmt.Errorf("unknown issue_platform %q (expected github, gitlab or bitbucket)", config.IssuePlatform)
+	}
+
+	// With Jira set up, the ticket goes into the message unless placed elsewhere.
+	if config.Jira.URL != "" && config.TicketPosition == "" {
+		config.TicketPosition = ticketFooter
+	}
+	switch config.TicketPosition {
+	case "", ticketPrefix, ticketSuffix, ticketFooter:
+	default:
+		return nil, fmt.Errorf("unknown ticket_position %q (expected prefix, suffix or footer)", config.TicketPosition)
+	}
+
+	if err := checkCleaners(config.Cleaners); err != nil {
+		return nil, err
+	}
+
+	if config.CABundle, err = expandHome(config.CABundle); err != nil {
+		return nil, err
+	}
+
+	if err := applyLogLevel(config); err != nil {
+		return nil, err
+	}
+	slog.Debug("resolved config", "path", configPath, "config", config)
+	return config, nil
+}
+
+// apply copies every flag the user actually set onto config. Only flags
+// given on the command line override anything, so an explicit
+// `--temperature 0` still works.
+func (f *configFlags) apply(config *Config) {
+	f.flags.Visit(func(fl *flag.Flag) {
+		switch fl.Name {
+		case "provider":
+			config.Provider = f.provider
+		case "model":
+			config.Model = f.model
+		case "url":
+			config.BaseURL = f.url
+		case "api-key":
+			config.APIKey = f.apiKey
+		case "temperature":
+			config.Temperature = f.temperature
+		case "max-tokens":
+			config.MaxTokens = f.maxTokens
+		case "top-p":
+			config.TopP = f.sampling.TopP
+		case "top-k":
+			config.TopK = f.sampling.TopK
+		case "repeat-penalty":
+			config.RepeatPenalty = f.sampling.RepeatPenalty
+		case "num-ctx":
+			config.NumCtx = f.sampling.NumCtx
+		case "seed":
+			config.Seed = f.sampling.Seed
+		case "body":
+			config.Body = f.body
+		case "language":
+			config.Language = f.language
+		case "no-redact":
+			config.Redact = !f.noRedact
+		case "timeout":
+			config.Timeout = f.timeout
+		case "retries":
+			config.Retries = f.retries
+		case "history-examples":
+			config.HistoryExamples = f.history
+		case "signoff":
+			config.Signoff = f.signoff
+		case "risk":
+			config.RiskTrailer = f.risk
+		case "co-author":
+			// Added to the configured co-authors rather than replacing them.
+			config.CoAuthors = append(config.CoAuthors, f.coAuthors...)
+		case "closes":
+			config.Closes = append(config.Closes, f.closes...)
+		case "no-cache":
+			if f.noCache {
+				config.CacheTTL = 0
+			}
+		case "deterministic":
+			config.Deterministic = f.deterministic
+		case "structured":
+			config.StructuredOutput = f.structured
+		case "max-subject-length":
+			config.Lint.MaxSubjectLength = f.maxSubject
+		case "strict":
+			config.StrictSubject = f.strict
+		}
+	})
+	// -vv wins over -v when both are given.
+	if f.veryVerbose {
+		config.LogLevel = "debug"
+	} else if f.verbose {
+		config.LogLevel = "info"
+	}
+}
@@ -0,0 +1,388 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+	"gopkg.in/yaml.v3"
+)
+
+// maskedSecret replaces API keys in `config show`.
+const maskedSecret = "********"
+
+// initConfig is the subset of Config written by `config init`.
+type initConfig struct {
+	Provider   string `yaml:"provider,omitempty"`
+	OllamaURL  string `yaml:"ollama_url,omitempty"`
+	BaseURL    string `yaml:"base_url,omitempty"`
+	Model      string `yaml:"model,omitempty"`
+	Deployment string `yaml:"deployment,omitempty"`
+	AWSRegion  string `yaml:"aws_region,omitempty"`
+	Convention string `yaml:"convention,omitempty"`
+	Language   string `yaml:"language,omitempty"`
+	Body       bool   `yaml:"body,omitempty"`
+}
+
+// wizardProviders are the choices offered by `config init`, in order.
+var wizardProviders = []string{"ollama", "openai", "azure", "anthropic", "gemini", "bedrock"}
+
+// ask prints question with its default and returns the answer, or def
+// when the answer is empty.
+func ask(question, def string) (string, error) {
+	if def != "" {
+		fmt.Printf("%s [%s]: ", question, def)
+	} else {
+		fmt.Printf("%s: ", question)
+	}
+	answer, err := readLine()
+	if err != nil {
+		return "", err
+	}
+	if answer == "" {
+		return def, nil
+	}
+	return answer, nil
+}
+
+// askChoice asks for one of choices, by name or number.
+func askChoice(question string, choices []string, def string) (string, error) {
+	for {
+		fmt.Println(question)
+		for i, choice := range choices {
+			fmt.Printf("  %d) %s\n", i+1, choice)
+		}
+		answer, err := ask("Choice", def)
+		if err != nil {
+			return "", err
+		}
+		if n, err := strconv.Atoi(answer); err == nil && n >= 1 && n <= len(choices) {
+			return choices[n-1], nil
+		}
+		if slices.Contains(choices, answer) {
+			return answer, nil
+		}
+		fmt.Printf("Please pick one of 1-%d.\n", len(choices))
+	}
+}
+
+// detectOllama returns the names of the models on the Ollama instance at
+// url, or nil if none is reachable.
+func detectOllama(url string) []string {
+	ctx, cancel := context.WithTimeout(appCtx, 2*time.Second)
+	defer cancel()
+	models, err := provider.OllamaModels(ctx, provider.Config{URL: url})
+	if err != nil {
+		return nil
+	}
+	return modelNames(models)
+}
+
+// modelNames returns the names of models.
+func modelNames(models []provider.OllamaModel) []string {
+	names := make([]string, 0, len(models))
+	for _, m := range models {
+		names = append(names, m.Name)
+	}
+	return names
+}
+
+// runConfigWizard asks the questions for a new config file.
+func runConfigWizard() (*initConfig, error) {
+	out := &initConfig{}
+	ollamaURL := os.Getenv("GCM_OLLAMA_URL")
+	if ollamaURL == "" {
+		ollamaURL = "http://localhost:11434"
+	}
+
+	models := detectOllama(ollamaURL)
+	providerDefault := "anthropic"
+	if models != nil {
+		fmt.Printf("✅ Found Ollama at %s with %d model(s).\n", ollamaURL, len(models))
+		providerDefault = "ollama"
+	} else {
+		fmt.Printf("ℹ️  No Ollama instance answered at %s.\n", ollamaURL)
+	}
+	name, err := askChoice("Which provider do you want to use?", wizardProviders, providerDefault)
+	if err != nil {
+		return nil, err
+	}
+	out.Provider = name
+
+	switch name {
+	case "ollama":
+		url, err := ask("Ollama URL", ollamaURL)
+		if err != nil {
+			return nil, err
+		}
+		out.OllamaURL = url
+		if url != ollamaURL {
+			models = detectOllama(url)
+		}
+		if len(models) > 0 {
+			out.Model, err = askChoice("Which model?", models, "1")
+		} else {
+			fmt.Println("⚠️  No models found; install one with `ollama pull <model>`.")
+			out.Model, err = ask("Model name", "llama3")
+		}
+	case "azure":
+		if out.BaseURL, err = ask("Azure OpenAI endpoint (https://<resource>.openai.azure.com)", ""); err != nil {
+			return nil, err
+		}
+		out.Deployment, err = ask("Deployment name", "")
+	case "bedrock":
+		if out.AWSRegion, err = ask("AWS region", "us-east-1"); err != nil {
+			return nil, err
+		}
+		out.Model, err = ask("Bedrock model ID", "anthropic.claude-3-5-haiku-20241022-v1:0")
+	default:
+		defaults := map[string]string{"openai": "gpt-4o-mini", "anthropic": "claude-3-5-haiku-latest", "gemini": "gemini-1.5-flash"}
+		out.Model, err = ask("Model", defaults[name])
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if out.Convention, err = askChoice("Which commit message convention?", []string{conventionConventional, conventionGitmoji}, "1"); err != nil {
+		return nil, err
+	}
+	language, err := ask("Language for messages (e.g. de, ja; empty for English)", "")
+	if err != nil {
+		return nil, err
+	}
+	out.Language = strings.TrimSpace(language)
+	if out.Body, err = confirmDefault("Write a body under the subject line by default?", false); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// confirmDefault is confirm with a configurable answer for an empty reply.
+func confirmDefault(question string, def bool) (bool, error) {
+	if def {
+		return confirm(question)
+	}
+	fmt.Printf("%s [y/N] ", question)
+	answer, err := readLine()
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// runConfigInit implements `git-commit-message config init`.
+func runConfigInit(args []string) error {
+	flags := flag.NewFlagSet("config init", flag.ExitOnError)
+	path := flags.String("config", "", "where to write the config file (default config.yaml in $XDG_CONFIG_HOME/git_commit_message or ~/.config/git_commit_message)")
+	force := flags.Bool("force", false, "overwrite an existing config file")
+	flags.Parse(args)
+
+	if *path == "" {
+		*path = os.Getenv("GCM_CONFIG")
+	}
+	if *path == "" {
+		var err error
+		if *path, err = defaultConfigPath(); err != nil {
+			return err
+		}
+	}
+	if _, err := os.Stat(*path); err == nil && !*force {
+		return fmt.Errorf("%s already exists; pass --force to overwrite it", *path)
+	}
+
+	answers, err := runConfigWizard()
+	if err != nil {
+		return err
+	}
+	data, err := encodeConfigFile(*path, answers, "Written by `git-commit-message config init`. See the README for every option.")
+	if err != nil {
+		return fmt.Errorf("could not encode config: %w", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(*path), 0o755); err != nil {
+		return fmt.Errorf("could not create %s: %w", filepath.Dir(*path), err)
+	}
+	if err := os.WriteFile(*path, data, 0o600); err != nil {
+		return fmt.Errorf("could not write %s: %w", *path, err)
+	}
+	fmt.Printf("\n✅ Wrote %s\n", *path)
+	if slices.Contains(keyringProviders, answers.Provider) {
+		fmt.Printf("🔑 Store your API key with: git-commit-message auth login %s\n", answers.Provider)
+	}
+	return nil
+}
+
+// configProblems runs the checks that resolveConfig leaves until a value is
+// used, so `config validate` catches them up front.
+func configProblems(config *Config) []string {
+	var problems []string
+	for _, pc := range config.fallbackConfigs() {
+		if name := strings.ToLower(pc.Provider); name != "" && !slices.Contains(wizardProviders, name) {
+			problems = append(problems, fmt.Sprintf("unknown provider %q (expected one of %s)", pc.Provider, strings.Join(wizardProviders, ", ")))
+		}
+	}
+	if source, err := promptTemplate(config); err != nil {
+		problems = append(problems, err.Error())
+	} else if _, err := template.New("prompt").Parse(source); err != nil {
+		problems = append(problems, fmt.Sprintf("invalid prompt template: %v", err))
+	}
+	if _, err := ticketFromBranch("", config.TicketPattern); err != nil {
+		problems = append(problems, err.Error())
+	}
+	if _, err := issueFromBranch("", config.IssueBranchPattern); err != nil {
+		problems = append(problems, err.Error())
+	}
+	for _, ref := range config.Closes {
+		if _, err := closingFooter(config.IssuePlatform, ref); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid closes entry: %v", err))
+		}
+	}
+	problems = append(problems, budgetProblems(config)...)
+	for i, example := range config.Examples {
+		if strings.TrimSpace(example.Diff) == "" || strings.TrimSpace(example.Message) == "" {
+			problems = append(problems, fmt.Sprintf("examples entry %d needs both diff and message", i+1))
+		}
+	}
+	for _, coAuthor := range config.CoAuthors {
+		if err := validIdentity(coAuthor); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid co_authors entry: %v", err))
+		}
+	}
+	for _, trailer := range config.Trailers {
+		if !footerToken.MatchString(trailer.Token) {
+			problems = append(problems, fmt.Sprintf("invalid trailer token %q", trailer.Token))
+		}
+		if _, err := template.New(trailer.Token).Parse(trailer.Value); err != nil {
+			problems = append(problems, fmt.Sprintf("invalid template in trailer %s: %v", trailer.Token, err))
+		}
+		// Commands used to be templates; they now get the values from the
+		// environment.
+		if strings.Contains(trailer.Command, "{{") {
+			problems = append(problems, fmt.Sprintf("trailer %s: command is not a template; use $GCM_SUBJECT, $GCM_BRANCH and the like instead of {{...}}", trailer.Token))
+		}
+	}
+	return problems
+}
+
+// runConfigValidate implements `git-commit-message config validate`. It
+// exits 1 if the merged configuration has any problem.
+func runConfigValidate(args []string) error {
+	flags := flag.NewFlagSet("config validate", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		fmt.Printf("❌ %v\n", err)
+		os.Exit(1)
+	}
+	if problems := configProblems(config); len(problems) > 0 {
+		for _, problem := range problems {
+			fmt.Printf("❌ %s\n", problem)
+		}
+		os.Exit(1)
+	}
+	fmt.Println("✅ Configuration is valid.")
+	return nil
+}
+
+// durationKeys are the Config keys shown as durations by `config show`;
+// yaml.v3 would otherwise print them in nanoseconds.
+var durationKeys = []string{"timeout", "connect_timeout", "cache_ttl"}
+
+// maskCredentials returns copies of the reverse proxy credentials with the
+// secrets masked. Header values are all masked, as they usually are secrets.
+func maskCredentials(token string, basic *provider.BasicAuth, headers map[string]string) (string, *provider.BasicAuth, map[string]string) {
+	if token != "" {
+		token = maskedSecret
+	}
+	if basic != nil {
+		basic = &provider.BasicAuth{Username: basic.Username, Password: maskedSecret}
+	}
+	if headers != nil {
+		masked := make(map[string]string, len(headers))
+		for key := range headers {
+			masked[key] = maskedSecret
+		}
+		headers = masked
+	}
+	return token, basic, headers
+}
+
+// runConfigShow implements `git-commit-message config show`: print the
+// fully merged configuration, with API keys masked.
+func runConfigShow(args []string) error {
+	flags := flag.NewFlagSet("config show", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+
+	config, err := resolveSettings(overrides)
+	if err != nil {
+		return err
+	}
+	shown := *config
+	if shown.APIKey != "" {
+		shown.APIKey = maskedSecret
+	}
+	shown.AuthToken, shown.BasicAuth, shown.Headers = maskCredentials(config.AuthToken, config.BasicAuth, config.Headers)
+	if shown.Jira.Token != "" {
+		shown.Jira.Token = maskedSecret
+	}
+	shown.Fallbacks = slices.Clone(config.Fallbacks)
+	for i, f := range shown.Fallbacks {
+		if f.APIKey != "" {
+			shown.Fallbacks[i].APIKey = maskedSecret
+		}
+		shown.Fallbacks[i].AuthToken, shown.Fallbacks[i].BasicAuth, shown.Fallbacks[i].Headers = maskCredentials(f.AuthToken, f.BasicAuth, f.Headers)
+	}
+
+	var node yaml.Node
+	if err := node.Encode(&shown); err != nil {
+		return fmt.Errorf("could not encode config: %w", err)
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if slices.Contains(durationKeys, node.Content[i].Value) {
+			if ns, err := strconv.ParseInt(node.Content[i+1].Value, 10, 64); err == nil {
+				node.Content[i+1].Value = time.Duration(ns).String()
+			}
+		}
+	}
+	encoder := yaml.NewEncoder(os.Stdout)
+	encoder.SetIndent(2)
+	return encoder.Encode(&node)
+}
+
+// runConfig implements the `git-commit-message config` subcommands.
+func runConfig(args []string) error {
+	usage := func() {
+		fmt.Fprintln(os.Stderr, "Usage: git-commit-message config init|validate|show [flags]")
+		os.Exit(2)
+	}
+	if len(args) == 0 {
+		usage()
+	}
+	switch args[0] {
+	case "init":
+		return runConfigInit(args[1:])
+	case "validate":
+		return runConfigValidate(args[1:])
+	case "show":
+		return runConfigShow(args[1:])
+	default:
+		usage()
+	}
+	return nil
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// configExtensions are the config file formats, in the order they are
+// looked for when a directory has more than one.
+var configExtensions = []string{".yaml", ".yml", ".toml", ".json"}
+
+// findConfigFile returns the first existing dir/name.<ext> for the
+// supported formats, or dir/name.yaml and false when there is none.
+func findConfigFile(dir, name string) (string, bool) {
+	for _, ext := range configExtensions {
+		path := filepath.Join(dir, name+ext)
+		if _, err := os.Stat(path); err == nil {
+			return path, true
+		}
+	}
+	return filepath.Join(dir, name+".yaml"), false
+}
+
+// configAsYAML converts the contents of the config file at path to YAML,
+// choosing the format by extension, so every format is decoded and
+// validated the same way. JSON is valid YAML and passes through unchanged.
+func configAsYAML(path string, data []byte) ([]byte, error) {
+	if !strings.EqualFold(filepath.Ext(path), ".toml") {
+		return data, nil
+	}
+	var values map[string]any
+	if _, err := toml.Decode(string(data), &values); err != nil {
+		return nil, err
+	}
+	return yaml.Marshal(values)
+}
+
+// encodeConfigFile encodes v in the format matching path's extension,
+// with header as a leading comment where the format allows one.
+func encodeConfigFile(path string, v any, header string) ([]byte, error) {
+	data, err := yaml.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	ext := strings.ToLower(filepath.Ext(path))
+	if ext != ".toml" && ext != ".json" {
+		return append([]byte("# "+header+"\n"), data...), nil
+	}
+
+	// Go through a generic map so the yaml tags decide the key names.
+	var values map[string]any
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, err
+	}
+	if ext == ".json" {
+		data, err := json.MarshalIndent(values, "", "  ")
+		return append(data, '\n'), err
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "# %s\n", header)
+	if err := toml.NewEncoder(&buf).Encode(values); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// setConfigValue sets key to value in the config file at path, creating
+// the file if needed. YAML files are edited in place, keeping comments and
+// key order; TOML and JSON files are rewritten.
+func setConfigValue(path, key, value string) error {
+	data, err := os.ReadFile(path)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("could not read %s: %w", path, err)
+	}
+	mode := os.FileMode(0o600)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode().Perm()
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".toml", ".json":
+		values := make(map[string]any)
+		if len(bytes.TrimSpace(data)) > 0 {
+			if strings.EqualFold(filepath.Ext(path), ".json") {
+				err = json.Unmarshal(data, &values)
+			} else {
+				_, err = toml.Decode(string(data), &values)
+			}
+			if err != nil {
+				return fmt.Errorf("could not parse %s: %w", path, err)
+			}
+		}
+		values[key] = value
+		var buf bytes.Buffer
+		if strings.EqualFold(filepath.Ext(path), ".json") {
+			encoder := json.NewEncoder(&buf)
+			encoder.SetIndent("", "  ")
+			err = encoder.Encode(values)
+		} else {
+			err = toml.NewEncoder(&buf).Encode(values)
+		}
+		if err != nil {
+			return fmt.Errorf("could not encode %s: %w", path, err)
+		}
+		data = buf.Bytes()
+	default:
+		var doc yaml.Node
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return fmt.Errorf("could not parse %s: %w", path, err)
+		}
+		if doc.Kind == 0 {
+			doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+		}
+		mapping := doc.Content[0]
+		if mapping.Kind != yaml.MappingNode {
+			return fmt.Errorf("%s is not a YAML mapping", path)
+		}
+		found := false
+		for i := 0; i+1 < len(mapping.Content); i += 2 {
+			if mapping.Content[i].Value == key {
+				mapping.Content[i+1].SetString(value)
+				found = true
+			}
+		}
+		if !found {
+			valueNode := &yaml.Node{}
+			valueNode.SetString(value)
+			mapping.Content = append(mapping.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, valueNode)
+		}
+		var buf bytes.Buffer
+		encoder := yaml.NewEncoder(&buf)
+		encoder.SetIndent(2)
+		if err := encoder.Encode(&doc); err != nil {
+			return fmt.Errorf("could not encode %s: %w", path, err)
+		}
+		data = buf.Bytes()
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("could not create %s: %w", filepath.Dir(path), err)
+	}
+	if err := os.WriteFile(path, data, mode); err != nil {
+		return fmt.Errorf("could not write %s: %w", path, err)
+	}
+	return nil
+}
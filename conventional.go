@@ -0,0 +1,82 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// conventionalHeader matches `type(scope)!: subject`.
+var conventionalHeader = regexp.MustCompile(`^([a-zA-Z]+)(?:\(([^()]*)\))?(!)?: (.+)$`)
+
+// conventionalTypes are the commit types accepted by validateConventional.
+var conventionalTypes = []string{
+	"feat", "fix", "docs", "style", "refactor", "perf", "test", "build", "ci", "chore", "revert",
+}
+
+// conventionalCommit is a parsed conventional commit subject line.
+type conventionalCommit struct {
+	Type     string
+	Scope    string
+	Breaking bool
+	Subject  string
+}
+
+// parseConventional splits a subject line into its conventional commit
+// parts. ok is false when the line doesn't follow the convention.
+func parseConventional(header string) (c conventionalCommit, ok bool) {
+	m := conventionalHeader.FindStringSubmatch(strings.TrimSpace(header))
+	if m == nil {
+		return conventionalCommit{}, false
+	}
+	return conventionalCommit{
+		Type:     strings.ToLower(m[1]),
+		Scope:    m[2],
+		Breaking: m[3] == "!",
+		Subject:  m[4],
+	}, true
+}
+
+// String reassembles the subject line.
+func (c conventionalCommit) String() string {
+	var b strings.Builder
+	b.WriteString(c.Type)
+	if c.Scope != "" {
+		b.WriteString("(" + c.Scope + ")")
+	}
+	if c.Breaking {
+		b.WriteString("!")
+	}
+	b.WriteString(": " + c.Subject)
+	return b.String()
+}
+
+// validateConventional reports why header is not a valid conventional commit
+// subject line, or nil if it is.
+func validateConventional(header string) error {
+	commit, ok := parseConventional(header)
+	if !ok {
+		return fmt.Errorf("it does not match the format 'type(scope): subject'")
+	}
+	if !slices.Contains(conventionalTypes, commit.Type) {
+		return fmt.Errorf("%q is not a conventional commit type (use one of %s)", commit.Type, strings.Join(conventionalTypes, ", "))
+	}
+	if strings.TrimSpace(commit.Subject) == "" {
+		return fmt.Errorf("the subject after the colon is empty")
+	}
+	return nil
+}
+
+// breakingFooter matches the BREAKING CHANGE footer, which may also be
+// spelled with a hyphen.
+var breakingFooter = regexp.MustCompile(`(?m)^BREAKING[ -]CHANGE: `)
+
+// isBreaking reports whether a commit announces a breaking change, either
+// with "!" in its header or with a BREAKING CHANGE footer.
+func isBreaking(subject, body string) bool {
+	if commit, ok := parseConventional(subject); ok && commit.Breaking {
+		return true
+	}
+	return breakingFooter.MatchString(body)
+}
@@ -0,0 +1,304 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+const (
+	// daemonDialTimeout is how long the CLI waits for the daemon before
+	// talking to the provider directly.
+	daemonDialTimeout = 100 * time.Millisecond
+	// daemonKeepAlive is the Ollama keep_alive used by the daemon when
+	// none is configured, so the model stays loaded between commits.
+	daemonKeepAlive = "30m"
+)
+
+// daemonRequest is one generation request sent over the socket. The
+// client sends its own provider configs so per-repository settings still
+// apply when the daemon was started elsewhere.
+type daemonRequest struct {
+	Configs []provider.Config `json:"configs"`
+	Prompt  string            `json:"prompt"`
+	Options provider.Options  `json:"options"`
+	// Stream asks for the reply's pieces as they arrive, since
+	// Options.OnToken can't cross the socket.
+	Stream bool `json:"stream,omitempty"`
+}
+
+// daemonResponse is the daemon's reply to a daemonRequest. A streamed
+// request gets one with only Token set for each piece of the reply first.
+type daemonResponse struct {
+	Token string `json:"token,omitempty"`
+	Reply string `json:"reply,omitempty"`
+	Error string `json:"error,omitempty"`
+	// ModelNotFound is set when Error wraps provider.ErrModelNotFound.
+	ModelNotFound bool `json:"model_not_found,omitempty"`
+}
+
+// daemonError is an error reported by the daemon. It unwraps to the
+// sentinel the daemon's error matched, so errors.Is works across the socket.
+type daemonError struct {
+	msg      string
+	sentinel error
+}
+
+func (e *daemonError) Error() string { return e.msg }
+func (e *daemonError) Unwrap() error { return e.sentinel }
+
+// daemonSocketPath returns the Unix socket the daemon listens on. It is
+// in a directory of its own that only the user can enter.
+func daemonSocketPath() (string, error) {
+	dir, err := defaultCacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "daemon", "daemon.sock"), nil
+}
+
+// daemonClient is a provider.Generator that forwards requests to a running daemon.
+type daemonClient struct {
+	socket  string
+	configs []provider.Config
+}
+
+// dialDaemon returns a client for the daemon if one is listening, or nil.
+func dialDaemon(configs []provider.Config) *daemonClient {
+	socket, err := daemonSocketPath()
+	if err != nil {
+		return nil
+	}
+	conn, err := net.DialTimeout("unix", socket, daemonDialTimeout)
+	if err != nil {
+		return nil
+	}
+	conn.Close()
+	slog.Debug("using daemon", "socket", socket)
+	return &daemonClient{socket: socket, configs: configs}
+}
+
+// Generate implements provider.Generator.
+func (d *daemonClient) Generate(ctx context.Context, prompt string, opts provider.Options) (string, error) {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "unix", d.socket)
+	if err != nil {
+		return "", fmt.Errorf("could not reach daemon at %s: %w", d.socket, err)
+	}
+	defer conn.Close()
+	// Unblock the read below if the caller gives up.
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	req := daemonRequest{Configs: d.configs, Prompt: prompt, Options: opts, Stream: opts.OnToken != nil}
+	if err := json.NewEncoder(conn).Encode(req); err != nil {
+		return "", fmt.Errorf("could not send request to daemon: %w", err)
+	}
+	decoder := json.NewDecoder(conn)
+	var resp daemonResponse
+	for {
+		resp = daemonResponse{}
+		if err := decoder.Decode(&resp); err != nil {
+			if ctx.Err() != nil {
+				return "", ctx.Err()
+			}
+			return "", fmt.Errorf("could not read daemon reply: %w", err)
+		}
+		if resp.Token == "" {
+			break
+		}
+		if opts.OnToken != nil {
+			opts.OnToken(resp.Token)
+		}
+	}
+	if resp.Error != "" {
+		err := &daemonError{msg: resp.Error}
+		if resp.ModelNotFound {
+			err.sentinel = provider.ErrModelNotFound
+		}
+		return "", err
+	}
+	return resp.Reply, nil
+}
+
+// daemon serves generation requests and keeps one generator per distinct
+// provider config, so HTTP connections and loaded models stay warm.
+type daemon struct {
+	keepAlive  string
+	mu         sync.Mutex
+	generators map[string]provider.Generator
+}
+
+// generator returns the cached generator for configs, creating it if needed.
+func (d *daemon) generator(configs []provider.Config) (provider.Generator, error) {
+	for i := range configs {
+		if configs[i].KeepAlive == "" {
+			configs[i].KeepAlive = d.keepAlive
+		}
+	}
+	encoded, err := json.Marshal(configs)
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(encoded)
+	key := hex.EncodeToString(sum[:])
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if generator, ok := d.generators[key]; ok {
+		return generator, nil
+	}
+	generator, err := newGenerator(configs)
+	if err != nil {
+		return nil, err
+	}
+	d.generators[key] = generator
+	return generator, nil
+}
+
+// serve handles one connection: a single request and its response.
+func (d *daemon) serve(ctx context.Context, conn net.Conn) {
+	defer conn.Close()
+	var req daemonRequest
+	if err := json.NewDecoder(bufio.NewReader(conn)).Decode(&req); err != nil {
+		// dialDaemon probes by connecting and hanging up straight away.
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		slog.Warn("bad daemon request", "err", err)
+		return
+	}
+	started := time.Now()
+	encoder := json.NewEncoder(conn)
+	if req.Stream {
+		req.Options.OnToken = func(token string) {
+			if token == "" {
+				return
+			}
+			if err := encoder.Encode(daemonResponse{Token: token}); err != nil {
+				slog.Warn("could not stream daemon reply", "err", err)
+			}
+		}
+	}
+	var resp daemonResponse
+	generator, err := d.generator(req.Configs)
+	if err == nil {
+		resp.Reply, err = generator.Generate(ctx, req.Prompt, req.Options)
+	}
+	if err != nil {
+		resp.Error = err.Error()
+		resp.ModelNotFound = errors.Is(err, provider.ErrModelNotFound)
+	}
+	slog.Info("daemon request", "prompt_bytes", len(req.Prompt), "duration", time.Since(started), "err", err)
+	if err := encoder.Encode(resp); err != nil {
+		slog.Warn("could not write daemon reply", "err", err)
+	}
+}
+
+// listenDaemon listens on socket, replacing a stale socket left by a
+// daemon that died, but refusing to start twice.
+func listenDaemon(socket string) (net.Listener, error) {
+	if conn, err := net.DialTimeout("unix", socket, daemonDialTimeout); err == nil {
+		conn.Close()
+		return nil, fmt.Errorf("a daemon is already listening on %s", socket)
+	}
+	// The socket accepts API keys from clients, so it is created in a
+	// directory no one else can enter rather than made private afterwards.
+	dir := filepath.Dir(socket)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(dir, 0o700); err != nil {
+		return nil, err
+	}
+	if err := os.Remove(socket); err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	listener, err := net.Listen("unix", socket)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chmod(socket, 0o600); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	return listener, nil
+}
+
+// runDaemon implements `git-commit-message daemon`: preload the configured
+// model and serve requests on the Unix socket until interrupted.
+func runDaemon(args []string) error {
+	flags := flag.NewFlagSet("daemon", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	socket, err := daemonSocketPath()
+	if err != nil {
+		return err
+	}
+	listener, err := listenDaemon(socket)
+	if err != nil {
+		return err
+	}
+	defer os.Remove(socket)
+
+	ctx, cancel := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer cancel()
+	go func() {
+		<-ctx.Done()
+		listener.Close()
+	}()
+
+	d := &daemon{keepAlive: config.KeepAlive, generators: make(map[string]provider.Generator)}
+	if d.keepAlive == "" {
+		d.keepAlive = daemonKeepAlive
+	}
+	generator, err := d.generator(config.fallbackConfigs())
+	if err != nil {
+		return err
+	}
+	if preloader, ok := generator.(provider.Preloader); ok {
+		if err := preloader.Preload(ctx); err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "🚀 Serving %s on %s (Ctrl-C to stop)\n", providerLabel(config.providerConfig()), socket)
+	var wg sync.WaitGroup
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil {
+				break
+			}
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.serve(ctx, conn)
+		}()
+	}
+	wg.Wait()
+	return nil
+}
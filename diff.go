@@ -0,0 +1,296 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// collectDiff returns the diff for mode, prepared for sending to the model:
+// files matching the configured exclude patterns are removed and, unless
+// disabled, secrets are redacted. What was redacted is reported on stderr.
+func collectDiff(config *Config, mode diffMode) (string, error) {
+	diff, err := getDiff(mode)
+	if err != nil {
+		return "", err
+	}
+	return prepareDiff(config, diff), nil
+}
+
+// prepareDiff applies the exclude patterns and secret redaction to a diff
+// obtained some other way, e.g. from an existing commit.
+func prepareDiff(config *Config, diff string) string {
+	diff, _ = excludeFiles(diff, config.Exclude)
+	if config.Redact {
+		var found []redaction
+		diff, found = redactSecrets(diff)
+		if len(found) > 0 {
+			fmt.Fprintf(os.Stderr, "🔒 Redacted %d possible secret(s) before sending the diff: %s\n", len(found), redactionReport(found))
+		}
+	}
+	return diff
+}
+
+// diffTarget selects the changes a command that only reads them works on:
+// the staged, unstaged or all uncommitted changes, a range of commits, or
+// a single commit given as the command's argument.
+type diffTarget struct {
+	staged, unstaged, all bool
+	revRange              string
+}
+
+// registerDiffTarget adds the flags selecting a diffTarget to flags.
+func registerDiffTarget(flags *flag.FlagSet) *diffTarget {
+	t := &diffTarget{}
+	flags.BoolVar(&t.staged, "staged", false, "use the staged changes (the default)")
+	flags.BoolVar(&t.unstaged, "unstaged", false, "use the unstaged changes in the working tree")
+	flags.BoolVar(&t.all, "all", false, "use both staged and unstaged changes")
+	flags.StringVar(&t.revRange, "range", "", "use the changes between two commits, e.g. main..feature")
+	return t
+}
+
+// collect returns the selected diff, prepared for the model, and a
+// description of it such as "staged changes" or "changes in commit
+// a1b2c3d". commit is the command's argument, if any.
+func (t *diffTarget) collect(config *Config, commit string) (diff, label string, err error) {
+	selected := 0
+	for _, set := range []bool{t.staged, t.unstaged, t.all, t.revRange != "", commit != ""} {
+		if set {
+			selected++
+		}
+	}
+	if selected > 1 {
+		return "", "", fmt.Errorf("--staged, --unstaged, --all, --range and a commit are mutually exclusive")
+	}
+	switch {
+	case t.revRange != "":
+		diff, err = rangeDiff(t.revRange)
+		label = "changes in " + t.revRange
+	case commit != "":
+		diff, err = commitDiff(commit)
+		label = "changes in commit " + commit
+	default:
+		mode, _ := selectDiffMode(t.staged, t.unstaged, t.all, false)
+		diff, err = getDiff(mode)
+		label = mode.String() + " changes"
+	}
+	if err != nil {
+		return "", "", err
+	}
+	diff = prepareDiff(config, diff)
+	switch {
+	case strings.TrimSpace(diff) != "":
+		return diff, label, nil
+	default:
+		return "", "", fmt.Errorf("no %s found", label)
+	}
+}
+
+// fileDiff is the part of a unified diff that belongs to a single file.
+type fileDiff struct {
+	// Path is the file's path after the change (its old path if deleted).
+	Path string
+	// Text is the full diff section, starting with its "diff --git" line.
+	Text string
+}
+
+// splitDiff splits a `git diff` into per-file sections. Text before the
+// first "diff --git" line, if any, is dropped.
+func splitDiff(diff string) []fileDiff {
+	var files []fileDiff
+	var current *strings.Builder
+	var currentPath string
+	flush := func() {
+		if current != nil {
+			files = append(files, fileDiff{Path: currentPath, Text: current.String()})
+		}
+	}
+	for _, line := range strings.SplitAfter(diff, "\n") {
+		if strings.HasPrefix(line, "diff --git ") {
+			flush()
+			current = &strings.Builder{}
+			currentPath = diffHeaderPath(line)
+		}
+		if current == nil {
+			continue
+		}
+		// The +++/--- lines are more reliable than the header for paths with spaces.
+		if rest, ok := strings.CutPrefix(line, "+++ b/"); ok {
+			currentPath = strings.TrimRight(rest, "\n")
+		}
+		current.WriteString(line)
+	}
+	flush()
+	return files
+}
+
+// fileStatus classifies a file section of a diff as added, deleted,
+// renamed or modified, from its extended header lines.
+func fileStatus(f fileDiff) string {
+	for _, line := range strings.Split(f.Text, "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			return "modified"
+		case strings.HasPrefix(line, "new file mode"):
+			return "added"
+		case strings.HasPrefix(line, "deleted file mode"):
+			return "deleted"
+		case strings.HasPrefix(line, "rename from "):
+			return "renamed"
+		}
+	}
+	return "modified"
+}
+
+// renameSource returns the old path of a renamed file section, or "".
+func renameSource(f fileDiff) string {
+	for _, line := range strings.Split(f.Text, "\n") {
+		if from, ok := strings.CutPrefix(line, "rename from "); ok {
+			return from
+		}
+		if strings.HasPrefix(line, "@@") {
+			break
+		}
+	}
+	return ""
+}
+
+// diffHeaderPath extracts the new path from a "diff --git a/x b/x" line.
+func diffHeaderPath(line string) string {
+	line = strings.TrimRight(line, "\n")
+	if i := strings.LastIndex(line, " b/"); i != -1 {
+		return line[i+len(" b/"):]
+	}
+	return line
+}
+
+// joinDiff is the inverse of splitDiff.
+func joinDiff(files []fileDiff) string {
+	var b strings.Builder
+	for _, f := range files {
+		b.WriteString(f.Text)
+	}
+	return b.String()
+}
+
+// excludeFiles drops the sections of diff whose path matches any of the
+// patterns, returning the filtered diff and the excluded paths.
+func excludeFiles(diff string, patterns []string) (string, []string) {
+	if len(patterns) == 0 {
+		return diff, nil
+	}
+	var kept []fileDiff
+	var excluded []string
+	for _, f := range splitDiff(diff) {
+		if matchesAny(patterns, f.Path) {
+			excluded = append(excluded, f.Path)
+			continue
+		}
+		kept = append(kept, f)
+	}
+	return joinDiff(kept), excluded
+}
+
+// matchesAny reports whether p matches one of the glob patterns.
+func matchesAny(patterns []string, p string) bool {
+	for _, pattern := range patterns {
+		if matchGlob(pattern, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchGlob matches a slash-separated path against a gitignore-style glob:
+// patterns without a slash match the file name at any depth ("*.min.js"),
+// a trailing slash matches everything in a directory ("[Dd]ocs/"), "**"
+// matches any number of directories ("vendor/**", "**/go.sum"), "*", "?"
+// and character classes ("*.[ch]", "[!.]*") never cross a slash.
+func matchGlob(pattern, p string) bool {
+	if dir, ok := strings.CutSuffix(pattern, "/"); ok {
+		if !strings.Contains(dir, "/") {
+			dir = "**/" + dir
+		}
+		pattern = dir + "/**/*"
+	}
+	pattern = strings.TrimPrefix(pattern, "/")
+	if !strings.Contains(pattern, "/") {
+		return globRegexp(pattern).MatchString(path.Base(p))
+	}
+	return globRegexp(pattern).MatchString(p)
+}
+
+// globRegexp converts a glob with "**" support into an anchored regexp.
+func globRegexp(pattern string) *regexp.Regexp {
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; {
+		case strings.HasPrefix(pattern[i:], "**/"):
+			b.WriteString("(?:.*/)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "/**") && i+3 == len(pattern):
+			b.WriteString("(?:/.*)?")
+			i += 2
+		case strings.HasPrefix(pattern[i:], "**"):
+			b.WriteString(".*")
+			i++
+		case c == '*':
+			b.WriteString("[^/]*")
+		case c == '?':
+			b.WriteString("[^/]")
+		case c == '[':
+			class, n, ok := globClass(pattern[i:])
+			if !ok {
+				b.WriteString(`\[`)
+				continue
+			}
+			b.WriteString(class)
+			i += n - 1
+		default:
+			b.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	return regexp.MustCompile(b.String())
+}
+
+// globClass converts the character class at the start of glob, such as
+// "[ch]", "[a-z]" or "[!.]", into a regexp class that never matches a
+// slash, returning it and the length of the glob class. ok is false when
+// the class isn't closed, and the "[" is then taken literally.
+func globClass(glob string) (class string, n int, ok bool) {
+	i := 1
+	negate := i < len(glob) && (glob[i] == '!' || glob[i] == '^')
+	if negate {
+		i++
+	}
+	start := i
+	// A "]" first in the class is one of its characters.
+	if i < len(glob) && glob[i] == ']' {
+		i++
+	}
+	for i < len(glob) && glob[i] != ']' {
+		i++
+	}
+	if i >= len(glob) {
+		return "", 0, false
+	}
+	var b strings.Builder
+	b.WriteString("[")
+	if negate {
+		b.WriteString("^/")
+	}
+	for _, r := range glob[start:i] {
+		if r == '-' {
+			b.WriteRune(r)
+			continue
+		}
+		b.WriteString(regexp.QuoteMeta(string(r)))
+	}
+	b.WriteString("]")
+	return b.String(), i + 1, true
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// doctorPrompt is the tiny generation `doctor` runs end to end.
+const doctorPrompt = "Reply with the single word OK."
+
+// doctor collects the outcome of each check.
+type doctor struct {
+	failed int
+}
+
+// pass, fail and skip print one check result.
+func (d *doctor) pass(check, detail string) { fmt.Printf("✅ %s: %s\n", check, detail) }
+func (d *doctor) skip(check, reason string) { fmt.Printf("⏭️  %s: %s\n", check, reason) }
+func (d *doctor) fail(check string, err error) {
+	d.failed++
+	fmt.Printf("❌ %s: %v\n", check, err)
+}
+
+// runDoctor implements `git-commit-message doctor`: check everything a
+// generation depends on, in order, and exit 1 if anything failed.
+func runDoctor(args []string) error {
+	flags := flag.NewFlagSet("doctor", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+	d := &doctor{}
+
+	if _, err := exec.LookPath("git"); err != nil {
+		d.fail("git", err)
+	} else if out, err := runGit("--version"); err != nil {
+		d.fail("git", err)
+	} else {
+		d.pass("git", strings.TrimSpace(out))
+	}
+
+	if top, err := runGit("rev-parse", "--show-toplevel"); err != nil {
+		d.fail("repository", fmt.Errorf("not inside a git work tree"))
+	} else {
+		d.pass("repository", strings.TrimSpace(top))
+	}
+
+	config, err := resolveConfig(overrides)
+	if err == nil {
+		if problems := configProblems(config); len(problems) > 0 {
+			err = fmt.Errorf("%s", strings.Join(problems, "; "))
+		}
+	}
+	if err != nil {
+		d.fail("config", err)
+		d.skip("endpoint", "the config is invalid")
+		d.skip("model", "the config is invalid")
+		d.skip("generation", "the config is invalid")
+		return d.finish()
+	}
+	d.pass("config", providerLabel(config.providerConfig()))
+
+	pc := withKeyringKeys([]provider.Config{config.providerConfig()})[0]
+	ready := true
+	if name := strings.ToLower(pc.Provider); name == "" || name == "ollama" {
+		ctx, cancel := context.WithTimeout(appCtx, 10*time.Second)
+		models, err := provider.OllamaModels(ctx, pc)
+		cancel()
+		switch {
+		case err != nil:
+			ready = false
+			d.fail("endpoint", err)
+			d.skip("model", "the endpoint is unreachable")
+		case !slices.Contains(modelNames(models), pc.Model) && !slices.Contains(modelNames(models), pc.Model+":latest"):
+			d.pass("endpoint", fmt.Sprintf("Ollama at %s with %d model(s)", ollamaEndpoint(pc), len(models)))
+			d.fail("model", fmt.Errorf("%s is not installed; pull it with `ollama pull %s` or pick one with `git-commit-message models`", pc.Model, pc.Model))
+			ready = false
+		default:
+			d.pass("endpoint", fmt.Sprintf("Ollama at %s with %d model(s)", ollamaEndpoint(pc), len(models)))
+			d.pass("model", pc.Model+" is installed")
+		}
+	} else {
+		d.skip("endpoint", "checked by the test generation for "+pc.Provider)
+		d.skip("model", "checked by the test generation for "+pc.Provider)
+	}
+
+	if !ready {
+		d.skip("generation", "fix the checks above first")
+		return d.finish()
+	}
+	generator, err := newGenerator([]provider.Config{pc})
+	if err != nil {
+		d.fail("generation", err)
+		return d.finish()
+	}
+	ctx, cancel := context.WithTimeout(appCtx, config.Timeout*time.Duration(config.Retries+1))
+	defer cancel()
+	started := time.Now()
+	reply, err := generator.Generate(ctx, doctorPrompt, provider.Options{Temperature: 0, MaxTokens: 10})
+	if err != nil {
+		d.fail("generation", err)
+	} else {
+		d.pass("generation", fmt.Sprintf("replied %q in %s", strings.TrimSpace(reply), time.Since(started).Round(time.Millisecond)))
+	}
+	return d.finish()
+}
+
+// ollamaEndpoint returns the URL an Ollama config talks to.
+func ollamaEndpoint(pc provider.Config) string {
+	if pc.URL != "" {
+		return pc.URL
+	}
+	return "http://localhost:11434"
+}
+
+// finish prints the summary and exits 1 if any check failed.
+func (d *doctor) finish() error {
+	if d.failed > 0 {
+		fmt.Printf("\n%d check(s) failed.\n", d.failed)
+		os.Exit(1)
+	}
+	fmt.Println("\nEverything looks good.")
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+)
+
+// explainPrompt asks for a longer walkthrough of a change than a commit
+// message has room for.
+const explainPrompt = "Explain the {{.Target}} below to a developer who hasn't seen them, for a code review or a handoff. Write Markdown with these sections:\n\n## Summary\nTwo or three sentences on what the change does overall.\n\n## Walkthrough\nWhat changed where, file by file or grouped by area, in the order that makes the change easiest to follow. Name the functions, types and settings involved.\n\n## Why\nThe most likely reasons for the change, based on the code, marked as inferred where the diff doesn't say.\n\nStick to what the diff shows and don't review or grade the change. {{.LanguageInstructions}}Do not include any preamble.\n\n" + systemSeparator + changesSection
+
+// explainData is what explainPrompt is executed against.
+type explainData struct {
+	*promptData
+	// Target describes the diff, e.g. "staged changes" or "changes in main..feature".
+	Target string
+}
+
+// runExplain implements `git-commit-message explain [<commit>]`: a plain
+// English walkthrough of the staged changes, a range or a commit.
+func runExplain(args []string) error {
+	flags := flag.NewFlagSet("explain", flag.ExitOnError)
+	target := registerDiffTarget(flags)
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message explain [flags] [<commit>]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	diff, label, err := target.collect(config, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🤖 Explaining the %s...\n", label)
+	explanation, err := explainDiff(appCtx, config, diff, label)
+	if err != nil {
+		return err
+	}
+	fmt.Println(explanation)
+	return nil
+}
+
+// explainDiff writes the walkthrough of diff, described by label.
+func explainDiff(ctx context.Context, config *Config, diff, label string) (string, error) {
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	prompt, err := renderPrompt(explainPrompt, &explainData{
+		promptData: newPromptData(config, diff, summaries),
+		Target:     label,
+	})
+	if err != nil {
+		return "", err
+	}
+	explanation, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	return stripOuterFence(explanation), nil
+}
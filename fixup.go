@@ -0,0 +1,236 @@
+package main
+
+import (
+	"cmp"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxFixupCandidates is how many likely targets `fixup` lists.
+const maxFixupCandidates = 3
+
+// hunkHeader matches the old-side position in a "@@ -12,3 +12,4 @@" line.
+var hunkHeader = regexp.MustCompile(`^@@ -(\d+)(?:,(\d+))? `)
+
+// blameHeader matches the first line of each entry in `git blame --porcelain`:
+// the commit and the line's number in the original and final file.
+var blameHeader = regexp.MustCompile(`^([0-9a-f]{40}) \d+ (\d+)`)
+
+// fixupCandidate is a commit the staged changes may belong to, scored by
+// how many of the touched lines it last changed.
+type fixupCandidate struct {
+	SHA     string
+	Subject string
+	Score   int
+}
+
+// runFixup implements `git-commit-message fixup`: it blames the lines the
+// staged changes touch, picks the commit on the branch that last changed
+// most of them and offers to commit the changes with --fixup for it.
+func runFixup(args []string) error {
+	flags := flag.NewFlagSet("fixup", flag.ExitOnError)
+	base := flags.String("base", "", "only consider commits after this branch (default: origin's default branch, main or master)")
+	yes := flags.Bool("yes", false, "commit as a fixup of the best match without asking")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message fixup [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	candidates, err := fixupCandidates(*base)
+	if err != nil {
+		return err
+	}
+	fmt.Println("🔎 The staged changes touch lines last changed by:")
+	for i, c := range candidates {
+		fmt.Printf("  %d) %s %s (score %d)\n", i+1, shortSHA(c.SHA), c.Subject, c.Score)
+	}
+	target := candidates[0]
+	if !*yes {
+		choice, err := pickFixup(candidates)
+		if err != nil {
+			return err
+		}
+		if choice < 0 {
+			fmt.Println("Commit aborted.")
+			return nil
+		}
+		target = candidates[choice]
+	}
+
+	cmd := exec.Command("git", "commit", "--fixup", target.SHA)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to execute 'git commit': %w", err)
+	}
+	return nil
+}
+
+// pickFixup asks which candidate to fix up, returning -1 for none.
+func pickFixup(candidates []fixupCandidate) (int, error) {
+	for {
+		fmt.Printf("Commit as a fixup of [1-%d], or n to abort (default 1): ", len(candidates))
+		answer, err := readLine()
+		if err != nil {
+			return -1, err
+		}
+		switch strings.ToLower(answer) {
+		case "":
+			return 0, nil
+		case "n", "no", "q":
+			return -1, nil
+		}
+		if choice, err := strconv.Atoi(answer); err == nil && choice >= 1 && choice <= len(candidates) {
+			return choice - 1, nil
+		}
+	}
+}
+
+// fixupCandidates returns the commits between base and HEAD that last
+// changed the lines the staged diff touches, best match first. Removed
+// and modified lines count twice as much as the line an addition follows.
+func fixupCandidates(base string) ([]fixupCandidate, error) {
+	if !hasHead() {
+		return nil, fmt.Errorf("there are no commits to fix up yet")
+	}
+	diff, err := runGit("diff", "--staged", "--unified=0", "--no-renames")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return nil, fmt.Errorf("no staged changes found; stage the fix first")
+	}
+	onBranch, err := branchCommits(base)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[string]int)
+	for _, f := range splitDiff(diff) {
+		path, weights := touchedLines(f)
+		if len(weights) == 0 {
+			continue
+		}
+		blamed, err := blameLines(path, weights)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  Could not blame %s: %v\n", path, err)
+			continue
+		}
+		for sha, score := range blamed {
+			if onBranch == nil || onBranch[sha] {
+				scores[sha] += score
+			}
+		}
+	}
+	if len(scores) == 0 {
+		return nil, fmt.Errorf("none of the staged changes touch lines changed on this branch; commit them normally")
+	}
+
+	var candidates []fixupCandidate
+	for sha, score := range scores {
+		subject, err := runGit("log", "-1", "--format=%s", sha)
+		if err != nil {
+			return nil, err
+		}
+		candidates = append(candidates, fixupCandidate{SHA: sha, Subject: strings.TrimSpace(subject), Score: score})
+	}
+	slices.SortFunc(candidates, func(a, b fixupCandidate) int {
+		return cmp.Or(b.Score-a.Score, strings.Compare(a.SHA, b.SHA))
+	})
+	return candidates[:min(len(candidates), maxFixupCandidates)], nil
+}
+
+// branchCommits returns the set of commits between base, or the default
+// base branch, and HEAD. It is nil, allowing any commit, when there is no
+// base branch or HEAD is on it.
+func branchCommits(base string) (map[string]bool, error) {
+	if base == "" {
+		var err error
+		if base, err = defaultBaseBranch(); err != nil {
+			return nil, nil
+		}
+	} else if _, err := runGit("rev-parse", "--verify", "--quiet", base); err != nil {
+		return nil, fmt.Errorf("unknown base branch %q", base)
+	}
+	output, err := runGit("rev-list", base+"..HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+	commits := make(map[string]bool)
+	for _, sha := range strings.Fields(output) {
+		commits[sha] = true
+	}
+	return commits, nil
+}
+
+// touchedLines returns the old path of a zero-context file section and
+// the weight of each of its old lines: 2 for lines removed or replaced and
+// 1 for the line an addition was made after. New files have no old lines.
+func touchedLines(f fileDiff) (string, map[int]int) {
+	path := ""
+	weights := make(map[int]int)
+	for _, line := range strings.Split(f.Text, "\n") {
+		if rest, ok := strings.CutPrefix(line, "--- a/"); ok {
+			path = rest
+			continue
+		}
+		m := hunkHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		start, _ := strconv.Atoi(m[1])
+		count := 1
+		if m[2] != "" {
+			count, _ = strconv.Atoi(m[2])
+		}
+		if count == 0 {
+			// A pure addition after line start; at the top of the file,
+			// the first line is the closest neighbour.
+			weights[max(start, 1)] += 1
+			continue
+		}
+		for n := start; n < start+count; n++ {
+			weights[n] += 2
+		}
+	}
+	if path == "" {
+		return "", nil
+	}
+	return path, weights
+}
+
+// blameLines returns, for each commit, the summed weight of the lines of
+// path in HEAD that it last changed.
+func blameLines(path string, weights map[int]int) (map[string]int, error) {
+	args := []string{"blame", "--porcelain"}
+	for _, n := range slices.Sorted(maps.Keys(weights)) {
+		args = append(args, "-L", fmt.Sprintf("%d,%d", n, n))
+	}
+	output, err := runGit(append(args, "HEAD", "--", path)...)
+	if err != nil {
+		return nil, err
+	}
+	scores := make(map[string]int)
+	for _, line := range strings.Split(output, "\n") {
+		if m := blameHeader.FindStringSubmatch(line); m != nil {
+			n, _ := strconv.Atoi(m[2])
+			scores[m[1]] += weights[n]
+		}
+	}
+	return scores, nil
+}
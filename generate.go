@@ -0,0 +1,277 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strings"
+	"time"
+)
+
+// runGenerate is the default command: suggest a message for the current changes.
+func runGenerate(args []string) {
+	flags := flag.NewFlagSet("git-commit-message", flag.ExitOnError)
+	staged := flags.Bool("staged", false, "describe staged changes (the default)")
+	unstaged := flags.Bool("unstaged", false, "describe unstaged changes in the working tree")
+	all := flags.Bool("all", false, "describe both staged and unstaged changes")
+	amend := flags.Bool("amend", false, "describe the last commit; with --commit, --edit or -i, reword it with `git commit --amend`")
+	commit := flags.Bool("commit", false, "run `git commit` with the generated message")
+	yes := flags.Bool("yes", false, "with --commit, skip the confirmation prompt")
+	count := flags.Int("n", 1, "generate this many candidate messages and pick one")
+	interactive := flags.Bool("i", false, "review the suggestion interactively: accept, edit, regenerate or tweak it")
+	output := flags.String("output", "text", "output format: text or json")
+	dryRun := flags.Bool("dry-run", false, "print the rendered prompt instead of calling the model")
+	edit := flags.Bool("edit", false, "open the suggestion and the diff in your editor, then commit the saved message")
+	watch := flags.Bool("watch", false, "keep running and regenerate the suggestion whenever the changes do")
+	copyMessage := flags.Bool("copy", false, "also copy the final message to the clipboard")
+	patch := flags.String("diff", "", "describe the patch in this `file` (- for stdin) instead of the repository's changes")
+	revRange := flags.String("range", "", "describe the changes between two commits, e.g. main..feature, instead of the index")
+	forCommit := flags.String("for", "", "describe the changes made by an existing `commit`")
+	var quiet bool
+	flags.BoolVar(&quiet, "q", false, "print only the commit message, for use in scripts")
+	flags.BoolVar(&quiet, "quiet", false, "same as -q")
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+
+	if quiet {
+		statusOut = io.Discard
+		if *interactive {
+			log.Fatalf("Error: -q cannot be combined with -i")
+		}
+	}
+	switch *output {
+	case "text":
+	case "json":
+		// stdout is reserved for the JSON document; progress goes to stderr.
+		statusOut = os.Stderr
+		if *commit || *interactive || *edit {
+			log.Fatalf("Error: --output json cannot be combined with --commit, --edit or -i")
+		}
+	default:
+		log.Fatalf("Error: unknown --output %q (expected text or json)", *output)
+	}
+
+	mode, err := selectDiffMode(*staged, *unstaged, *all, *amend)
+	if err != nil {
+		log.Fatalf("Error: %v", err)
+	}
+	if *count < 1 {
+		log.Fatalf("Error: -n must be at least 1")
+	}
+	if *watch && (*commit || *interactive || *edit || *output != "text" || *count != 1 || *dryRun) {
+		log.Fatalf("Error: --watch only shows suggestions and cannot be combined with --commit, --edit, -i, -n, --dry-run or --output")
+	}
+	sources := 0
+	for _, source := range []string{*patch, *revRange, *forCommit} {
+		if source != "" {
+			sources++
+		}
+	}
+	if sources > 1 {
+		log.Fatalf("Error: --diff, --range and --for are mutually exclusive")
+	}
+	if sources > 0 && (*commit || *interactive || *edit || *watch || *staged || *unstaged || *all || *amend) {
+		log.Fatalf("Error: --diff, --range and --for only show a message and cannot be combined with --commit, --edit, -i, --watch, --staged, --unstaged, --all or --amend")
+	}
+	if *edit && *interactive {
+		log.Fatalf("Error: --edit cannot be combined with -i, which can edit the message itself")
+	}
+	// `git commit` records the index, so committing a message that describes
+	// unstaged-only changes would be misleading.
+	if (*commit || *interactive || *edit) && mode == diffUnstaged {
+		log.Fatalf("Error: --commit, --edit and -i cannot be combined with --unstaged")
+	}
+
+	// 1. Load configuration
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		log.Fatalf("Error loading configuration: %v", err)
+	}
+
+	if *watch {
+		if err := watchSuggestions(config, mode); err != nil {
+			log.Fatalf("Error watching changes: %v", err)
+		}
+		return
+	}
+
+	// 2. Get the git diff for the selected changes, or the patch or
+	// commits given
+	var diff string
+	switch {
+	case *patch != "":
+		diff, err = readPatch(*patch)
+		if err != nil {
+			log.Fatalf("Error reading patch: %v", err)
+		}
+		diff = prepareDiff(config, diff)
+		if strings.TrimSpace(diff) == "" {
+			log.Fatalf("Error: the patch %s is empty", *patch)
+		}
+	case *revRange != "" || *forCommit != "":
+		if *revRange != "" {
+			diff, err = rangeDiff(*revRange)
+		} else {
+			diff, err = commitDiff(*forCommit)
+		}
+		if err != nil {
+			log.Fatalf("Error getting git diff: %v", err)
+		}
+		diff = prepareDiff(config, diff)
+		if strings.TrimSpace(diff) == "" {
+			log.Fatalf("Error: %s%s changes nothing", *revRange, *forCommit)
+		}
+	default:
+		diff, err = collectDiff(config, mode)
+		if err != nil {
+			log.Fatalf("Error getting git diff: %v", err)
+		}
+		if strings.TrimSpace(diff) == "" {
+			fmt.Fprintf(statusOut, "No %s changes found. Nothing to commit. 🤔\n", mode)
+			os.Exit(0)
+		}
+	}
+
+	if *dryRun {
+		if err := printDryRun(config, diff); err != nil {
+			log.Fatalf("Error building prompt: %v", err)
+		}
+		return
+	}
+
+	// 3. Generate the commit message(s)
+	started := time.Now()
+	var finalMessage string
+	var candidates []string
+	recorder := &promptRecorder{}
+	if *count == 1 {
+		fmt.Fprintln(statusOut, "🤖 Generating commit message from diff...")
+		ctx := withPromptRecorder(appCtx, recorder)
+		// Stream the reply so slow local models show progress, but only
+		// when a person is watching: pipes and JSON output get it at once.
+		if *output == "text" && statusOut == os.Stdout && isTerminal(os.Stdout) {
+			ctx = withLiveOutput(ctx, newLiveOutput(os.Stdout))
+		}
+		// Concluding a merge gets a merge message rather than a description
+		// of everything the merge stages.
+		if heads := mergeHeads(); len(heads) > 0 && config.MergeMessages && sources == 0 && (mode == diffStaged || mode == diffAll) {
+			finalMessage, err = mergeMessage(ctx, config, heads)
+		} else {
+			finalMessage, err = cachedSuggestion(ctx, config, diff)
+		}
+		if err != nil {
+			log.Fatalf("Error generating commit message: %v", err)
+		}
+	} else {
+		fmt.Fprintf(statusOut, "🤖 Generating %d commit messages from diff...\n", *count)
+		candidates, err = suggestMessages(withPromptRecorder(appCtx, recorder), config, diff, *count)
+		if err != nil {
+			log.Fatalf("Error generating commit messages: %v", err)
+		}
+		finalMessage = candidates[0]
+		// A patch on stdin leaves no terminal to pick from.
+		if len(candidates) > 1 && !*yes && !quiet && *output == "text" && *patch != "-" {
+			if finalMessage, err = pickMessage(candidates); err != nil {
+				log.Fatalf("Error reading choice: %v", err)
+			}
+		}
+	}
+	suggestions := candidates
+	if suggestions == nil {
+		suggestions = []string{finalMessage}
+	}
+
+	// In interactive mode the user reviews the message and accepting it commits.
+	if *interactive {
+		finalMessage, err = runInteractive(appCtx, config, mode, diff, finalMessage)
+		if err != nil {
+			log.Fatalf("Error in interactive session: %v", err)
+		}
+		if finalMessage == "" {
+			recordHistory(config, recorder, suggestions, outcomeRejected, "")
+			fmt.Fprintln(statusOut, "Commit aborted.")
+			return
+		}
+		recordHistory(config, recorder, suggestions, outcomeAccepted, finalMessage)
+		if *copyMessage {
+			copyFinalMessage(finalMessage)
+		}
+		if err := gitCommit(finalMessage, mode); err != nil {
+			log.Fatalf("Error creating commit: %v", err)
+		}
+		return
+	}
+
+	// With --edit, saving the file is what accepts the message, as with
+	// `git commit` itself.
+	if *edit {
+		finalMessage, err = editMessage(finalMessage, diff)
+		if err != nil {
+			log.Fatalf("Error editing commit message: %v", err)
+		}
+		if finalMessage == "" {
+			recordHistory(config, recorder, suggestions, outcomeRejected, "")
+			fmt.Fprintln(statusOut, "Commit aborted: the message is empty.")
+			return
+		}
+		recordHistory(config, recorder, suggestions, outcomeAccepted, finalMessage)
+		if *copyMessage {
+			copyFinalMessage(finalMessage)
+		}
+		if err := gitCommit(finalMessage, mode); err != nil {
+			log.Fatalf("Error creating commit: %v", err)
+		}
+		return
+	}
+
+	// 4. Print the final message
+	if !*commit {
+		recordHistory(config, recorder, suggestions, outcomeSuggested, "")
+	}
+	if *output == "json" {
+		if *copyMessage {
+			copyFinalMessage(finalMessage)
+		}
+		if err := printJSONResult(config, finalMessage, candidates, time.Since(started)); err != nil {
+			log.Fatalf("Error writing JSON output: %v", err)
+		}
+		return
+	}
+	fmt.Fprintln(statusOut, "\n✅ Suggested Commit Message:")
+	fmt.Println(finalMessage)
+	if *copyMessage {
+		copyFinalMessage(finalMessage)
+	}
+
+	// 5. Optionally create the commit
+	if !*commit {
+		return
+	}
+	if !*yes {
+		ok, err := confirm("\nCommit with this message?")
+		if err != nil {
+			log.Fatalf("Error reading confirmation: %v", err)
+		}
+		if !ok {
+			recordHistory(config, recorder, suggestions, outcomeRejected, "")
+			fmt.Fprintln(statusOut, "Commit aborted.")
+			return
+		}
+	}
+	recordHistory(config, recorder, suggestions, outcomeAccepted, finalMessage)
+	if err := gitCommit(finalMessage, mode); err != nil {
+		log.Fatalf("Error creating commit: %v", err)
+	}
+}
+
+// copyFinalMessage copies message to the clipboard. Failing to is only
+// worth a warning, since the message has been printed anyway.
+func copyFinalMessage(message string) {
+	if err := copyToClipboard(message); err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not copy the message: %v\n", err)
+		return
+	}
+	fmt.Fprintln(statusOut, "📋 Copied to the clipboard.")
+}
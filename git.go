@@ -0,0 +1,291 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+	"time"
+)
+
+// diffMode selects which changes the commit message describes.
+type diffMode int
+
+const (
+	// diffStaged describes the index, which is what `git commit` records.
+	diffStaged diffMode = iota
+	// diffUnstaged describes working tree changes that are not yet staged.
+	diffUnstaged
+	// diffAll describes everything that differs from HEAD.
+	diffAll
+	// diffHead describes the last commit, for rewording it with --amend.
+	diffHead
+)
+
+// String returns the human-readable name used in messages.
+func (m diffMode) String() string {
+	switch m {
+	case diffUnstaged:
+		return "unstaged"
+	case diffAll:
+		return "staged or unstaged"
+	case diffHead:
+		return "committed"
+	default:
+		return "staged"
+	}
+}
+
+// selectDiffMode turns the mutually exclusive --staged/--unstaged/--all/--amend flags into a diffMode.
+func selectDiffMode(staged, unstaged, all, amend bool) (diffMode, error) {
+	count := 0
+	for _, set := range []bool{staged, unstaged, all, amend} {
+		if set {
+			count++
+		}
+	}
+	if count > 1 {
+		return diffStaged, fmt.Errorf("--staged, --unstaged, --all and --amend are mutually exclusive")
+	}
+	switch {
+	case amend:
+		if !hasHead() {
+			return diffStaged, fmt.Errorf("--amend needs an existing commit")
+		}
+		return diffHead, nil
+	case unstaged:
+		return diffUnstaged, nil
+	case all:
+		return diffAll, nil
+	default:
+		return diffStaged, nil
+	}
+}
+
+// gitConfigOverrides pin the settings that change the format of git's
+// output, so user and system config can't break what the tool parses:
+// no pager or colors, a/ and b/ prefixes, paths relative to the top level,
+// plain submodule lines and unquoted non-ASCII paths.
+var gitConfigOverrides = []string{
+	"-c", "core.pager=cat",
+	"-c", "color.ui=never",
+	"-c", "core.quotePath=false",
+	"-c", "diff.noprefix=false",
+	"-c", "diff.mnemonicPrefix=false",
+	"-c", "diff.relative=false",
+	"-c", "diff.submodule=short",
+	"-c", "log.showSignature=false",
+}
+
+// diffOutputFlags keep external diff tools and textconv drivers out of
+// patches, since their output isn't a diff the model or git apply can read.
+var diffOutputFlags = []string{"--no-color", "--no-ext-diff", "--no-textconv"}
+
+// gitCommand returns the command for `git args...` with
+// gitConfigOverrides, and diffOutputFlags for the commands that print
+// patches. Leading -C options are kept in front.
+func gitCommand(args ...string) *exec.Cmd {
+	i := 0
+	for i+1 < len(args) && args[i] == "-C" {
+		i += 2
+	}
+	full := append(slices.Clone(gitConfigOverrides), args[:i]...)
+	if i < len(args) {
+		full = append(full, args[i])
+		switch args[i] {
+		case "diff", "show", "log":
+			full = append(full, diffOutputFlags...)
+		}
+		full = append(full, args[i+1:]...)
+	}
+	cmd := exec.CommandContext(appCtx, "git", full...)
+	cmd.Env = slices.DeleteFunc(os.Environ(), func(v string) bool {
+		return strings.HasPrefix(v, "GIT_EXTERNAL_DIFF=") || strings.HasPrefix(v, "GIT_PAGER=")
+	})
+	return cmd
+}
+
+// runGit executes git with the given arguments and returns its stdout.
+func runGit(args ...string) (string, error) {
+	return runGitInput("", args...)
+}
+
+// runGitInput is like runGit but feeds input to git's stdin.
+func runGitInput(input string, args ...string) (string, error) {
+	cmd := gitCommand(args...)
+	cmd.Stdin = strings.NewReader(input)
+	started := time.Now()
+	output, err := cmd.Output()
+	slog.Debug("git", "args", args, "duration", time.Since(started), "bytes", len(output), "err", err)
+	if err != nil {
+		// This can happen if git is not installed or not in a repo. The
+		// first line of git's complaint says which.
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			if reason, _, _ := strings.Cut(strings.TrimSpace(string(exitErr.Stderr)), "\n"); reason != "" {
+				return "", fmt.Errorf("failed to execute 'git %s': %w: %s", args[0], err, reason)
+			}
+		}
+		return "", fmt.Errorf("failed to execute 'git %s': %w", args[0], err)
+	}
+	return string(output), nil
+}
+
+// currentBranch returns the checked-out branch name, or "" when HEAD is detached.
+func currentBranch() string {
+	branch, err := runGit("branch", "--show-current")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(branch)
+}
+
+// recentSubjects returns the subject lines of the last n commits on HEAD.
+func recentSubjects(n int) []string {
+	if !hasHead() {
+		return nil
+	}
+	output, err := runGit("log", fmt.Sprintf("-n%d", n), "--format=%s")
+	if err != nil {
+		return nil
+	}
+	return strings.Split(strings.TrimSpace(output), "\n")
+}
+
+// styleSubjects returns up to n recent subjects that are useful as style
+// examples: merge commits, fixup!/squash! commits, reverts and "wip"
+// commits are skipped since they are generated or not worth imitating.
+func styleSubjects(n int) []string {
+	if !hasHead() {
+		return nil
+	}
+	// Ask for extra commits so skipped ones don't shrink the list.
+	output, err := runGit("log", "--no-merges", fmt.Sprintf("-n%d", n*2+10), "--format=%s")
+	if err != nil {
+		return nil
+	}
+	var subjects []string
+	for _, subject := range strings.Split(strings.TrimSpace(output), "\n") {
+		if subject == "" || skipLint(subject) || strings.HasPrefix(strings.ToLower(subject), "wip") {
+			continue
+		}
+		subjects = append(subjects, subject)
+		if len(subjects) == n {
+			break
+		}
+	}
+	return subjects
+}
+
+// logCommit is a commit as listed by rangeCommits.
+type logCommit struct {
+	SHA     string
+	Subject string
+	// Body is everything after the subject line, including trailers.
+	Body string
+}
+
+// rangeCommits returns the non-merge commits selected by the `git log`
+// arguments revs (usually a single range), newest first.
+func rangeCommits(revs ...string) ([]logCommit, error) {
+	output, err := runGit(append([]string{"log", "--no-merges", "--format=%H%x00%B%x1e"}, revs...)...)
+	if err != nil {
+		return nil, err
+	}
+	var commits []logCommit
+	for _, record := range strings.Split(output, "\x1e") {
+		sha, message, ok := strings.Cut(strings.TrimSpace(record), "\x00")
+		if !ok {
+			continue
+		}
+		subject, body, _ := strings.Cut(strings.TrimSpace(message), "\n")
+		commits = append(commits, logCommit{SHA: sha, Subject: subject, Body: strings.TrimSpace(body)})
+	}
+	return commits, nil
+}
+
+// hasHead reports whether the repository has at least one commit.
+func hasHead() bool {
+	_, err := runGit("rev-parse", "--verify", "--quiet", "HEAD")
+	return err == nil
+}
+
+// getDiff returns the diff for the requested mode.
+func getDiff(mode diffMode) (string, error) {
+	return gitDiff(mode)
+}
+
+// getDiffStat returns `git diff --stat` for the requested mode.
+func getDiffStat(mode diffMode) (string, error) {
+	return gitDiff(mode, "--stat")
+}
+
+// gitDiff runs `git diff` for mode with any extra arguments appended.
+func gitDiff(mode diffMode, extra ...string) (string, error) {
+	switch mode {
+	case diffUnstaged:
+		return runGit(append([]string{"diff"}, extra...)...)
+	case diffAll:
+		// Before the first commit there is no HEAD to diff against, so
+		// combine the two halves instead.
+		if !hasHead() {
+			staged, err := runGit(append([]string{"diff", "--staged"}, extra...)...)
+			if err != nil {
+				return "", err
+			}
+			unstaged, err := runGit(append([]string{"diff"}, extra...)...)
+			if err != nil {
+				return "", err
+			}
+			return staged + unstaged, nil
+		}
+		return runGit(append([]string{"diff", "HEAD"}, extra...)...)
+	case diffHead:
+		return commitDiff("HEAD", extra...)
+	default:
+		return runGit(append([]string{"diff", "--staged"}, extra...)...)
+	}
+}
+
+// commitDiff returns the changes introduced by commit rev, relative to its
+// first parent, with any extra `git diff` arguments.
+func commitDiff(rev string, extra ...string) (string, error) {
+	// The root commit has no parent to diff against.
+	if _, err := runGit("rev-parse", "--verify", "--quiet", rev+"~1"); err != nil {
+		return runGit(append([]string{"show", "--format="}, append(extra, rev)...)...)
+	}
+	return runGit(append([]string{"diff", rev + "~1", rev}, extra...)...)
+}
+
+// rangeDiff returns the changes between the two ends of a range such as
+// main..feature, or from their merge base with main...feature.
+func rangeDiff(spec string, extra ...string) (string, error) {
+	if !strings.Contains(spec, "..") {
+		return "", fmt.Errorf("%q is not a range; use A..B, or --for for a single commit", spec)
+	}
+	return runGit(append(append([]string{"diff"}, extra...), spec, "--")...)
+}
+
+// gitCommit runs `git commit` with the given message for mode, streaming
+// git's own output to the terminal. diffAll includes tracked but unstaged
+// changes (`git commit -a`); diffHead rewrites the last commit's message
+// without folding in anything that is staged (`--amend --only`).
+func gitCommit(message string, mode diffMode) error {
+	args := []string{"commit", "-m", message}
+	switch mode {
+	case diffAll:
+		args = append(args, "-a")
+	case diffHead:
+		args = append(args, "--amend", "--only")
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to execute 'git commit': %w", err)
+	}
+	return nil
+}
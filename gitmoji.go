@@ -0,0 +1,147 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// gitmojiEntry ties a gitmoji to the conventional type and subject keywords
+// it stands for.
+type gitmojiEntry struct {
+	Emoji    string
+	Type     string
+	Keywords []string
+}
+
+// gitmojis is the built-in validation and mapping table. Order matters for
+// keyword lookup: the first entry with a matching keyword wins.
+var gitmojis = []gitmojiEntry{
+	{"💥", "", []string{"breaking"}},
+	{"🚑️", "", []string{"hotfix", "critical"}},
+	{"🔒️", "", []string{"security", "vulnerability"}},
+	{"🐛", "fix", []string{"fix", "resolve", "correct", "bug"}},
+	{"✨", "feat", []string{"add", "implement", "introduce", "support", "feature"}},
+	{"📝", "docs", []string{"doc", "docs", "readme", "document", "comment"}},
+	{"🎨", "style", []string{"format", "style", "lint", "whitespace"}},
+	{"♻️", "refactor", []string{"refactor", "restructure", "rename", "move", "extract", "simplify"}},
+	{"⚡️", "perf", []string{"optimize", "speed", "perf", "performance", "faster", "cache"}},
+	{"✅", "test", []string{"test", "tests", "spec"}},
+	{"👷", "ci", []string{"ci", "pipeline", "workflow"}},
+	{"📦️", "build", []string{"build", "package", "bundle"}},
+	{"⬆️", "", []string{"bump", "upgrade"}},
+	{"⬇️", "", []string{"downgrade"}},
+	{"➕", "", []string{"dependency"}},
+	{"🔥", "", []string{"remove", "delete", "drop"}},
+	{"⏪️", "revert", []string{"revert"}},
+	{"✏️", "", []string{"typo", "typos"}},
+	{"🚧", "", []string{"wip"}},
+	{"🎉", "", []string{"initial", "init", "begin"}},
+	{"🔧", "chore", []string{"config", "configure", "chore", "update"}},
+}
+
+// defaultGitmoji is used when nothing in the message hints at a better one.
+const defaultGitmoji = "🔧"
+
+// gitmojiInstructions is the {{.Convention}} text for convention: gitmoji.
+func gitmojiInstructions() string {
+	var table []string
+	for _, entry := range gitmojis {
+		hint := entry.Type
+		if hint == "" {
+			hint = entry.Keywords[0]
+		}
+		table = append(table, entry.Emoji+" "+hint)
+	}
+	return "in the gitmoji format: a single gitmoji, a space and a lowercase imperative subject (e.g., '✨ add user login' or '🐛 resolve race condition'). Choose the emoji from this list: " + strings.Join(table, ", ")
+}
+
+// normalizeEmoji drops variation selectors so "♻" and "♻️" compare equal.
+func normalizeEmoji(s string) string {
+	return strings.ReplaceAll(s, "\uFE0F", "")
+}
+
+// leadingGitmoji returns the table entry message starts with, if any.
+func leadingGitmoji(message string) (gitmojiEntry, bool) {
+	normalized := normalizeEmoji(message)
+	for _, entry := range gitmojis {
+		if strings.HasPrefix(normalized, normalizeEmoji(entry.Emoji)) {
+			return entry, true
+		}
+	}
+	return gitmojiEntry{}, false
+}
+
+// gitmojiForType returns the emoji for a conventional commit type.
+func gitmojiForType(commitType string) (string, bool) {
+	for _, entry := range gitmojis {
+		if entry.Type != "" && entry.Type == commitType {
+			return entry.Emoji, true
+		}
+	}
+	return "", false
+}
+
+// gitmojiForSubject picks an emoji from the first keyword found in subject.
+func gitmojiForSubject(subject string) string {
+	words := strings.FieldsFunc(strings.ToLower(subject), func(r rune) bool {
+		return !unicode.IsLetter(r)
+	})
+	for _, entry := range gitmojis {
+		for _, keyword := range entry.Keywords {
+			for _, word := range words {
+				if matchesKeyword(word, keyword) {
+					return entry.Emoji
+				}
+			}
+		}
+	}
+	return defaultGitmoji
+}
+
+// matchesKeyword reports whether word is keyword or a simple inflection of
+// it ("added", "fixes", "removing").
+func matchesKeyword(word, keyword string) bool {
+	rest, ok := strings.CutPrefix(word, keyword)
+	if !ok {
+		return false
+	}
+	switch rest {
+	case "", "s", "es", "d", "ed", "ded", "ing":
+		return true
+	}
+	return false
+}
+
+// toGitmoji rewrites the subject line of message so it starts with a valid
+// gitmoji. Messages that already do are only normalised; conventional
+// `type(scope): subject` lines are mapped through the table; anything else
+// gets an emoji picked from its keywords. The body, if any, is untouched.
+func toGitmoji(message string) string {
+	subject, rest, hasRest := strings.Cut(message, "\n")
+	subject = strings.TrimSpace(subject)
+
+	if entry, ok := leadingGitmoji(subject); ok {
+		// Re-emit the canonical emoji so variation selectors are consistent.
+		text := strings.TrimSpace(strings.TrimPrefix(normalizeEmoji(subject), normalizeEmoji(entry.Emoji)))
+		subject = entry.Emoji + " " + text
+	} else if commit, ok := parseConventional(subject); ok {
+		emoji, found := gitmojiForType(commit.Type)
+		if !found {
+			emoji = gitmojiForSubject(commit.Subject)
+		}
+		if commit.Breaking {
+			emoji = "💥"
+		}
+		subject = emoji + " " + commit.Subject
+		if commit.Scope != "" {
+			subject = emoji + " " + commit.Scope + ": " + commit.Subject
+		}
+	} else {
+		subject = gitmojiForSubject(subject) + " " + subject
+	}
+
+	if hasRest {
+		return subject + "\n" + rest
+	}
+	return subject
+}
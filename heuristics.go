@@ -0,0 +1,250 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Supported values for Config.Heuristics.
+const (
+	heuristicsConstrain = "constrain"
+	heuristicsSkip      = "skip"
+	heuristicsOff       = "off"
+)
+
+// changeHint is what a diff says about its commit message on its own:
+// the type, such as docs for a change that only touches documentation,
+// and the changes that can break existing users.
+type changeHint struct {
+	Type  string
+	Scope string
+	// breaking lists what detectBreaking found.
+	breaking []string
+	// what completes "Only ... changed" in the prompt.
+	what string
+	// verb and files describe the change for a message written without the model.
+	verb  string
+	files []string
+}
+
+// depManifests are the dependency manifests of common ecosystems and
+// depLockfiles the files pinning their resolved versions.
+var (
+	depManifests = []string{
+		"go.mod", "package.json", "Cargo.toml", "requirements.txt", "pyproject.toml",
+		"Pipfile", "Gemfile", "composer.json", "mix.exs",
+	}
+	depLockfiles = []string{
+		"go.sum", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml",
+		"Cargo.lock", "poetry.lock", "Pipfile.lock", "Gemfile.lock", "composer.lock", "mix.lock",
+	}
+)
+
+// docExtensions and docNames identify documentation files; anything
+// under a docs/ or doc/ directory counts too.
+var (
+	docExtensions = []string{".md", ".markdown", ".rst", ".adoc", ".txt", ".org"}
+	docNames      = []string{"LICENSE", "LICENCE", "COPYING", "AUTHORS", "CONTRIBUTORS", "CHANGELOG", "NOTICE"}
+)
+
+// testFile matches test files by name, e.g. foo_test.go, test_foo.py or
+// foo.spec.ts; anything under a test directory counts too.
+var testFile = regexp.MustCompile(`(_test\.[a-z]+|^test_.*\.py|\.(test|spec)\.[a-z]+|Tests?\.(java|kt|cs|swift))$`)
+
+// testDirs are directory names whose contents are tests.
+var testDirs = []string{"test", "tests", "__tests__", "testdata", "spec"}
+
+// classifyDiff returns the hint for diff, if every file in it is the same
+// obvious kind of change: dependency updates, documentation, tests, or
+// whitespace-only formatting.
+func classifyDiff(diff string) (changeHint, bool) {
+	files := splitDiff(diff)
+	if len(files) == 0 {
+		return changeHint{}, false
+	}
+	var paths []string
+	for _, f := range files {
+		paths = append(paths, f.Path)
+	}
+	hint := changeHint{verb: changeVerb(files), files: paths}
+	switch {
+	case all(paths, isDepFile) && slices.ContainsFunc(paths, isLockfile):
+		hint.Type, hint.Scope, hint.what, hint.verb = "chore", "deps", "dependency manifests", "update dependencies in"
+		// Name the manifests rather than their lockfiles where both changed.
+		if manifests := slices.DeleteFunc(slices.Clone(paths), isLockfile); len(manifests) > 0 {
+			hint.files = manifests
+		}
+	case all(paths, isDocFile):
+		hint.Type, hint.what = "docs", "documentation"
+	case all(paths, isTestFile):
+		hint.Type, hint.what = "test", "tests"
+	case whitespaceOnly(files):
+		hint.Type, hint.what, hint.verb = "style", "whitespace and formatting", "format"
+	default:
+		return changeHint{}, false
+	}
+	return hint, true
+}
+
+// all reports whether every path satisfies match.
+func all(paths []string, match func(string) bool) bool {
+	return !slices.ContainsFunc(paths, func(p string) bool { return !match(p) })
+}
+
+func isDepFile(p string) bool {
+	return slices.Contains(depManifests, path.Base(p)) || isLockfile(p)
+}
+
+func isLockfile(p string) bool {
+	return slices.Contains(depLockfiles, path.Base(p))
+}
+
+func isDocFile(p string) bool {
+	if isDepFile(p) || strings.HasPrefix(path.Base(p), "requirements") {
+		return false
+	}
+	dir, _, _ := strings.Cut(p, "/")
+	if strings.Contains(p, "/") && (dir == "docs" || dir == "doc") {
+		return true
+	}
+	base := path.Base(p)
+	ext := path.Ext(base)
+	return slices.Contains(docExtensions, strings.ToLower(ext)) ||
+		slices.Contains(docNames, strings.ToUpper(strings.TrimSuffix(base, ext)))
+}
+
+func isTestFile(p string) bool {
+	if testFile.MatchString(path.Base(p)) {
+		return true
+	}
+	dirs := strings.Split(p, "/")
+	return slices.ContainsFunc(dirs[:len(dirs)-1], func(dir string) bool { return slices.Contains(testDirs, dir) })
+}
+
+// whitespaceOnly reports whether files only modify existing files and
+// every change is to whitespace: the removed and added lines are the same
+// once whitespace is dropped.
+func whitespaceOnly(files []fileDiff) bool {
+	for _, f := range files {
+		if fileStatus(f) != "modified" {
+			return false
+		}
+		var removed, added strings.Builder
+		for _, line := range strings.Split(f.Text, "\n") {
+			switch {
+			case strings.HasPrefix(line, "---"), strings.HasPrefix(line, "+++"):
+			case strings.HasPrefix(line, "-"):
+				removed.WriteString(withoutSpace(line[1:]))
+			case strings.HasPrefix(line, "+"):
+				added.WriteString(withoutSpace(line[1:]))
+			}
+		}
+		// A section without content changes, e.g. a mode change or a
+		// binary file, isn't formatting.
+		if !strings.Contains(f.Text, "\n@@") || removed.String() != added.String() {
+			return false
+		}
+	}
+	return true
+}
+
+// withoutSpace returns s with all whitespace removed.
+func withoutSpace(s string) string {
+	return strings.Map(func(r rune) rune {
+		if unicode.IsSpace(r) {
+			return -1
+		}
+		return r
+	}, s)
+}
+
+// changeVerb is "add" when every file is new, "remove" when every file
+// was deleted and "update" otherwise.
+func changeVerb(files []fileDiff) string {
+	statuses := make(map[string]bool)
+	for _, f := range files {
+		statuses[fileStatus(f)] = true
+	}
+	switch {
+	case len(statuses) == 1 && statuses["added"]:
+		return "add"
+	case len(statuses) == 1 && statuses["deleted"]:
+		return "remove"
+	default:
+		return "update"
+	}
+}
+
+// header returns the type and scope as a conventional commit prefix.
+func (h changeHint) header() string {
+	if h.Scope == "" {
+		return h.Type
+	}
+	return h.Type + "(" + h.Scope + ")"
+}
+
+// instructions returns the prompt sentence asking for the hinted type,
+// or just for the scope when only the package is known.
+func (h changeHint) instructions() string {
+	if h.Type == "" {
+		return fmt.Sprintf("Only the %s package changed, so use the scope '%s'. ", h.Scope, h.Scope)
+	}
+	return fmt.Sprintf("Only %s changed, so use the type prefix '%s'. ", h.what, h.header())
+}
+
+// message writes the commit message for the hint without the model, e.g.
+// "docs: update README.md and CONTRIBUTING.md".
+func (h changeHint) message() string {
+	object := fmt.Sprintf("%d files", len(h.files))
+	switch len(h.files) {
+	case 1:
+		object = path.Base(h.files[0])
+	case 2:
+		object = path.Base(h.files[0]) + " and " + path.Base(h.files[1])
+	}
+	return fmt.Sprintf("%s: %s %s", h.header(), h.verb, object)
+}
+
+// enforce replaces the type and scope of a conventional commit message
+// with the hinted ones. Other messages are returned unchanged.
+func (h changeHint) enforce(message string) string {
+	if h.Type == "" && h.Scope == "" {
+		return message
+	}
+	subject, rest, _ := strings.Cut(message, "\n")
+	commit, ok := parseConventional(subject)
+	if !ok || (h.Type == "" || commit.Type == h.Type) && (h.Scope == "" || commit.Scope == h.Scope) {
+		return message
+	}
+	if h.Type != "" {
+		commit.Type = h.Type
+	}
+	if h.Scope != "" {
+		commit.Scope = h.Scope
+	}
+	if rest == "" {
+		return commit.String()
+	}
+	return commit.String() + "\n" + rest
+}
+
+// changeHintFor returns the hint for diff under config's heuristics and
+// detect_breaking settings, with the scope of the package the diff is
+// confined to unless the heuristics chose one.
+func changeHintFor(config *Config, diff string) changeHint {
+	var hint changeHint
+	if config.Heuristics != heuristicsOff {
+		hint, _ = classifyDiff(diff)
+	}
+	if hint.Scope == "" {
+		hint.Scope = config.packageScope
+	}
+	if config.DetectBreaking {
+		hint.breaking = detectBreaking(diff)
+	}
+	return hint
+}
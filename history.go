@@ -0,0 +1,302 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// historyFile is the name of the suggestion log in the data directory.
+const historyFile = "history.jsonl"
+
+// maxHistoryPrompt caps how much of each prompt is logged, so large diffs
+// don't bloat the log.
+const maxHistoryPrompt = 32 << 10
+
+// The outcomes a historyRecord can have.
+const (
+	// outcomeSuggested means the message was only shown or printed.
+	outcomeSuggested = "suggested"
+	// outcomeAccepted means a suggestion was committed as it was.
+	outcomeAccepted = "accepted"
+	// outcomeEdited means a suggestion was changed before it was committed.
+	outcomeEdited = "edited"
+	// outcomeRejected means the commit was aborted.
+	outcomeRejected = "rejected"
+)
+
+// historyRecord is one line of the suggestion log: what was asked, what
+// the model suggested and what became of it.
+type historyRecord struct {
+	Time        time.Time `json:"time"`
+	Repo        string    `json:"repo,omitempty"`
+	Branch      string    `json:"branch,omitempty"`
+	Model       string    `json:"model"`
+	Prompt      string    `json:"prompt,omitempty"`
+	Suggestions []string  `json:"suggestions"`
+	Outcome     string    `json:"outcome"`
+	// Message is the committed message, when it is not one of Suggestions.
+	Message string `json:"message,omitempty"`
+}
+
+// defaultDataDir returns $XDG_DATA_HOME/git_commit_message, falling back
+// to %LOCALAPPDATA% on Windows and ~/.local/share everywhere else.
+func defaultDataDir() (string, error) {
+	if xdg := os.Getenv("XDG_DATA_HOME"); filepath.IsAbs(xdg) {
+		return filepath.Join(xdg, appDirName), nil
+	}
+	if localAppData := os.Getenv("LOCALAPPDATA"); runtime.GOOS == "windows" && localAppData != "" {
+		return filepath.Join(localAppData, appDirName), nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, ".local", "share", appDirName), nil
+}
+
+// promptRecorder keeps the first commit message prompt sent under a
+// context, for the suggestion log.
+type promptRecorder struct {
+	mu     sync.Mutex
+	prompt string
+}
+
+// record keeps prompt unless one was recorded already; regenerating or
+// shortening a message sends the same prompt again, or a longer one.
+func (r *promptRecorder) record(prompt string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.prompt == "" {
+		r.prompt = prompt
+	}
+}
+
+// promptRecorderKey is the context key for withPromptRecorder.
+type promptRecorderKey struct{}
+
+// withPromptRecorder returns a context whose commit message prompts are
+// recorded in r.
+func withPromptRecorder(ctx context.Context, r *promptRecorder) context.Context {
+	return context.WithValue(ctx, promptRecorderKey{}, r)
+}
+
+// promptRecorderFrom returns the promptRecorder set by withPromptRecorder, or nil.
+func promptRecorderFrom(ctx context.Context) *promptRecorder {
+	r, _ := ctx.Value(promptRecorderKey{}).(*promptRecorder)
+	return r
+}
+
+// recordHistory appends what became of suggestions to the log when
+// keep_history is on, with the prompt only if history_prompts is on too.
+// message is what was committed, or "". Like the
+// cache, the log is best effort and failing to write it never fails the run.
+func recordHistory(config *Config, recorder *promptRecorder, suggestions []string, outcome, message string) {
+	if !config.KeepHistory || len(suggestions) == 0 {
+		return
+	}
+	record := historyRecord{
+		Time:        time.Now().UTC(),
+		Branch:      currentBranch(),
+		Model:       providerLabel(config.providerConfig()),
+		Suggestions: suggestions,
+		Outcome:     outcome,
+	}
+	if top, err := runGit("rev-parse", "--show-toplevel"); err == nil {
+		record.Repo = strings.TrimSpace(top)
+	}
+	if recorder != nil && config.HistoryPrompts {
+		recorder.mu.Lock()
+		record.Prompt = recorder.prompt
+		recorder.mu.Unlock()
+		if len(record.Prompt) > maxHistoryPrompt {
+			cut := maxHistoryPrompt
+			for cut > 0 && !utf8.RuneStart(record.Prompt[cut]) {
+				cut--
+			}
+			record.Prompt = record.Prompt[:cut] + "\n[truncated]"
+		}
+	}
+	if message = strings.TrimSpace(message); message != "" && !slices.ContainsFunc(suggestions, func(s string) bool { return strings.TrimSpace(s) == message }) {
+		record.Message = message
+		if outcome == outcomeAccepted {
+			record.Outcome = outcomeEdited
+		}
+	}
+	if err := appendHistory(record); err != nil {
+		slog.Warn("could not write history", "err", err)
+	}
+}
+
+// appendHistory appends record to the log as one JSON line.
+func appendHistory(record historyRecord) error {
+	dir, err := defaultDataDir()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	data, err := json.Marshal(record)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filepath.Join(dir, historyFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
+
+// readHistory returns the logged records, newest first. Lines that can't
+// be decoded, such as one cut short by a crash, and records without a
+// message are skipped.
+func readHistory() ([]historyRecord, error) {
+	dir, err := defaultDataDir()
+	if err != nil {
+		return nil, err
+	}
+	file, err := os.Open(filepath.Join(dir, historyFile))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	var records []historyRecord
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(nil, 4*maxHistoryPrompt+1<<20)
+	for scanner.Scan() {
+		var record historyRecord
+		if json.Unmarshal(scanner.Bytes(), &record) == nil && record.finalMessage() != "" {
+			records = append(records, record)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("could not read the history: %w", err)
+	}
+	slices.Reverse(records)
+	return records, nil
+}
+
+// finalMessage returns the message the record ended with: the committed
+// message if it was edited, otherwise the first suggestion, or "" for a
+// record with neither.
+func (r historyRecord) finalMessage() string {
+	if r.Message != "" {
+		return r.Message
+	}
+	if len(r.Suggestions) == 0 {
+		return ""
+	}
+	return r.Suggestions[0]
+}
+
+// runHistory implements `git-commit-message history [show|use <n>]`:
+// list past suggestions, newest first, show one in full or commit the
+// staged changes with one.
+func runHistory(args []string) error {
+	flags := flag.NewFlagSet("history", flag.ExitOnError)
+	limit := flags.Int("n", 20, "list at most this many entries")
+	here := flags.Bool("here", false, "only list entries from the current repository")
+	grep := flags.String("grep", "", "only list entries whose messages contain this text")
+	showPrompt := flags.Bool("prompt", false, "with show, also print the prompt")
+	yes := flags.Bool("yes", false, "with use, commit without asking for confirmation")
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message history [flags] [show|use <n>]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	records, err := readHistory()
+	if err != nil {
+		return err
+	}
+	if *here {
+		top, err := runGit("rev-parse", "--show-toplevel")
+		if err != nil {
+			return err
+		}
+		records = slices.DeleteFunc(records, func(r historyRecord) bool { return r.Repo != strings.TrimSpace(top) })
+	}
+	if *grep != "" {
+		needle := strings.ToLower(*grep)
+		records = slices.DeleteFunc(records, func(r historyRecord) bool {
+			return !slices.ContainsFunc(append([]string{r.Message}, r.Suggestions...), func(m string) bool {
+				return strings.Contains(strings.ToLower(m), needle)
+			})
+		})
+	}
+
+	switch flags.NArg() {
+	case 0:
+		if len(records) == 0 {
+			fmt.Println("No suggestions in the history yet. 🤔")
+			return nil
+		}
+		for i, r := range records[:min(len(records), max(*limit, 1))] {
+			fmt.Printf("%3d  %s  %-9s  %-20s  %s\n", i+1, r.Time.Local().Format("2006-01-02 15:04"), r.Outcome, filepath.Base(r.Repo), subjectLine(r.finalMessage()))
+		}
+		return nil
+	case 2:
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	n, err := strconv.Atoi(flags.Arg(1))
+	if err != nil || n < 1 || n > len(records) {
+		return fmt.Errorf("no history entry %q; `history` lists them by number", flags.Arg(1))
+	}
+	record := records[n-1]
+	switch flags.Arg(0) {
+	case "show":
+		fmt.Printf("Time:    %s\nRepo:    %s\nBranch:  %s\nModel:   %s\nOutcome: %s\n", record.Time.Local().Format(time.RFC1123), record.Repo, record.Branch, record.Model, record.Outcome)
+		for i, s := range record.Suggestions {
+			fmt.Printf("\nSuggestion %d:\n%s\n", i+1, s)
+		}
+		if record.Message != "" {
+			fmt.Printf("\nCommitted message:\n%s\n", record.Message)
+		}
+		if *showPrompt && record.Prompt != "" {
+			fmt.Printf("\nPrompt:\n%s\n", record.Prompt)
+		}
+	case "use":
+		message := record.finalMessage()
+		fmt.Println(message)
+		if !*yes {
+			ok, err := confirm("\nCommit the staged changes with this message?")
+			if err != nil {
+				return err
+			}
+			if !ok {
+				fmt.Println("Commit aborted.")
+				return nil
+			}
+		}
+		return gitCommit(message, diffStaged)
+	default:
+		flags.Usage()
+		os.Exit(2)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// hookUsage documents the hook subcommand.
+const hookUsage = "usage: git-commit-message hook prepare-commit-msg <file> [<source> [<sha>]]\n       git-commit-message hook commit-msg [--fix] <file>"
+
+// runHook dispatches `git-commit-message hook <name> ...`, which is meant to
+// be called from the git hook of the same name.
+func runHook(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(hookUsage)
+	}
+	switch args[0] {
+	case "prepare-commit-msg":
+		return prepareCommitMsg(args[1:])
+	case "commit-msg":
+		return commitMsg(args[1:])
+	default:
+		return fmt.Errorf("unknown hook %q\n%s", args[0], hookUsage)
+	}
+}
+
+// prepareCommitMsg implements the prepare-commit-msg hook. git passes the
+// message file, the message source and, for amends, a commit SHA. The
+// generated suggestion is written above whatever git already put in the
+// file, so the editor opens with it pre-filled.
+func prepareCommitMsg(args []string) error {
+	if len(args) == 0 {
+		return fmt.Errorf(hookUsage)
+	}
+	messageFile := args[0]
+	source := ""
+	if len(args) > 1 {
+		source = args[1]
+	}
+
+	// Leave messages that already have meaningful content alone: -m/-F
+	// ("message"), squashes and amends/-c ("commit"). Templates only
+	// contain guidance, so the suggestion goes on top of them. git's merge
+	// message is replaced, keeping its comments, unless merge_messages is off.
+	switch source {
+	case "", "template", "merge":
+	default:
+		return nil
+	}
+
+	existing, err := os.ReadFile(messageFile)
+	if err != nil {
+		return fmt.Errorf("could not read commit message file %s: %w", messageFile, err)
+	}
+
+	config, err := resolveConfig(nil)
+	if err != nil {
+		return hookWarning(err)
+	}
+	var message string
+	recorder := &promptRecorder{}
+	ctx := withPromptRecorder(appCtx, recorder)
+	if source == "merge" {
+		heads := mergeHeads()
+		if !config.MergeMessages || len(heads) == 0 {
+			return nil
+		}
+		if message, err = mergeMessage(ctx, config, heads); err != nil {
+			return hookWarning(err)
+		}
+		existing = []byte(commentLines(string(existing)))
+	} else {
+		diff, err := collectDiff(config, diffStaged)
+		if err != nil {
+			return hookWarning(err)
+		}
+		if strings.TrimSpace(diff) == "" {
+			return nil
+		}
+		if message, err = cachedSuggestion(ctx, config, diff); err != nil {
+			return hookWarning(err)
+		}
+	}
+	if message == "" {
+		return nil
+	}
+	// git only commits after the hook has exited, so whether the message
+	// was kept or edited is not known here.
+	recordHistory(config, recorder, []string{message}, outcomeSuggested, "")
+
+	content := message + "\n" + string(existing)
+	if err := os.WriteFile(messageFile, []byte(content), 0o644); err != nil {
+		return fmt.Errorf("could not write commit message file %s: %w", messageFile, err)
+	}
+	return nil
+}
+
+// commentLines returns the lines of a commit message file that git will
+// strip, such as the list of conflicts and the instructions, with the
+// blank line before them.
+func commentLines(content string) string {
+	var b strings.Builder
+	for _, line := range strings.SplitAfter(content, "\n") {
+		if strings.HasPrefix(line, "#") {
+			b.WriteString(line)
+		}
+	}
+	if b.Len() == 0 {
+		return ""
+	}
+	return "\n" + b.String()
+}
+
+// hookWarning reports a generation failure without failing the hook: an
+// unreachable model must never stop someone from committing.
+func hookWarning(err error) error {
+	fmt.Fprintf(os.Stderr, "git-commit-message: could not suggest a commit message: %v\n", err)
+	return nil
+}
+
+// hookMarker identifies hook scripts written by install-hook, so that
+// uninstall-hook never deletes a hook somebody wrote by hand.
+const hookMarker = "# Installed by git-commit-message install-hook"
+
+// hookScript is the hook written by install-hook: its marker, this
+// executable and the hook name.
+const hookScript = `#!/bin/sh
+%s
+exec %s hook %s "$@"
+`
+
+// hookName returns the hook install-hook and uninstall-hook work on.
+func hookName(commitMsg bool) string {
+	if commitMsg {
+		return "commit-msg"
+	}
+	return "prepare-commit-msg"
+}
+
+// shellQuote quotes s for safe use in a POSIX shell script.
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// hooksDir returns the directory git runs hooks from, honouring core.hooksPath.
+func hooksDir() (string, error) {
+	top, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return "", fmt.Errorf("not inside a git repository: %w", err)
+	}
+	top = strings.TrimSpace(top)
+
+	if hooksPath, err := runGit("config", "--path", "core.hooksPath"); err == nil {
+		hooksPath = strings.TrimSpace(hooksPath)
+		if hooksPath != "" {
+			// Relative hooksPath values are resolved against the work tree root.
+			if !filepath.IsAbs(hooksPath) {
+				hooksPath = filepath.Join(top, hooksPath)
+			}
+			return hooksPath, nil
+		}
+	}
+
+	// --git-path copes with worktrees and separate git dirs.
+	dir, err := runGit("rev-parse", "--git-path", "hooks")
+	if err != nil {
+		return "", err
+	}
+	dir = strings.TrimSpace(dir)
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(top, dir)
+	}
+	return dir, nil
+}
+
+// runInstallHook implements `git-commit-message install-hook [--force] [--commit-msg]`.
+func runInstallHook(args []string) error {
+	flags := flag.NewFlagSet("install-hook", flag.ExitOnError)
+	force := flags.Bool("force", false, "overwrite an existing hook")
+	commitMsg := flags.Bool("commit-msg", false, "install the commit-msg hook that lints messages instead of the one that suggests them")
+	flags.Parse(args)
+
+	dir, err := hooksDir()
+	if err != nil {
+		return err
+	}
+	name := hookName(*commitMsg)
+	hookPath := filepath.Join(dir, name)
+
+	if existing, err := os.ReadFile(hookPath); err == nil {
+		if !strings.Contains(string(existing), hookMarker) && !*force {
+			return fmt.Errorf("%s already exists and was not installed by git-commit-message; use --force to overwrite it", hookPath)
+		}
+	}
+
+	executable, err := os.Executable()
+	if err != nil {
+		return fmt.Errorf("could not determine the path of this executable: %w", err)
+	}
+
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("could not create hooks directory %s: %w", dir, err)
+	}
+	script := fmt.Sprintf(hookScript, hookMarker, shellQuote(executable), name)
+	if err := os.WriteFile(hookPath, []byte(script), 0o755); err != nil {
+		return fmt.Errorf("could not write hook %s: %w", hookPath, err)
+	}
+
+	fmt.Printf("✅ Installed %s hook at %s\n", name, hookPath)
+	return nil
+}
+
+// runUninstallHook implements `git-commit-message uninstall-hook [--commit-msg]`.
+func runUninstallHook(args []string) error {
+	flags := flag.NewFlagSet("uninstall-hook", flag.ExitOnError)
+	commitMsg := flags.Bool("commit-msg", false, "remove the commit-msg hook instead of the prepare-commit-msg one")
+	flags.Parse(args)
+
+	dir, err := hooksDir()
+	if err != nil {
+		return err
+	}
+	name := hookName(*commitMsg)
+	hookPath := filepath.Join(dir, name)
+
+	existing, err := os.ReadFile(hookPath)
+	if os.IsNotExist(err) {
+		fmt.Printf("No %s hook installed. Nothing to do.\n", name)
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("could not read hook %s: %w", hookPath, err)
+	}
+	if !strings.Contains(string(existing), hookMarker) {
+		return fmt.Errorf("%s was not installed by git-commit-message; remove it by hand if you really want it gone", hookPath)
+	}
+
+	if err := os.Remove(hookPath); err != nil {
+		return fmt.Errorf("could not remove hook %s: %w", hookPath, err)
+	}
+	fmt.Printf("🗑️  Removed %s hook from %s\n", name, hookPath)
+	return nil
+}
@@ -0,0 +1,166 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Supported values for Config.ImperativeMood.
+const (
+	// moodRewrite turns known verbs into the imperative locally.
+	moodRewrite = "rewrite"
+	// moodModel also asks the model again when the first word still isn't
+	// in the imperative mood.
+	moodModel = "model"
+	moodOff   = "off"
+)
+
+// imperativeVerbs are the verbs moodRewrite knows. Their past tense,
+// gerund and third-person forms are derived by verbForms.
+var imperativeVerbs = []string{
+	"add", "adjust", "allow", "apply", "avoid", "build", "bump", "change",
+	"check", "clarify", "clean", "configure", "convert", "copy", "create",
+	"delete", "deprecate", "detect", "disable", "document", "drop", "enable",
+	"ensure", "expose", "extract", "fix", "format", "handle", "ignore",
+	"implement", "improve", "include", "increase", "initialize", "introduce",
+	"limit", "log", "make", "map", "merge", "migrate", "move", "optimize",
+	"parse", "prevent", "reduce", "refactor", "release", "remove", "rename",
+	"reorder", "replace", "resolve", "restore", "return", "revert", "rewrite",
+	"run", "simplify", "skip", "sort", "split", "stop", "strip", "support",
+	"switch", "tidy", "tweak", "update", "upgrade", "use", "validate", "wrap",
+	"write",
+}
+
+// irregularPast maps past tenses that verbForms can't derive to their verb.
+var irregularPast = map[string]string{
+	"built": "build", "made": "make", "ran": "run", "rewrote": "rewrite",
+	"rewritten": "rewrite", "wrote": "write", "written": "write",
+}
+
+// doubledVerbs double their last consonant before "-ed" and "-ing".
+var doubledVerbs = []string{"drop", "log", "map", "run", "skip", "stop", "strip", "wrap"}
+
+// moodTable maps every non-imperative form of imperativeVerbs to the verb.
+var moodTable = buildMoodTable()
+
+// buildMoodTable derives moodTable from imperativeVerbs.
+func buildMoodTable() map[string]string {
+	table := make(map[string]string, len(irregularPast)+3*len(imperativeVerbs))
+	for _, verb := range imperativeVerbs {
+		for _, form := range verbForms(verb) {
+			table[form] = verb
+		}
+	}
+	for form, verb := range irregularPast {
+		table[form] = verb
+	}
+	return table
+}
+
+// verbForms returns the regular past tense, gerund and third-person forms
+// of verb.
+func verbForms(verb string) []string {
+	last := verb[len(verb)-1]
+	switch {
+	case strings.HasSuffix(verb, "e"):
+		stem := strings.TrimSuffix(verb, "e")
+		return []string{verb + "d", stem + "ing", verb + "s"}
+	case last == 'y' && !strings.ContainsRune("aeiou", rune(verb[len(verb)-2])):
+		stem := strings.TrimSuffix(verb, "y")
+		return []string{stem + "ied", verb + "ing", stem + "ies"}
+	case strings.HasSuffix(verb, "s"), strings.HasSuffix(verb, "sh"), strings.HasSuffix(verb, "ch"), last == 'x', last == 'z':
+		return []string{verb + "ed", verb + "ing", verb + "es"}
+	}
+	for _, doubled := range doubledVerbs {
+		if verb == doubled {
+			return []string{verb + string(last) + "ed", verb + string(last) + "ing", verb + "s"}
+		}
+	}
+	return []string{verb + "ed", verb + "ing", verb + "s"}
+}
+
+// splitDescription splits a subject line into the part before the
+// description (`type(scope): ` or a gitmoji) and the description itself.
+func splitDescription(subject string) (prefix, description string) {
+	if m := conventionalHeader.FindStringSubmatchIndex(subject); m != nil {
+		return subject[:m[8]], subject[m[8]:]
+	}
+	if _, ok := leadingGitmoji(subject); ok {
+		if i := strings.IndexByte(subject, ' '); i >= 0 {
+			return subject[:i+1], subject[i+1:]
+		}
+	}
+	return "", subject
+}
+
+// imperativeSubject rewrites the first word of the description in message's
+// subject line to the imperative if moodTable knows it ("added" → "add"),
+// keeping a leading capital.
+func imperativeSubject(message string) string {
+	subject, rest, hasRest := strings.Cut(message, "\n")
+	prefix, description := splitDescription(subject)
+	word, tail, _ := strings.Cut(description, " ")
+	verb, ok := moodTable[strings.ToLower(word)]
+	if !ok {
+		return message
+	}
+	if first, _ := utf8.DecodeRuneInString(word); unicode.IsUpper(first) {
+		verb = strings.ToUpper(verb[:1]) + verb[1:]
+	}
+	subject = prefix + verb
+	if tail != "" {
+		subject += " " + tail
+	}
+	if hasRest {
+		return subject + "\n" + rest
+	}
+	return subject
+}
+
+// moodProblem reports why the description in message's subject line
+// doesn't start with an imperative verb, or "".
+func moodProblem(message string) string {
+	_, description := splitDescription(subjectLine(message))
+	words := strings.Fields(description)
+	if len(words) == 0 {
+		return ""
+	}
+	return imperativeProblem(words[0])
+}
+
+// moodPrompt asks the model to put the subject line in the imperative mood.
+func moodPrompt(prompt, previous, problem string) string {
+	return fmt.Sprintf(
+		"%s\n\nYour previous answer was:\n%s\n\nIts subject line must start with a verb in the imperative mood: %s. Reply again with only the corrected commit message.",
+		prompt, previous, problem,
+	)
+}
+
+// fixMood puts the subject line of message in the imperative mood, as
+// configured by config.ImperativeMood. Only English messages are checked.
+func fixMood(ctx context.Context, config *Config, prompt, message string, hint changeHint) (string, error) {
+	if config.ImperativeMood == moodOff || !config.english() {
+		return message, nil
+	}
+	message = imperativeSubject(message)
+	if config.ImperativeMood != moodModel {
+		return message, nil
+	}
+	for attempt := 0; attempt < rewordRetries; attempt++ {
+		problem := moodProblem(message)
+		if problem == "" {
+			break
+		}
+		slog.Info("subject line not in the imperative mood, asking again", "problem", problem)
+		retry, err := generateSuggestion(ctx, config, moodPrompt(prompt, message, problem))
+		if err != nil {
+			return "", err
+		}
+		message = imperativeSubject(hint.enforce(config.clean(retry)))
+	}
+	return message, nil
+}
@@ -0,0 +1,219 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// stdin is shared by every prompt so buffered input isn't lost between questions.
+var stdin = bufio.NewReader(os.Stdin)
+
+// readLine reads one line of user input without the trailing newline.
+func readLine() (string, error) {
+	answer, err := stdin.ReadString('\n')
+	if err != nil && answer == "" {
+		return "", err
+	}
+	return strings.TrimSpace(answer), nil
+}
+
+// confirm asks a yes/no question on stdin; an empty answer means yes.
+func confirm(question string) (bool, error) {
+	fmt.Printf("%s [Y/n] ", question)
+	answer, err := readLine()
+	if err != nil {
+		return false, err
+	}
+	switch strings.ToLower(answer) {
+	case "", "y", "yes":
+		return true, nil
+	default:
+		return false, nil
+	}
+}
+
+// pickMessage prints a numbered list of candidates and asks which one to
+// use. An empty answer picks the first.
+func pickMessage(candidates []string) (string, error) {
+	fmt.Println("\n📝 Candidate Commit Messages:")
+	for i, candidate := range candidates {
+		fmt.Printf("  %d) %s\n", i+1, candidate)
+	}
+	for {
+		fmt.Printf("Choose a message [1-%d] (default 1): ", len(candidates))
+		answer, err := readLine()
+		if err != nil {
+			return "", err
+		}
+		if answer == "" {
+			return candidates[0], nil
+		}
+		choice, err := strconv.Atoi(answer)
+		if err == nil && choice >= 1 && choice <= len(candidates) {
+			return candidates[choice-1], nil
+		}
+		fmt.Printf("Please enter a number between 1 and %d.\n", len(candidates))
+	}
+}
+
+// interactiveHelp lists the commands understood by runInteractive.
+const interactiveHelp = "[a]ccept and commit, [e]dit, [r]egenerate, set [t]ype, set [s]cope, [q]uit, or tell the model what to change"
+
+// runInteractive shows a summary of the diff and the suggestion, then lets
+// the user accept, edit, regenerate or tweak it until they are happy.
+// Anything longer than a command, such as "shorter" or "mention the
+// migration", is sent to the model as feedback on the message. It returns
+// the message to commit, or "" when the user quits.
+func runInteractive(ctx context.Context, config *Config, mode diffMode, diff, message string) (string, error) {
+	refine := newRefinement(config, diff)
+	if stat, err := getDiffStat(mode); err == nil {
+		fmt.Println("\n📊 Changes:")
+		fmt.Print(stat)
+	}
+
+	for {
+		fmt.Println("\n✅ Suggested Commit Message:")
+		fmt.Println(message)
+		fmt.Printf("\n%s: ", interactiveHelp)
+		answer, err := readLine()
+		if err != nil {
+			return "", err
+		}
+
+		switch strings.ToLower(answer) {
+		case "a", "accept", "":
+			return message, nil
+		case "e", "edit":
+			edited, err := editMessage(message, diff)
+			if err != nil {
+				fmt.Printf("⚠️  %v\n", err)
+				continue
+			}
+			if edited != "" {
+				message = edited
+			}
+		case "r", "regenerate":
+			fmt.Println("🤖 Regenerating commit message...")
+			regenerated, err := suggestMessage(ctx, config, diff)
+			if err != nil {
+				fmt.Printf("⚠️  %v\n", err)
+				continue
+			}
+			message = regenerated
+		case "t", "type", "s", "scope":
+			message = tweakHeader(message, strings.HasPrefix(strings.ToLower(answer), "t"))
+		case "q", "quit":
+			return "", nil
+		default:
+			if len(answer) < 2 {
+				fmt.Println("Unknown command.")
+				continue
+			}
+			fmt.Println("🤖 Revising commit message...")
+			revised, err := refine.revise(ctx, message, answer)
+			if err != nil {
+				fmt.Printf("⚠️  %v\n", err)
+				continue
+			}
+			message = revised
+		}
+	}
+}
+
+// tweakHeader asks for a new conventional commit type (or scope) and
+// rewrites the first line of message with it.
+func tweakHeader(message string, setType bool) string {
+	header, rest, _ := strings.Cut(message, "\n")
+	commit, ok := parseConventional(header)
+	if !ok {
+		// Treat a free-form line as the subject of a new conventional header.
+		commit = conventionalCommit{Type: "chore", Subject: header}
+	}
+
+	if setType {
+		fmt.Printf("New type (currently %q): ", commit.Type)
+	} else {
+		fmt.Printf("New scope (currently %q, '-' to remove): ", commit.Scope)
+	}
+	answer, err := readLine()
+	if err != nil || answer == "" {
+		return message
+	}
+	if setType {
+		commit.Type = strings.ToLower(answer)
+	} else if answer == "-" {
+		commit.Scope = ""
+	} else {
+		commit.Scope = answer
+	}
+
+	if rest == "" {
+		return commit.String()
+	}
+	return commit.String() + "\n" + rest
+}
+
+// scissors is the line git puts above the diff in the editor with
+// `git commit -v`. Everything from it on is not part of the message.
+const scissors = "# ------------------------ >8 ------------------------"
+
+// editMessage opens message in the user's git editor and returns the saved
+// text with comment lines removed. A non-empty diff is shown below the
+// message for reference, the way `git commit -v` does.
+func editMessage(message, diff string) (string, error) {
+	file, err := os.CreateTemp("", "git-commit-message-*.txt")
+	if err != nil {
+		return "", fmt.Errorf("could not create temporary file: %w", err)
+	}
+	defer os.Remove(file.Name())
+
+	content := message + "\n\n# Edit the commit message above. Lines starting with '#' are ignored.\n"
+	if diff != "" {
+		content += scissors + "\n# Do not modify or remove the line above.\n# Everything below it will be ignored.\n" + diff
+	}
+	if _, err := file.WriteString(content); err != nil {
+		file.Close()
+		return "", fmt.Errorf("could not write temporary file: %w", err)
+	}
+	file.Close()
+
+	// `git var GIT_EDITOR` honours GIT_EDITOR, core.editor, VISUAL and EDITOR.
+	editor, err := runGit("var", "GIT_EDITOR")
+	if err != nil {
+		return "", err
+	}
+	cmd := exec.Command("sh", "-c", strings.TrimSpace(editor)+` "$@"`, "editor", file.Name())
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return "", fmt.Errorf("editor exited with an error: %w", err)
+	}
+
+	edited, err := os.ReadFile(file.Name())
+	if err != nil {
+		return "", fmt.Errorf("could not read edited message: %w", err)
+	}
+	return stripComments(string(edited)), nil
+}
+
+// stripComments removes git-style comment lines, everything from the
+// scissors line on, and surrounding blank lines.
+func stripComments(text string) string {
+	var lines []string
+	for _, line := range strings.Split(text, "\n") {
+		if line == scissors {
+			break
+		}
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		lines = append(lines, strings.TrimRight(line, " \t"))
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+// interruptGrace is how long in-flight git commands and requests get to
+// wind down after Ctrl-C before the process exits.
+const interruptGrace = 200 * time.Millisecond
+
+// appCtx is cancelled on Ctrl-C or SIGTERM. Git commands and model
+// requests run under it, so an interrupt stops them at once instead of
+// leaving a request running until it times out.
+var appCtx = context.Background()
+
+// echoOff is set while readSecret has turned terminal echo off, so an
+// interrupt can turn it back on.
+var echoOff atomic.Bool
+
+// handleInterrupts sets up appCtx. On the first SIGINT or SIGTERM it is
+// cancelled, the terminal is restored and the process exits with the
+// conventional status 130.
+func handleInterrupts() {
+	ctx, cancel := context.WithCancel(context.Background())
+	appCtx = ctx
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		// The cancelled work fails with "context canceled"; keep that out
+		// of sight, and hold log.Fatalf so the exit status stays 130.
+		logLevel.Set(slog.LevelError + 1)
+		log.SetOutput(stalledWriter{})
+		cancel()
+		time.Sleep(interruptGrace)
+		restoreTerminal()
+		fmt.Fprintln(os.Stderr, "\nInterrupted.")
+		os.Exit(130)
+	}()
+}
+
+// stalledWriter blocks every write forever.
+type stalledWriter struct{}
+
+func (stalledWriter) Write(p []byte) (int, error) {
+	select {}
+}
+
+// restoreTerminal undoes terminal changes made while reading input and
+// clears a half-drawn spinner line.
+func restoreTerminal() {
+	if echoOff.Load() {
+		stty("echo")
+	}
+	if isTerminal(os.Stdout) {
+		fmt.Fprint(os.Stdout, "\r\033[K")
+	}
+}
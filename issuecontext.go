@@ -0,0 +1,190 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/issues"
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// issueNumbers returns the numbers of the issues the change is for: the
+// one in the branch name and any same-repository --closes references.
+func issueNumbers(config *Config) []string {
+	var numbers []string
+	if number, err := issueFromBranch(currentBranch(), config.IssueBranchPattern); err == nil && number != "" {
+		numbers = append(numbers, number)
+	}
+	for _, ref := range config.Closes {
+		if m := issueReference.FindStringSubmatch(strings.TrimSpace(ref)); m != nil && m[1] == "" && !slices.Contains(numbers, m[2]) {
+			numbers = append(numbers, m[2])
+		}
+	}
+	return numbers
+}
+
+// platformToken returns the API token for platform from the environment
+// or the keyring, or "" for anonymous requests.
+func platformToken(platform string, envVars ...string) string {
+	for _, name := range envVars {
+		if token := os.Getenv(name); token != "" {
+			return token
+		}
+	}
+	token, err := keyringGet(platform)
+	if err != nil {
+		slog.Debug("no token in keyring", "platform", platform, "err", err)
+		return ""
+	}
+	return token
+}
+
+// JiraConfig is the `jira:` section of the config.
+type JiraConfig struct {
+	// URL is the Jira site, e.g. https://example.atlassian.net.
+	URL string `yaml:"url"`
+	// User is the account email on Jira Cloud, where Token is an API token.
+	// Leave it empty on Jira Data Center, where Token is a personal access token.
+	User string `yaml:"user"`
+	// Token defaults to $JIRA_API_TOKEN or the keyring's "jira" entry.
+	Token string `yaml:"token"`
+}
+
+// jiraContext looks up the Jira ticket named in the branch and returns it
+// as a prompt section, or "" when there is none or it can't be fetched.
+func jiraContext(config *Config) string {
+	key, err := ticketFromBranch(currentBranch(), config.TicketPattern)
+	if err != nil || key == "" {
+		return ""
+	}
+	client, err := issueClient(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return ""
+	}
+	cfg := issues.Config{URL: config.Jira.URL, User: config.Jira.User, Token: config.Jira.Token, Timeout: config.Timeout, Client: client}
+	if cfg.Token == "" {
+		cfg.Token = platformToken(platformJira, "JIRA_API_TOKEN")
+	}
+	issue, err := issues.Jira(appCtx, cfg, key)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return ""
+	}
+	return issueSection([]issues.Issue{issue}, nil)
+}
+
+// issueClient returns the client for issue lookups: the one models are
+// reached with, so ca_bundle and insecure_skip_verify apply to them too.
+func issueClient(config *Config) (*http.Client, error) {
+	return provider.HTTPClient(config.providerConfig())
+}
+
+// issueAPI is how issue context is looked up on one platform.
+type issueAPI struct {
+	// tokenEnv are the environment variables that may hold an API token.
+	tokenEnv []string
+	// host is the platform's public host. Self-hosted instances serve the
+	// API under apiPath; "" means they aren't supported.
+	host, apiPath string
+	issue         func(ctx context.Context, cfg issues.Config, repo, number string) (issues.Issue, error)
+	// changeRequest finds the open merge or pull request for a branch.
+	// It is nil where that isn't looked up.
+	changeRequest func(ctx context.Context, cfg issues.Config, repo, branch string) (issues.ChangeRequest, bool, error)
+}
+
+// issueAPIs holds the issueAPI of every platform.
+var issueAPIs = map[string]issueAPI{
+	platformGitHub:    {[]string{"GITHUB_TOKEN", "GH_TOKEN"}, "github.com", "/api/v3", issues.GitHub, nil},
+	platformGitLab:    {[]string{"GITLAB_TOKEN", "GL_TOKEN"}, "gitlab.com", "/api/v4", issues.GitLab, issues.GitLabMergeRequest},
+	platformBitbucket: {[]string{"BITBUCKET_TOKEN"}, "bitbucket.org", "", issues.Bitbucket, issues.BitbucketPullRequest},
+}
+
+// issueContext looks up the issues the change is for, and the merge or
+// pull request open for the branch, on the platform hosting the issue
+// remote, and returns them as a prompt section. Lookups that fail are
+// reported and skipped.
+func issueContext(config *Config) string {
+	repo, ok := issueRemote(config)
+	if !ok {
+		slog.Info("no issue remote to fetch issues from", "remote", config.IssueRemote)
+		return ""
+	}
+	platform := issuePlatform(config)
+	api, ok := issueAPIs[platform]
+	if !ok {
+		slog.Info("issue context not supported", "host", repo.Host)
+		return ""
+	}
+	client, err := issueClient(config)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		return ""
+	}
+	cfg := issues.Config{URL: config.IssueAPIURL, Token: platformToken(platform, api.tokenEnv...), Timeout: config.Timeout, Client: client}
+	if cfg.URL == "" && repo.Host != api.host {
+		if api.apiPath == "" {
+			slog.Info("issue context not supported on self-hosted instances", "platform", platform, "host", repo.Host)
+			return ""
+		}
+		cfg.URL = "https://" + repo.Host + api.apiPath
+	}
+
+	var found []issues.Issue
+	for _, number := range issueNumbers(config) {
+		issue, err := api.issue(appCtx, cfg, repo.Path, number)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+			continue
+		}
+		found = append(found, issue)
+	}
+	var request *issues.ChangeRequest
+	if branch := currentBranch(); api.changeRequest != nil && branch != "" {
+		cr, ok, err := api.changeRequest(appCtx, cfg, repo.Path, branch)
+		switch {
+		case err != nil:
+			fmt.Fprintf(os.Stderr, "⚠️  %v\n", err)
+		case ok:
+			request = &cr
+		}
+	}
+	return issueSection(found, request)
+}
+
+// maxDescriptionTokens caps how much of an issue or merge request
+// description goes into the prompt.
+const maxDescriptionTokens = 300
+
+// issueSection formats the issues and the merge or pull request as a
+// prompt section, or "" if there are none.
+func issueSection(found []issues.Issue, request *issues.ChangeRequest) string {
+	var b strings.Builder
+	if len(found) > 0 {
+		b.WriteString("The change is work on the following issue. Use it to explain why the change was made, but describe what the diff actually does:\n")
+		for _, issue := range found {
+			fmt.Fprintf(&b, "- %s: %s", issue.Ref, issue.Title)
+			if len(issue.Labels) > 0 {
+				fmt.Fprintf(&b, " (labels: %s)", strings.Join(issue.Labels, ", "))
+			}
+			b.WriteString("\n")
+			if description := strings.TrimSpace(issue.Description); description != "" {
+				b.WriteString("  Description: " + truncateToTokens(description, maxDescriptionTokens) + "\n")
+			}
+		}
+		b.WriteString("\n")
+	}
+	if request != nil {
+		fmt.Fprintf(&b, "The branch is under review as %s: %s\n", request.Ref, request.Title)
+		if description := strings.TrimSpace(request.Description); description != "" {
+			b.WriteString("Its description:\n" + truncateToTokens(description, maxDescriptionTokens) + "\n")
+		}
+		b.WriteString("\n")
+	}
+	return b.String()
+}
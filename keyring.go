@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"os/exec"
+	"runtime"
+	"slices"
+	"strings"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// keyringService is the service name API keys are stored under.
+const keyringService = "git-commit-message"
+
+// keyringProviders are the providers authenticated with an API key. Ollama
+// needs none and Bedrock uses the AWS credential chain.
+var keyringProviders = []string{"openai", "azure", "anthropic", "gemini"}
+
+// keyringPlatforms are the issue platforms whose API tokens can be stored
+// in the keyring too, for fetching issue context.
+var keyringPlatforms = []string{platformGitHub, platformGitLab, platformBitbucket, platformJira}
+
+// keyringAccounts returns every account `auth` can store a key for.
+func keyringAccounts() []string {
+	return slices.Concat(keyringProviders, keyringPlatforms)
+}
+
+// The PowerShell snippets used on Windows. The account name and secret are
+// passed through the environment and stdin rather than the command line.
+const (
+	psVault    = "[void][Windows.Security.Credentials.PasswordVault,Windows.Security.Credentials,ContentType=WindowsRuntime]; $v = New-Object Windows.Security.Credentials.PasswordVault; "
+	psGetKey   = psVault + "$c = $v.Retrieve('" + keyringService + "', $env:GCM_KEYRING_ACCOUNT); $c.RetrievePassword(); $c.Password"
+	psSetKey   = psVault + "$v.Add((New-Object Windows.Security.Credentials.PasswordCredential('" + keyringService + "', $env:GCM_KEYRING_ACCOUNT, [Console]::In.ReadLine())))"
+	psClearKey = psVault + "$v.Remove($v.Retrieve('" + keyringService + "', $env:GCM_KEYRING_ACCOUNT))"
+)
+
+// keyringCommand runs one of the platform's keyring tools: security on
+// macOS, PowerShell's PasswordVault on Windows and secret-tool (libsecret)
+// everywhere else.
+func keyringCommand(account, input string, args ...string) (string, error) {
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Env = append(os.Environ(), "GCM_KEYRING_ACCOUNT="+account)
+	cmd.Stdin = strings.NewReader(input)
+	var stderr strings.Builder
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return "", fmt.Errorf("%s: %w: %s", args[0], err, msg)
+		}
+		return "", fmt.Errorf("%s: %w", args[0], err)
+	}
+	return strings.TrimSpace(string(output)), nil
+}
+
+// securityQuote quotes s for a command line read by `security -i`.
+func securityQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(s) + `"`
+}
+
+// keyringGet returns the API key stored for account.
+func keyringGet(account string) (string, error) {
+	switch runtime.GOOS {
+	case "darwin":
+		return keyringCommand(account, "", "security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	case "windows":
+		return keyringCommand(account, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", psGetKey)
+	default:
+		return keyringCommand(account, "", "secret-tool", "lookup", "service", keyringService, "account", account)
+	}
+}
+
+// keyringSet stores secret for account, replacing any existing key. The
+// secret is written to the tool's stdin so it never shows up in ps.
+func keyringSet(account, secret string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		line := "add-generic-password -U -s " + securityQuote(keyringService) + " -a " + securityQuote(account) + " -w " + securityQuote(secret) + "\n"
+		_, err = keyringCommand(account, line, "security", "-i")
+	case "windows":
+		_, err = keyringCommand(account, secret+"\n", "powershell", "-NoProfile", "-NonInteractive", "-Command", psSetKey)
+	default:
+		_, err = keyringCommand(account, secret, "secret-tool", "store", "--label=git-commit-message "+account+" API key", "service", keyringService, "account", account)
+	}
+	return err
+}
+
+// keyringDelete removes the key stored for account.
+func keyringDelete(account string) error {
+	var err error
+	switch runtime.GOOS {
+	case "darwin":
+		_, err = keyringCommand(account, "", "security", "delete-generic-password", "-s", keyringService, "-a", account)
+	case "windows":
+		_, err = keyringCommand(account, "", "powershell", "-NoProfile", "-NonInteractive", "-Command", psClearKey)
+	default:
+		_, err = keyringCommand(account, "", "secret-tool", "clear", "service", keyringService, "account", account)
+	}
+	return err
+}
+
+// withKeyringKeys fills in the API key from the keyring for every config
+// that needs one and has none configured. A missing keyring or key is not
+// an error: the backend still falls back to its environment variable.
+func withKeyringKeys(configs []provider.Config) []provider.Config {
+	for i, pc := range configs {
+		account := strings.ToLower(pc.Provider)
+		if pc.APIKey != "" || !slices.Contains(keyringProviders, account) {
+			continue
+		}
+		key, err := keyringGet(account)
+		if err != nil {
+			slog.Debug("no API key in keyring", "provider", account, "err", err)
+			continue
+		}
+		configs[i].APIKey = key
+	}
+	return configs
+}
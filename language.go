@@ -0,0 +1,85 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+)
+
+// languageNames maps common language codes to the names we put in prompts;
+// models follow "German" more reliably than "de".
+var languageNames = map[string]string{
+	"ar":    "Arabic",
+	"cs":    "Czech",
+	"da":    "Danish",
+	"de":    "German",
+	"el":    "Greek",
+	"en":    "English",
+	"es":    "Spanish",
+	"fi":    "Finnish",
+	"fr":    "French",
+	"he":    "Hebrew",
+	"hi":    "Hindi",
+	"hu":    "Hungarian",
+	"id":    "Indonesian",
+	"it":    "Italian",
+	"ja":    "Japanese",
+	"ko":    "Korean",
+	"nl":    "Dutch",
+	"no":    "Norwegian",
+	"pl":    "Polish",
+	"pt":    "Portuguese",
+	"pt-br": "Brazilian Portuguese",
+	"ro":    "Romanian",
+	"ru":    "Russian",
+	"sv":    "Swedish",
+	"th":    "Thai",
+	"tr":    "Turkish",
+	"uk":    "Ukrainian",
+	"vi":    "Vietnamese",
+	"zh":    "Chinese",
+	"zh-cn": "Simplified Chinese",
+	"zh-tw": "Traditional Chinese",
+}
+
+// languageName returns the prompt-friendly name for a language setting.
+// Unknown values are assumed to already be a name ("Esperanto") and are
+// passed through unchanged.
+func languageName(language string) string {
+	key := strings.ToLower(strings.ReplaceAll(language, "_", "-"))
+	if name, ok := languageNames[key]; ok {
+		return name
+	}
+	return language
+}
+
+// english reports whether messages are written in English, the only
+// language whose grammar is checked.
+func (c *Config) english() bool {
+	return c.Language == "" || strings.HasPrefix(strings.ToLower(c.Language), "en")
+}
+
+// runeWidth approximates how many terminal columns r occupies: East Asian
+// wide and fullwidth characters take two, combining marks none.
+func runeWidth(r rune) int {
+	switch {
+	case unicode.Is(unicode.Mn, r), r == '\u200d', r == '\ufe0f':
+		return 0
+	case unicode.Is(unicode.Han, r), unicode.Is(unicode.Hiragana, r),
+		unicode.Is(unicode.Katakana, r), unicode.Is(unicode.Hangul, r),
+		r >= 0x3000 && r <= 0x303f,   // CJK punctuation
+		r >= 0xff00 && r <= 0xff60,   // fullwidth forms
+		r >= 0x1f300 && r <= 0x1faff: // emoji
+		return 2
+	default:
+		return 1
+	}
+}
+
+// displayWidth returns the approximate column width of s.
+func displayWidth(s string) int {
+	width := 0
+	for _, r := range s {
+		width += runeWidth(r)
+	}
+	return width
+}
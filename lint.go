@@ -0,0 +1,225 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// LintConfig holds the `lint:` rules. Keys left out of the config keep the
+// values from defaultLintConfig.
+type LintConfig struct {
+	// MaxSubjectLength caps the subject line, in display columns, both here
+	// and for generated messages. 0 disables it.
+	MaxSubjectLength int `yaml:"max_subject_length"`
+	// MaxBodyLineLength caps body lines; URLs and footers are exempt. 0 disables it.
+	MaxBodyLineLength int `yaml:"max_body_line_length"`
+	// Imperative checks that the subject starts with a verb in the
+	// imperative mood ("add", not "added" or "adds"). English only.
+	Imperative bool `yaml:"imperative"`
+	// Types restricts the conventional commit type. Empty allows the standard types.
+	Types []string `yaml:"types"`
+	// Scopes restricts the conventional commit scope. Empty allows any scope.
+	Scopes []string `yaml:"scopes"`
+}
+
+// defaultLintConfig returns the rules used when the config has no `lint:` section.
+func defaultLintConfig() LintConfig {
+	return LintConfig{
+		MaxSubjectLength:  72,
+		MaxBodyLineLength: bodyWidth,
+		Imperative:        true,
+	}
+}
+
+// strictSubjectLength is the subject limit with strict_subject, the
+// 50 columns many projects and git's own documentation recommend.
+const strictSubjectLength = 50
+
+// subjectLimit returns the longest subject line allowed, in display
+// columns, or 0 for no limit.
+func (c *Config) subjectLimit() int {
+	limit := c.Lint.MaxSubjectLength
+	if c.StrictSubject && (limit == 0 || limit > strictSubjectLength) {
+		limit = strictSubjectLength
+	}
+	return limit
+}
+
+// imperativeExceptions end like a past tense, gerund or third-person verb
+// but are fine as the first word of a subject.
+var imperativeExceptions = []string{
+	"bleed", "breed", "bring", "embed", "exceed", "feed", "need", "proceed",
+	"seed", "shed", "speed", "string", "succeed", "alias", "canvas", "focus",
+}
+
+// imperativeProblem reports why word is not an imperative verb, or "".
+func imperativeProblem(word string) string {
+	word = strings.ToLower(strings.Trim(word, ".,:;!?\"'`"))
+	if len(word) < 4 || slices.Contains(imperativeExceptions, word) {
+		return ""
+	}
+	switch {
+	case strings.HasSuffix(word, "ed"), strings.HasSuffix(word, "ing"):
+		return fmt.Sprintf("%q is not in the imperative mood (write 'add', not 'added' or 'adding')", word)
+	case strings.HasSuffix(word, "s") && !strings.HasSuffix(word, "ss") &&
+		!strings.HasSuffix(word, "us") && !strings.HasSuffix(word, "is"):
+		return fmt.Sprintf("%q is not in the imperative mood (write 'add', not 'adds')", word)
+	}
+	return ""
+}
+
+// skipLint reports whether message was written by git or another tool and
+// shouldn't be held to the rules.
+func skipLint(subject string) bool {
+	for _, prefix := range []string{"Merge ", "Revert \"", "fixup! ", "squash! ", "amend! "} {
+		if strings.HasPrefix(subject, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// lintMessage checks message against the configured rules and returns one
+// line per violation.
+func lintMessage(config *Config, message string) []string {
+	rules := config.Lint
+	parsed := parseCommitMessage(message)
+	subject := parsed.Subject
+	if skipLint(subject) {
+		return nil
+	}
+
+	var problems []string
+	if strings.TrimSpace(subject) == "" {
+		return []string{"the subject line is empty"}
+	}
+	if limit := config.subjectLimit(); limit > 0 {
+		if width := displayWidth(subject); width > limit {
+			problems = append(problems, fmt.Sprintf("the subject is %d characters long, more than %d", width, limit))
+		}
+	}
+	if lines := strings.SplitN(message, "\n", 3); len(lines) > 1 && strings.TrimSpace(lines[1]) != "" {
+		problems = append(problems, "the subject must be followed by a blank line")
+	}
+
+	description := subject
+	switch config.Convention {
+	case conventionGitmoji:
+		entry, ok := leadingGitmoji(subject)
+		if !ok {
+			problems = append(problems, "the subject does not start with a gitmoji")
+		} else {
+			description = strings.TrimSpace(strings.TrimPrefix(normalizeEmoji(subject), normalizeEmoji(entry.Emoji)))
+		}
+	default:
+		commit, ok := parseConventional(subject)
+		if !ok {
+			problems = append(problems, "the subject does not match the format 'type(scope): subject'")
+			break
+		}
+		description = commit.Subject
+		types := rules.Types
+		if len(types) == 0 {
+			types = conventionalTypes
+		}
+		if !slices.Contains(types, commit.Type) {
+			problems = append(problems, fmt.Sprintf("%q is not an allowed type (use one of %s)", commit.Type, strings.Join(types, ", ")))
+		}
+		if len(rules.Scopes) > 0 && commit.Scope != "" && !slices.Contains(rules.Scopes, commit.Scope) {
+			problems = append(problems, fmt.Sprintf("%q is not an allowed scope (use one of %s)", commit.Scope, strings.Join(rules.Scopes, ", ")))
+		}
+	}
+
+	if rules.Imperative && config.english() {
+		if words := strings.Fields(description); len(words) > 0 {
+			if problem := imperativeProblem(words[0]); problem != "" {
+				problems = append(problems, problem)
+			}
+		}
+	}
+
+	if rules.MaxBodyLineLength > 0 {
+		for _, line := range strings.Split(parsed.Body, "\n") {
+			if displayWidth(line) > rules.MaxBodyLineLength && !strings.Contains(line, "://") {
+				problems = append(problems, fmt.Sprintf("body line is longer than %d characters: %q", rules.MaxBodyLineLength, line))
+			}
+		}
+	}
+	return problems
+}
+
+// runLint implements `git-commit-message lint [rev-range|file]`. It prints
+// every violation and exits 1 if there were any, so it can be used as a
+// commit-msg hook (`lint "$1"`) and in CI (`lint origin/main..HEAD`).
+func runLint(args []string) error {
+	flags := flag.NewFlagSet("lint", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message lint [flags] [rev-range|file] (default HEAD)")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	target := flags.Arg(0)
+	if target == "" {
+		target = "HEAD"
+	}
+
+	// Linting needs no model.
+	config, err := resolveSettings(overrides)
+	if err != nil {
+		return err
+	}
+
+	var commits []logCommit
+	if data, err := os.ReadFile(target); err == nil {
+		subject, body, _ := strings.Cut(strings.TrimSpace(stripComments(string(data))), "\n")
+		commits = []logCommit{{Subject: subject, Body: body}}
+	} else {
+		revs := []string{target}
+		if !strings.Contains(target, "..") {
+			revs = []string{"-1", target}
+		}
+		if commits, err = rangeCommits(revs...); err != nil {
+			return fmt.Errorf("%q is neither a file nor a revision range: %w", target, err)
+		}
+		// rangeCommits drops the blank line after the subject.
+		for i := range commits {
+			if commits[i].Body != "" {
+				commits[i].Body = "\n" + commits[i].Body
+			}
+		}
+	}
+
+	failed := 0
+	for _, commit := range commits {
+		message := commit.Subject
+		if commit.Body != "" {
+			message += "\n" + commit.Body
+		}
+		problems := lintMessage(config, message)
+		if len(problems) == 0 {
+			continue
+		}
+		failed++
+		label := target
+		if commit.SHA != "" {
+			label = commit.SHA[:7]
+		}
+		fmt.Printf("❌ %s: %s\n", label, commit.Subject)
+		for _, problem := range problems {
+			fmt.Printf("   - %s\n", problem)
+		}
+	}
+	if failed > 0 {
+		fmt.Fprintf(os.Stderr, "%d of %d message(s) failed lint\n", failed, len(commits))
+		os.Exit(1)
+	}
+	return nil
+}
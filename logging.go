@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// logLevel is shared by the default slog handler so the level can be raised
+// once the configuration (or -v/-vv) is known.
+var logLevel slog.LevelVar
+
+// setupLogging installs a stderr handler that only shows warnings until
+// applyLogLevel says otherwise. stdout is left alone for the message itself.
+func setupLogging() {
+	logLevel.Set(slog.LevelWarn)
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: &logLevel})))
+	// SetDefault also routes the log package through the handler, at info
+	// level; keep log.Fatalf errors visible regardless of the slog level.
+	log.SetOutput(os.Stderr)
+}
+
+// applyLogLevel sets the level from config.LogLevel (debug, info, warn or error).
+func applyLogLevel(config *Config) error {
+	if config.LogLevel == "" {
+		return nil
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(config.LogLevel)); err != nil {
+		return fmt.Errorf("unknown log_level %q (expected debug, info, warn or error)", config.LogLevel)
+	}
+	logLevel.Set(level)
+	return nil
+}
+
+// LogValue implements slog.LogValuer so the resolved config can be logged
+// without leaking API keys.
+func (c *Config) LogValue() slog.Value {
+	var fallbacks []string
+	for _, pc := range c.fallbackConfigs()[1:] {
+		fallbacks = append(fallbacks, providerLabel(pc))
+	}
+	return slog.GroupValue(
+		slog.String("provider", providerLabel(c.providerConfig())),
+		slog.String("url", c.providerConfig().URL),
+		slog.Bool("api_key_set", c.APIKey != ""),
+		slog.Bool("proxy_auth_set", c.AuthToken != "" || c.BasicAuth != nil),
+		slog.Int("headers", len(c.Headers)),
+		slog.Float64("temperature", c.Temperature),
+		slog.Int("max_tokens", c.MaxTokens),
+		slog.String("convention", c.Convention),
+		slog.String("language", c.Language),
+		slog.Bool("body", c.Body),
+		slog.Int("token_budget", c.TokenBudget),
+		slog.Int("context_window", c.contextWindow()),
+		slog.Int("diff_budget", c.diffBudget()),
+		slog.Bool("redact", c.Redact),
+		slog.String("exclude", strings.Join(c.Exclude, ",")),
+		slog.Duration("timeout", c.Timeout),
+		slog.Int("retries", c.Retries),
+		slog.String("fallbacks", strings.Join(fallbacks, ",")),
+	)
+}
@@ -2,173 +2,549 @@
 package main
 
 import (
-	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io"
 	"log"
-	"net/http"
+	"log/slog"
 	"os"
-	"os/exec"
-	"path/filepath"
 	"strings"
-	"time"
+	"sync"
 
-	"gopkg.in/yaml.v3"
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
 )
 
-// Config struct mirrors the structure of our config.yaml file.
-type Config struct {
-	OllamaURL   string  `yaml:"ollama_url"`
-	Model       string  `yaml:"model"`
-	Temperature float64 `yaml:"temperature"`
+// correctivePrompt asks the model to fix a reply that failed validation.
+func correctivePrompt(prompt, previous string, problem error) string {
+	return fmt.Sprintf(
+		"%s\n\nYour previous answer was:\n%s\n\nThat is not a valid conventional commit message: %v. Reply again with only a corrected commit message.",
+		prompt, previous, problem,
+	)
 }
 
-// OllamaRequest defines the structure for the JSON payload sent to Ollama.
-type OllamaRequest struct {
-	Model   string `json:"model"`
-	Prompt  string `json:"prompt"`
-	Stream  bool   `json:"stream"`
-	Options struct {
-		Temperature float64 `json:"temperature"`
-	} `json:"options"`
+// shorterSubjectPrompt asks the model to shorten a subject line that is
+// over the configured limit.
+func shorterSubjectPrompt(prompt, previous string, width, limit int) string {
+	return fmt.Sprintf(
+		"%s\n\nYour previous answer was:\n%s\n\nIts subject line is %d characters long, but it must be at most %d. Reply again with only the commit message, with a shorter subject line that says the same thing.",
+		prompt, previous, width, limit,
+	)
 }
 
-// OllamaResponse defines the structure to decode the JSON response from Ollama.
-type OllamaResponse struct {
-	Response string `json:"response"`
+// generateCommitMessage sends the prompt to the configured provider and gets a commit message.
+func generateCommitMessage(ctx context.Context, config *Config, prompt string) (string, error) {
+	return generate(ctx, config, prompt, false, nil)
 }
 
-// loadConfig reads and parses the configuration from the YAML file.
-func loadConfig() (*Config, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return nil, fmt.Errorf("could not get user home directory: %w", err)
-	}
+// generateJSON is generateCommitMessage for prompts asking for a JSON
+// reply; providers that support it are held to schema.
+func generateJSON(ctx context.Context, config *Config, prompt string, schema json.RawMessage) (string, error) {
+	return generate(ctx, config, prompt, false, schema)
+}
 
-	configPath := filepath.Join(homeDir, ".config", "git_commit_message", "config.yaml")
+// generateSuggestion is generateCommitMessage for prompts asking for the
+// commit message itself: the configured examples and the context's
+// history are sent ahead of the prompt, structured output is requested if configured, and the reply is
+// streamed to the context's liveOutput, if any.
+func generateSuggestion(ctx context.Context, config *Config, prompt string) (string, error) {
+	return generate(ctx, config, prompt, true, nil)
+}
 
-	configFile, err := os.ReadFile(configPath)
+// generate sends prompt to the configured provider, asking for a reply
+// matching schema if it is set. suggestion adds what generateSuggestion
+// describes.
+func generate(ctx context.Context, config *Config, prompt string, suggestion bool, schema json.RawMessage) (string, error) {
+	generator, err := config.generator()
 	if err != nil {
-		return nil, fmt.Errorf("could not read config file at %s: %w", configPath, err)
+		return "", err
 	}
-
-	var config Config
-	if err := yaml.Unmarshal(configFile, &config); err != nil {
-		return nil, fmt.Errorf("could not parse yaml config: %w", err)
+	if recorder := promptRecorderFrom(ctx); recorder != nil && suggestion {
+		recorder.record(prompt)
+	}
+	opts := config.generateOptions()
+	opts.System, prompt = splitSystem(prompt)
+	opts.Schema = schema
+	var live *liveOutput
+	if suggestion {
+		opts.Examples = append(config.fewShotExamples(), historyFrom(ctx)...)
+		live = liveOutputFrom(ctx)
+		if config.structured() {
+			opts.Schema = structuredSchema()
+		}
+	}
+	checkContext(config, examplesText(opts.Examples)+opts.System+prompt)
+	if live != nil {
+		live.start()
+		defer live.finish()
+		// Raw JSON is no use to watch, so structured replies only get the spinner.
+		if opts.Schema == nil {
+			opts.OnToken = live.token
+		}
 	}
+	reply, err := generator.Generate(ctx, prompt, opts)
+	if errors.Is(err, provider.ErrModelNotFound) {
+		if live != nil {
+			live.stopSpinner()
+		}
+		if pulled, pullErr := offerPull(ctx, config); pullErr != nil {
+			return "", pullErr
+		} else if pulled {
+			if live != nil {
+				live.start()
+			}
+			reply, err = generator.Generate(ctx, prompt, opts)
+		}
+	}
+	return stripThinking(reply), err
+}
 
-	return &config, nil
+// messageQuotes are stripped from both ends of the model's reply.
+const messageQuotes = "\"`“”„«»「」『』"
+
+// cleanMessage removes unwanted characters like quotes and extra newlines.
+func cleanMessage(msg string) string {
+	// Trim leading/trailing whitespace
+	cleaned := strings.TrimSpace(msg)
+	// Some models wrap their output in quotes, so we remove them. This
+	// includes the typographic quotes models use when writing in other languages.
+	cleaned = strings.Trim(cleaned, messageQuotes)
+	// Ensure it's truly a single line by taking everything before the first newline.
+	if idx := strings.Index(cleaned, "\n"); idx != -1 {
+		cleaned = cleaned[:idx]
+	}
+	return cleaned
 }
 
-// getStagedDiff executes `git diff --staged` and returns its output.
-func getStagedDiff() (string, error) {
-	cmd := exec.Command("git", "diff", "--staged")
-	output, err := cmd.Output()
-	if err != nil {
-		// This can happen if git is not installed or not in a repo.
-		return "", fmt.Errorf("failed to execute 'git diff': %w", err)
+// cleanMultilineMessage tidies a subject/body/footers reply: it drops
+// wrapping quotes and markdown fences, then reassembles the parsed message
+// so the body is wrapped consistently.
+func cleanMultilineMessage(msg string) string {
+	cleaned := strings.Trim(strings.TrimSpace(msg), messageQuotes)
+	var lines []string
+	for _, line := range strings.Split(cleaned, "\n") {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			continue
+		}
+		lines = append(lines, line)
 	}
-	return string(output), nil
+	return parseCommitMessage(strings.Join(lines, "\n")).String()
 }
 
-// generateCommitMessage sends the diff to Ollama and gets a commit message.
-func generateCommitMessage(config *Config, diff string) (string, error) {
-	// The prompt is crucial. It instructs the AI to act as an expert and provide a single-line message.
-	prompt := fmt.Sprintf(
-		"Based on the following git diff, generate a concise, single-line git commit message in the conventional commit format (e.g., 'feat: add user login' or 'fix: resolve race condition'). Do not include any explanation, preamble, or markdown formatting. Just the commit message itself.\n\nGit Diff:\n```diff\n%s\n```",
-		diff,
-	)
+// stripOuterFence removes a markdown fence wrapped around the whole reply,
+// leaving fenced blocks inside it alone. Used for Markdown documents.
+func stripOuterFence(msg string) string {
+	cleaned := strings.TrimSpace(msg)
+	lines := strings.Split(cleaned, "\n")
+	if len(lines) >= 2 && strings.HasPrefix(lines[0], "```") && strings.TrimSpace(lines[len(lines)-1]) == "```" {
+		cleaned = strings.TrimSpace(strings.Join(lines[1:len(lines)-1], "\n"))
+	}
+	return cleaned
+}
 
-	// Construct the request payload
-	apiRequest := OllamaRequest{
-		Model:  config.Model,
-		Prompt: prompt,
-		Stream: false, // We want a single response, not a stream
+// clean runs the configured cleaners over msg, then the cleaner matching
+// the configured message shape. A structured reply is assembled instead.
+func (c *Config) clean(msg string) string {
+	if c.structured() {
+		if reply, ok := parseStructured(msg); ok {
+			return reply.message(c.Body)
+		}
+	}
+	msg = c.runCleaners(msg)
+	if c.Body {
+		return cleanMultilineMessage(msg)
 	}
-	apiRequest.Options.Temperature = config.Temperature
+	return cleanMessage(msg)
+}
 
-	// Marshal the request payload to JSON
-	jsonData, err := json.Marshal(apiRequest)
+// preparePrompt builds the prompt for diff, summarising it first if it is
+// over the token budget.
+func preparePrompt(ctx context.Context, config *Config, diff string) (string, error) {
+	summaries, err := summarizeDiff(ctx, config, diff)
 	if err != nil {
-		return "", fmt.Errorf("failed to marshal request to JSON: %w", err)
+		return "", err
 	}
-
-	// Create the HTTP request
-	ollamaAPIURL := fmt.Sprintf("%s/api/generate", strings.TrimSuffix(config.OllamaURL, "/"))
-	req, err := http.NewRequest("POST", ollamaAPIURL, bytes.NewBuffer(jsonData))
+	prompt, err := buildPrompt(config, diff, summaries)
 	if err != nil {
-		return "", fmt.Errorf("failed to create HTTP request: %w", err)
+		return "", err
 	}
-	req.Header.Set("Content-Type", "application/json")
+	slog.Info("prompt built", "tokens", estimateTokens(prompt), "bytes", len(prompt), "summarized", summaries != "", "diff_budget", config.diffBudget(), "context_window", config.contextWindow())
+	return prompt, nil
+}
 
-	// Execute the request
-	client := &http.Client{Timeout: 30 * time.Second}
-	resp, err := client.Do(req)
+// suggestMessage generates a commit message for diff and cleans it up.
+// With heuristics set to skip, obvious changes such as docs-only diffs
+// get a message without asking the model.
+func suggestMessage(ctx context.Context, config *Config, diff string) (string, error) {
+	config = config.forPackage(diff)
+	hint := changeHintFor(config, diff)
+	config, err := config.withRisk(ctx, diff)
+	if err != nil {
+		return "", err
+	}
+	if hint.Type != "" && config.Heuristics == heuristicsSkip {
+		slog.Info("model skipped", "type", hint.Type, "scope", hint.Scope)
+		return finishMessage(config, hint.message())
+	}
+	prompt, err := preparePrompt(ctx, config, diff)
 	if err != nil {
-		return "", fmt.Errorf("failed to send request to Ollama at %s: %w", config.OllamaURL, err)
+		return "", err
 	}
-	defer resp.Body.Close()
+	return suggestFromPrompt(ctx, config, diff, prompt, hint)
+}
 
-	// Read and check the response
-	body, err := io.ReadAll(resp.Body)
+// suggestFromPrompt sends prompt, built for diff, to the model and cleans
+// up the reply. The type and scope are replaced by hint's, if set, and the
+// message is marked as breaking if hint found breaking changes. Replies
+// that aren't valid conventional commits are sent back to the model with
+// the reason, up to config.ConventionalRetries times. The subject line is
+// then put in the imperative mood and, if over the limit, shortened.
+func suggestFromPrompt(ctx context.Context, config *Config, diff, prompt string, hint changeHint) (string, error) {
+	message, err := generateSuggestion(ctx, config, prompt)
 	if err != nil {
-		return "", fmt.Errorf("failed to read response body: %w", err)
+		return "", err
 	}
+	message = hint.enforce(config.clean(message))
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("Ollama API returned non-200 status: %s. Response: %s", resp.Status, string(body))
+	// Gitmoji output is fixed up locally rather than re-prompted, since
+	// mapping a type or keyword to its emoji is deterministic.
+	if config.Convention == conventionGitmoji {
+		if message, err = fixMood(ctx, config, prompt, message, hint); err != nil {
+			return "", err
+		}
+		if message, err = shortenSubject(ctx, config, prompt, message, hint); err != nil {
+			return "", err
+		}
+		if message, err = markBreaking(ctx, config, message, diff, hint.breaking); err != nil {
+			return "", err
+		}
+		return finishMessage(config, message)
 	}
 
-	// Unmarshal the response
-	var ollamaResp OllamaResponse
-	if err := json.Unmarshal(body, &ollamaResp); err != nil {
-		return "", fmt.Errorf("failed to unmarshal Ollama response: %w", err)
+	for attempt := 0; attempt < config.ConventionalRetries; attempt++ {
+		problem := validateConventional(subjectLine(message))
+		if problem == nil {
+			break
+		}
+		retry, err := generateSuggestion(ctx, config, correctivePrompt(prompt, message, problem))
+		if err != nil {
+			return "", err
+		}
+		message = hint.enforce(config.clean(retry))
 	}
 
-	return ollamaResp.Response, nil
+	if config.ConventionalRetries > 0 {
+		if problem := validateConventional(subjectLine(message)); problem != nil {
+			fmt.Fprintf(os.Stderr, "⚠️  The model's message is still not a valid conventional commit: %v\n", problem)
+		}
+	}
+	if message, err = fixMood(ctx, config, prompt, message, hint); err != nil {
+		return "", err
+	}
+	if message, err = shortenSubject(ctx, config, prompt, message, hint); err != nil {
+		return "", err
+	}
+	if message, err = markBreaking(ctx, config, message, diff, hint.breaking); err != nil {
+		return "", err
+	}
+	return decorateMessage(config, message)
 }
 
-// cleanMessage removes unwanted characters like quotes and extra newlines.
-func cleanMessage(msg string) string {
-	// Trim leading/trailing whitespace
-	cleaned := strings.TrimSpace(msg)
-	// Some models wrap their output in quotes, so we remove them.
-	cleaned = strings.Trim(cleaned, "\"`")
-	// Ensure it's truly a single line by taking everything before the first newline.
-	if idx := strings.Index(cleaned, "\n"); idx != -1 {
-		cleaned = cleaned[:idx]
+// rewordRetries is how many times the model is asked to fix a subject
+// line that is too long or not in the imperative mood before it is used
+// anyway.
+const rewordRetries = 2
+
+// shortenSubject asks the model again, up to rewordRetries times, while
+// the subject line of message is over config.subjectLimit(). A reply that
+// breaks the convention is not an improvement and is dropped.
+func shortenSubject(ctx context.Context, config *Config, prompt, message string, hint changeHint) (string, error) {
+	limit := config.subjectLimit()
+	if limit <= 0 {
+		return message, nil
 	}
-	return cleaned
+	for attempt := 0; attempt < rewordRetries; attempt++ {
+		width := displayWidth(subjectLine(message))
+		if width <= limit {
+			return message, nil
+		}
+		slog.Info("subject line too long, asking again", "width", width, "limit", limit)
+		retry, err := generateSuggestion(ctx, config, shorterSubjectPrompt(prompt, message, width, limit))
+		if err != nil {
+			return "", err
+		}
+		retry = hint.enforce(config.clean(retry))
+		if config.Convention != conventionGitmoji && config.ConventionalRetries > 0 && validateConventional(subjectLine(retry)) != nil {
+			continue
+		}
+		if displayWidth(subjectLine(retry)) < width {
+			message = retry
+		}
+	}
+	if width := displayWidth(subjectLine(message)); width > limit {
+		fmt.Fprintf(os.Stderr, "⚠️  The subject line is still %d characters long, more than %d\n", width, limit)
+	}
+	return message, nil
 }
 
-func main() {
-	// 1. Load configuration
-	config, err := loadConfig()
+// finishMessage converts a conventional commit message to gitmoji if that
+// is the convention, then decorates it.
+func finishMessage(config *Config, message string) (string, error) {
+	if config.Convention == conventionGitmoji {
+		message = toGitmoji(message)
+	}
+	return decorateMessage(config, message)
+}
+
+// decorateMessage adds the parts of the message that come from the
+// repository rather than the model, such as the ticket key.
+func decorateMessage(config *Config, message string) (string, error) {
+	if config.TicketPosition != "" {
+		ticket, err := ticketFromBranch(currentBranch(), config.TicketPattern)
+		if err != nil {
+			return "", err
+		}
+		message = addTicket(config, message, ticket)
+	}
+	message, err := addClosingFooters(config, message)
 	if err != nil {
-		log.Fatalf("Error loading configuration: %v", err)
+		return "", err
 	}
+	return addTrailers(config, message)
+}
+
+// subjectLine returns the first line of message.
+func subjectLine(message string) string {
+	subject, _, _ := strings.Cut(message, "\n")
+	return subject
+}
 
-	// 2. Get staged git diff
-	diff, err := getStagedDiff()
+// suggestMessages generates n candidate messages concurrently and returns the
+// distinct, non-empty ones in the order they were requested. It only fails
+// when every request failed.
+func suggestMessages(ctx context.Context, config *Config, diff string, n int) ([]string, error) {
+	config = config.forPackage(diff)
+	hint := changeHintFor(config, diff)
+	if hint.Type != "" && config.Heuristics == heuristicsSkip {
+		message, err := suggestMessage(ctx, config, diff)
+		if err != nil {
+			return nil, err
+		}
+		return []string{message}, nil
+	}
+	// One risk rating for all of them, since they describe the same diff.
+	config, err := config.withRisk(ctx, diff)
 	if err != nil {
-		log.Fatalf("Error getting git diff: %v", err)
+		return nil, err
+	}
+	// Build the prompt once so large diffs are only summarised once.
+	prompt, err := preparePrompt(ctx, config, diff)
+	if err != nil {
+		return nil, err
 	}
 
-	if strings.TrimSpace(diff) == "" {
-		fmt.Println("No staged changes found. Nothing to commit. 🤔")
-		os.Exit(0)
+	results := make([]string, n)
+	errs := make([]error, n)
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i], errs[i] = suggestFromPrompt(ctx, config, diff, prompt, hint)
+		}()
 	}
+	wg.Wait()
 
-	// 3. Generate the commit message
-	fmt.Println("🤖 Generating commit message from diff...")
-	message, err := generateCommitMessage(config, diff)
-	if err != nil {
-		log.Fatalf("Error generating commit message: %v", err)
+	seen := make(map[string]bool)
+	var candidates []string
+	for _, message := range results {
+		if message == "" || seen[message] {
+			continue
+		}
+		seen[message] = true
+		candidates = append(candidates, message)
+	}
+	if len(candidates) == 0 {
+		if err := errors.Join(errs...); err != nil {
+			return nil, err
+		}
+		return nil, fmt.Errorf("the model returned only empty messages")
 	}
+	return candidates, nil
+}
 
-	// 4. Clean and print the final message
-	finalMessage := cleanMessage(message)
-	fmt.Println("\n✅ Suggested Commit Message:")
-	fmt.Println(finalMessage)
+func main() {
+	setupLogging()
+	// The daemon shuts down gracefully on signals by itself.
+	if len(os.Args) < 2 || os.Args[1] != "daemon" {
+		handleInterrupts()
+	}
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "--version", "-version", "version":
+			printVersion()
+			return
+		case "history":
+			if err := runHistory(os.Args[2:]); err != nil {
+				log.Fatalf("Error reading history: %v", err)
+			}
+			return
+		case "hook":
+			if err := runHook(os.Args[2:]); err != nil {
+				log.Fatalf("Error: %v", err)
+			}
+			return
+		case "install-hook":
+			if err := runInstallHook(os.Args[2:]); err != nil {
+				log.Fatalf("Error installing hook: %v", err)
+			}
+			return
+		case "reword":
+			if err := runReword(os.Args[2:]); err != nil {
+				log.Fatalf("Error rewording commits: %v", err)
+			}
+			return
+		case "annotate":
+			if err := runAnnotate(os.Args[2:]); err != nil {
+				log.Fatalf("Error annotating commit: %v", err)
+			}
+			return
+		case "explain":
+			if err := runExplain(os.Args[2:]); err != nil {
+				log.Fatalf("Error explaining changes: %v", err)
+			}
+			return
+		case "fixup":
+			if err := runFixup(os.Args[2:]); err != nil {
+				log.Fatalf("Error finding the commit to fix up: %v", err)
+			}
+			return
+		case "revert":
+			if err := runRevert(os.Args[2:]); err != nil {
+				log.Fatalf("Error generating revert message: %v", err)
+			}
+			return
+		case "stash":
+			if err := runStash(os.Args[2:]); err != nil {
+				log.Fatalf("Error stashing changes: %v", err)
+			}
+			return
+		case "squash":
+			if err := runSquash(os.Args[2:]); err != nil {
+				log.Fatalf("Error generating squash message: %v", err)
+			}
+			return
+		case "pr":
+			if err := runPR(os.Args[2:]); err != nil {
+				log.Fatalf("Error generating pull request: %v", err)
+			}
+			return
+		case "changelog":
+			if err := runChangelog(os.Args[2:]); err != nil {
+				log.Fatalf("Error generating changelog: %v", err)
+			}
+			return
+		case "release-notes":
+			if err := runReleaseNotes(os.Args[2:]); err != nil {
+				log.Fatalf("Error generating release notes: %v", err)
+			}
+			return
+		case "branch":
+			if err := runBranch(os.Args[2:]); err != nil {
+				log.Fatalf("Error suggesting branch name: %v", err)
+			}
+			return
+		case "tag-message":
+			if err := runTagMessage(os.Args[2:]); err != nil {
+				log.Fatalf("Error generating tag message: %v", err)
+			}
+			return
+		case "bump":
+			if err := runBump(os.Args[2:]); err != nil {
+				log.Fatalf("Error recommending version: %v", err)
+			}
+			return
+		case "lint":
+			if err := runLint(os.Args[2:]); err != nil {
+				log.Fatalf("Error linting messages: %v", err)
+			}
+			return
+		case "risk":
+			if err := runRisk(os.Args[2:]); err != nil {
+				log.Fatalf("Error assessing risk: %v", err)
+			}
+			return
+		case "review":
+			if err := runCodeReview(os.Args[2:]); err != nil {
+				log.Fatalf("Error reviewing changes: %v", err)
+			}
+			return
+		case "translate":
+			if err := runTranslate(os.Args[2:]); err != nil {
+				log.Fatalf("Error translating messages: %v", err)
+			}
+			return
+		case "review-message":
+			if err := runReviewMessage(os.Args[2:]); err != nil {
+				log.Fatalf("Error reviewing commit message: %v", err)
+			}
+			return
+		case "split":
+			if err := runSplit(os.Args[2:]); err != nil {
+				log.Fatalf("Error splitting changes: %v", err)
+			}
+			return
+		case "serve":
+			if err := runServe(os.Args[2:]); err != nil {
+				log.Fatalf("Error running server: %v", err)
+			}
+			return
+		case "mcp":
+			if err := runMCP(os.Args[2:]); err != nil {
+				log.Fatalf("Error running MCP server: %v", err)
+			}
+			return
+		case "daemon":
+			if err := runDaemon(os.Args[2:]); err != nil {
+				log.Fatalf("Error running daemon: %v", err)
+			}
+			return
+		case "config":
+			if err := runConfig(os.Args[2:]); err != nil {
+				log.Fatalf("Error in config command: %v", err)
+			}
+			return
+		case "auth":
+			if err := runAuth(os.Args[2:]); err != nil {
+				log.Fatalf("Error managing API keys: %v", err)
+			}
+			return
+		case "doctor":
+			if err := runDoctor(os.Args[2:]); err != nil {
+				log.Fatalf("Error running checks: %v", err)
+			}
+			return
+		case "models":
+			if err := runModels(os.Args[2:]); err != nil {
+				log.Fatalf("Error listing models: %v", err)
+			}
+			return
+		case "completion":
+			if err := runCompletion(os.Args[2:]); err != nil {
+				log.Fatalf("Error writing completion script: %v", err)
+			}
+			return
+		case "__complete":
+			if err := runComplete(os.Args[2:]); err != nil {
+				log.Fatalf("Error completing: %v", err)
+			}
+			return
+		case "uninstall-hook":
+			if err := runUninstallHook(os.Args[2:]); err != nil {
+				log.Fatalf("Error removing hook: %v", err)
+			}
+			return
+		}
+	}
+	runGenerate(os.Args[1:])
 }
@@ -0,0 +1,324 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+)
+
+// mcpProtocolVersions are the Model Context Protocol revisions the server
+// speaks, newest first. Tools with text results work the same in all of them.
+var mcpProtocolVersions = []string{"2025-06-18", "2025-03-26", "2024-11-05"}
+
+// JSON-RPC error codes used by the MCP server.
+const (
+	rpcParseError     = -32700
+	rpcMethodNotFound = -32601
+	rpcInvalidParams  = -32602
+)
+
+// rpcRequest is a JSON-RPC 2.0 request, or a notification when ID is empty.
+type rpcRequest struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id,omitempty"`
+	Method  string          `json:"method"`
+	Params  json.RawMessage `json:"params,omitempty"`
+}
+
+// rpcResponse is a JSON-RPC 2.0 response.
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result,omitempty"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+// rpcError is the error member of a failed rpcResponse.
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// mcpTool is a tool the MCP server offers.
+type mcpTool struct {
+	Name        string          `json:"name"`
+	Description string          `json:"description"`
+	InputSchema json.RawMessage `json:"inputSchema"`
+	// call runs the tool with its JSON arguments and returns its text result.
+	call func(ctx context.Context, s *mcpServer, args json.RawMessage) (string, error)
+}
+
+// mcpText is the content of a tool result.
+type mcpText struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+// mcpToolResult is the result of tools/call. Failures are reported here,
+// with IsError, so the calling model can see what went wrong.
+type mcpToolResult struct {
+	Content []mcpText `json:"content"`
+	IsError bool      `json:"isError,omitempty"`
+}
+
+// repositoryProperty is in every tool's schema: the server is usually
+// started outside the repository the agent is working on.
+const repositoryProperty = `"repository": {"type": "string", "description": "Path to the git repository. Defaults to the server's working directory."}`
+
+// mcpTools are the tools offered by `git-commit-message mcp`.
+var mcpTools = []mcpTool{
+	{
+		Name:        "generate_commit_message",
+		Description: "Write a commit message for the uncommitted changes in a git repository, following the repository's configured convention. Nothing is committed.",
+		InputSchema: json.RawMessage(`{"type": "object", "properties": {` + repositoryProperty + `,
+			"changes": {"type": "string", "enum": ["staged", "unstaged", "all"], "description": "Which changes to describe. Defaults to staged."},
+			"body": {"type": "boolean", "description": "Write a body and footers as well as the subject line."}}}`),
+		call: mcpGenerateCommitMessage,
+	},
+	{
+		Name:        "generate_pr_description",
+		Description: "Write a pull request title and Markdown description for the commits on the current branch of a git repository.",
+		InputSchema: json.RawMessage(`{"type": "object", "properties": {` + repositoryProperty + `,
+			"base": {"type": "string", "description": "The branch the pull request targets. Defaults to the remote's default branch, main or master."}}}`),
+		call: mcpGeneratePRDescription,
+	},
+	{
+		Name:        "lint_message",
+		Description: "Check a commit message against the repository's commit message rules and list any problems.",
+		InputSchema: json.RawMessage(`{"type": "object", "required": ["message"], "properties": {` + repositoryProperty + `,
+			"message": {"type": "string", "description": "The commit message to check."}}}`),
+		call: mcpLintMessage,
+	},
+}
+
+// mcpServer serves MCP requests read from in, one JSON-RPC message per
+// line, and writes the responses to out. Requests are handled one at a
+// time, since tools change into the repository they work on.
+type mcpServer struct {
+	overrides *configFlags
+	out       *json.Encoder
+}
+
+// runMCP implements `git-commit-message mcp`: a Model Context Protocol
+// server on stdio, so desktop and IDE agents can use the generator.
+func runMCP(args []string) error {
+	flags := flag.NewFlagSet("mcp", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message mcp [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	// stdout carries the protocol; progress and warnings go to stderr.
+	statusOut = os.Stderr
+	server := &mcpServer{overrides: overrides, out: json.NewEncoder(os.Stdout)}
+	return server.serve(appCtx, os.Stdin)
+}
+
+// serve handles requests from in until it is closed.
+func (s *mcpServer) serve(ctx context.Context, in io.Reader) error {
+	scanner := bufio.NewScanner(in)
+	// Tool arguments can hold whole commit messages.
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		var req rpcRequest
+		if err := json.Unmarshal([]byte(line), &req); err != nil {
+			s.reply(rpcResponse{ID: json.RawMessage("null"), Error: &rpcError{rpcParseError, "invalid JSON: " + err.Error()}})
+			continue
+		}
+		result, rpcErr := s.handle(ctx, req)
+		// Notifications get no response.
+		if len(req.ID) == 0 {
+			continue
+		}
+		s.reply(rpcResponse{ID: req.ID, Result: result, Error: rpcErr})
+	}
+	return scanner.Err()
+}
+
+// reply writes resp to the client.
+func (s *mcpServer) reply(resp rpcResponse) {
+	resp.JSONRPC = "2.0"
+	if err := s.out.Encode(resp); err != nil {
+		slog.Error("could not write MCP response", "err", err)
+	}
+}
+
+// handle dispatches a request to its method.
+func (s *mcpServer) handle(ctx context.Context, req rpcRequest) (any, *rpcError) {
+	slog.Info("mcp request", "method", req.Method)
+	switch req.Method {
+	case "initialize":
+		var params struct {
+			ProtocolVersion string `json:"protocolVersion"`
+		}
+		json.Unmarshal(req.Params, &params)
+		version := mcpProtocolVersions[0]
+		if slices.Contains(mcpProtocolVersions, params.ProtocolVersion) {
+			version = params.ProtocolVersion
+		}
+		return map[string]any{
+			"protocolVersion": version,
+			"capabilities":    map[string]any{"tools": map[string]any{}},
+			"serverInfo":      map[string]string{"name": "git-commit-message", "version": currentBuild().Version},
+		}, nil
+	case "ping":
+		// A struct, unlike an empty map, survives Result's omitempty.
+		return struct{}{}, nil
+	case "tools/list":
+		return map[string]any{"tools": mcpTools}, nil
+	case "tools/call":
+		var params struct {
+			Name      string          `json:"name"`
+			Arguments json.RawMessage `json:"arguments"`
+		}
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return nil, &rpcError{rpcInvalidParams, err.Error()}
+		}
+		i := slices.IndexFunc(mcpTools, func(t mcpTool) bool { return t.Name == params.Name })
+		if i < 0 {
+			return nil, &rpcError{rpcInvalidParams, fmt.Sprintf("unknown tool %q", params.Name)}
+		}
+		text, err := s.callTool(ctx, mcpTools[i], params.Arguments)
+		if err != nil {
+			return mcpToolResult{Content: []mcpText{{"text", err.Error()}}, IsError: true}, nil
+		}
+		return mcpToolResult{Content: []mcpText{{"text", text}}}, nil
+	default:
+		if strings.HasPrefix(req.Method, "notifications/") {
+			return nil, nil
+		}
+		return nil, &rpcError{rpcMethodNotFound, fmt.Sprintf("method %q not found", req.Method)}
+	}
+}
+
+// callTool runs tool inside the repository named in its arguments.
+func (s *mcpServer) callTool(ctx context.Context, tool mcpTool, args json.RawMessage) (string, error) {
+	if len(args) == 0 {
+		args = json.RawMessage("{}")
+	}
+	var target struct {
+		Repository string `json:"repository"`
+	}
+	if err := json.Unmarshal(args, &target); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if target.Repository != "" {
+		previous, err := os.Getwd()
+		if err != nil {
+			return "", err
+		}
+		if err := os.Chdir(target.Repository); err != nil {
+			return "", fmt.Errorf("could not open repository: %w", err)
+		}
+		defer os.Chdir(previous)
+	}
+	return tool.call(ctx, s, args)
+}
+
+// requireRepository fails unless the working directory is in a git repository.
+func requireRepository() error {
+	if _, err := runGit("rev-parse", "--git-dir"); err != nil {
+		return fmt.Errorf("not inside a git repository: %w", err)
+	}
+	return nil
+}
+
+// mcpGenerateCommitMessage implements the generate_commit_message tool.
+func mcpGenerateCommitMessage(ctx context.Context, s *mcpServer, args json.RawMessage) (string, error) {
+	var params struct {
+		Changes string `json:"changes"`
+		Body    bool   `json:"body"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	mode := diffStaged
+	switch params.Changes {
+	case "", "staged":
+	case "unstaged":
+		mode = diffUnstaged
+	case "all":
+		mode = diffAll
+	default:
+		return "", fmt.Errorf("unknown changes %q (expected staged, unstaged or all)", params.Changes)
+	}
+	if err := requireRepository(); err != nil {
+		return "", err
+	}
+	config, err := resolveConfig(s.overrides)
+	if err != nil {
+		return "", err
+	}
+	if params.Body {
+		config.Body = true
+	}
+	diff, err := collectDiff(config, mode)
+	if err != nil {
+		return "", err
+	}
+	if strings.TrimSpace(diff) == "" {
+		return "", fmt.Errorf("no %s changes found", mode)
+	}
+	return cachedSuggestion(ctx, config, diff)
+}
+
+// mcpGeneratePRDescription implements the generate_pr_description tool.
+func mcpGeneratePRDescription(ctx context.Context, s *mcpServer, args json.RawMessage) (string, error) {
+	var params struct {
+		Base string `json:"base"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if err := requireRepository(); err != nil {
+		return "", err
+	}
+	config, err := resolveConfig(s.overrides)
+	if err != nil {
+		return "", err
+	}
+	title, body, err := describePR(ctx, config, params.Base)
+	if err != nil {
+		return "", err
+	}
+	return title + "\n\n" + body, nil
+}
+
+// mcpLintMessage implements the lint_message tool.
+func mcpLintMessage(ctx context.Context, s *mcpServer, args json.RawMessage) (string, error) {
+	var params struct {
+		Message string `json:"message"`
+	}
+	if err := json.Unmarshal(args, &params); err != nil {
+		return "", fmt.Errorf("invalid arguments: %w", err)
+	}
+	if strings.TrimSpace(params.Message) == "" {
+		return "", errors.New("message is required")
+	}
+	config, err := resolveSettings(s.overrides)
+	if err != nil {
+		return "", err
+	}
+	problems := lintMessage(config, stripComments(params.Message))
+	if len(problems) == 0 {
+		return "The message follows the rules.", nil
+	}
+	return "The message breaks these rules:\n- " + strings.Join(problems, "\n- "), nil
+}
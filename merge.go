@@ -0,0 +1,144 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// maxMergeCommits caps how many incoming commit subjects the merge prompt lists.
+const maxMergeCommits = 30
+
+// mergePrompt asks for the body of a merge commit; the subject stays the
+// one git wrote.
+const mergePrompt = "A branch is being merged with the git commit whose subject is '{{.Subject}}'. Write the body of its commit message in plain prose: a short summary of what the incoming changes do, based on the commits and diff below, grouped by theme rather than listed commit by commit.{{if .Resolutions}} Then, in a separate paragraph, explain how each merge conflict was resolved, based on the resolution diff: which side was kept or how the two were combined.{{end}} Do not repeat the subject line. {{.LanguageInstructions}}Do not include any explanation, preamble, or markdown formatting. Just the body itself.\n\n" + systemSeparator + "Incoming commits:\n{{range .Commits}}- {{.}}\n{{end}}\n{{if .Resolutions}}Conflict resolutions (from the conflicted merge to the committed result):\n```diff\n{{.Resolutions}}\n```\n\n{{end}}" + changesSection
+
+// mergeData is what mergePrompt is executed against.
+type mergeData struct {
+	*promptData
+	// Subject is git's own subject for the merge, e.g. "Merge branch 'x'".
+	Subject string
+	// Commits are the subjects of the incoming commits, oldest first.
+	Commits []string
+	// Resolutions is the diff from the conflicted files, markers and all,
+	// to how they are staged, or "" when nothing conflicted.
+	Resolutions string
+}
+
+// mergeHeads returns the commits being merged while a merge is in
+// progress, read from MERGE_HEAD, and nil otherwise.
+func mergeHeads() []string {
+	path, err := runGit("rev-parse", "--git-path", "MERGE_HEAD")
+	if err != nil {
+		return nil
+	}
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(data))
+}
+
+// mergeDraft returns git's prepared message for the merge in progress: its
+// subject and the files listed as conflicted.
+func mergeDraft() (subject string, conflicts []string) {
+	path, err := runGit("rev-parse", "--git-path", "MERGE_MSG")
+	if err != nil {
+		return "", nil
+	}
+	data, err := os.ReadFile(strings.TrimSpace(path))
+	if err != nil {
+		return "", nil
+	}
+	lines := strings.Split(string(data), "\n")
+	subject = strings.TrimSpace(lines[0])
+	inConflicts := false
+	for _, line := range lines[1:] {
+		// Older versions of git don't comment the list out.
+		trimmed := strings.TrimSpace(strings.TrimPrefix(line, "#"))
+		switch {
+		case trimmed == "Conflicts:":
+			inConflicts = true
+		case inConflicts && trimmed != "" && strings.HasPrefix(strings.TrimPrefix(line, "#"), "\t"):
+			conflicts = append(conflicts, trimmed)
+		case inConflicts && trimmed != "":
+			inConflicts = false
+		}
+	}
+	return subject, conflicts
+}
+
+// conflictResolutions returns how the staged versions of the conflicted
+// files differ from git's automatic merge of them with conflict markers,
+// which needs `git merge-tree --write-tree` (git 2.38 or later).
+func conflictResolutions(heads, conflicts []string) string {
+	if len(conflicts) == 0 || len(heads) != 1 {
+		return ""
+	}
+	// merge-tree exits with 1 when there are conflicts, which there will
+	// be, but still prints the tree first.
+	output, err := gitCommand("merge-tree", "--write-tree", "HEAD", heads[0]).Output()
+	var exitErr *exec.ExitError
+	if err != nil && !(errors.As(err, &exitErr) && exitErr.ExitCode() == 1) || len(output) == 0 {
+		fmt.Fprintf(os.Stderr, "⚠️  Could not recreate the merge conflicts, so their resolution is not described: %v\n", err)
+		return ""
+	}
+	tree, _, _ := strings.Cut(string(output), "\n")
+	diff, err := runGit(append([]string{"diff", "--cached", tree, "--"}, conflicts...)...)
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(diff)
+}
+
+// mergeMessage writes the message for the merge of heads in progress:
+// git's own subject, and a body summarising the incoming changes and how
+// conflicts were resolved.
+func mergeMessage(ctx context.Context, config *Config, heads []string) (string, error) {
+	subject, conflicts := mergeDraft()
+	if subject == "" {
+		subject = "Merge " + shortSHA(heads[0])
+	}
+	revs := []string{"--reverse", "--no-merges", "--format=%s"}
+	for _, head := range heads {
+		revs = append(revs, "^HEAD", head)
+	}
+	output, err := runGit(append([]string{"log"}, revs...)...)
+	if err != nil {
+		return "", err
+	}
+	commits := strings.Split(strings.TrimSpace(output), "\n")
+	if len(commits) > maxMergeCommits {
+		commits = append([]string{fmt.Sprintf("...%d earlier commits", len(commits)-maxMergeCommits)}, commits[len(commits)-maxMergeCommits:]...)
+	}
+
+	// What the incoming side changed since the branches forked.
+	diff, err := rangeDiff("HEAD..." + heads[0])
+	if err != nil {
+		return "", err
+	}
+	diff = prepareDiff(config, diff)
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	prompt, err := renderPrompt(mergePrompt, &mergeData{
+		promptData:  newPromptData(config, diff, summaries),
+		Subject:     subject,
+		Commits:     commits,
+		Resolutions: prepareDiff(config, conflictResolutions(heads, conflicts)),
+	})
+	if err != nil {
+		return "", err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	// The subject is git's, so ticket keys and gitmoji don't apply, but
+	// trailers such as the sign-off still do.
+	return addTrailers(config, cleanMultilineMessage(subject+"\n\n"+config.runCleaners(reply)))
+}
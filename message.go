@@ -0,0 +1,212 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+)
+
+// bodyWidth is the conventional column limit for commit message bodies.
+const bodyWidth = 72
+
+// footerLine matches git trailer style footers such as `Refs: #12`,
+// `BREAKING CHANGE: ...`, `Closes #34` or `Closes owner/repo#34`.
+var footerLine = regexp.MustCompile(`^(BREAKING[ -]CHANGE|[A-Za-z][A-Za-z-]*)(: | #| [\w.-]+(?:/[\w.-]+)+#)(.+)$`)
+
+// footer is a single `Token: value` line at the end of a commit message.
+type footer struct {
+	Token     string
+	Separator string
+	Value     string
+}
+
+// String formats the footer as it appears in the message.
+func (f footer) String() string {
+	return f.Token + f.Separator + f.Value
+}
+
+// addFooter appends a `token: value` footer to message unless an identical
+// one is already there.
+func addFooter(message, token, value string) string {
+	return appendFooter(message, footer{Token: token, Separator: ": ", Value: value})
+}
+
+// appendFooter appends f to message unless an identical footer is already
+// there. The rest of the message is left as it is, so hand-wrapped text,
+// lists and code in the body keep their line breaks.
+func appendFooter(message string, f footer) string {
+	msg := parseCommitMessage(message)
+	for _, existing := range msg.Footers {
+		if strings.EqualFold(existing.Token, f.Token) && existing.Separator == f.Separator && existing.Value == f.Value {
+			return message
+		}
+	}
+	separator := "\n\n"
+	if len(msg.Footers) > 0 {
+		// Join the footer block the message already ends with.
+		separator = "\n"
+	}
+	return strings.TrimRight(message, " \t\r\n") + separator + f.String()
+}
+
+// commitMessage is a full commit message split into its parts.
+type commitMessage struct {
+	Subject string
+	Body    string
+	Footers []footer
+}
+
+// parseCommitMessage splits text into subject, body and footers. The last
+// paragraph is treated as footers only if every line in it looks like one.
+func parseCommitMessage(text string) commitMessage {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	subject, rest, _ := strings.Cut(text, "\n")
+	msg := commitMessage{Subject: strings.TrimSpace(subject)}
+
+	paragraphs := splitParagraphs(rest)
+	if n := len(paragraphs); n > 0 {
+		if footers, ok := parseFooters(paragraphs[n-1]); ok {
+			msg.Footers = footers
+			paragraphs = paragraphs[:n-1]
+		}
+	}
+	msg.Body = strings.Join(paragraphs, "\n\n")
+	return msg
+}
+
+// parseFooters parses paragraph as a block of footers.
+func parseFooters(paragraph string) ([]footer, bool) {
+	var footers []footer
+	for _, line := range strings.Split(paragraph, "\n") {
+		m := footerLine.FindStringSubmatch(strings.TrimSpace(line))
+		if m == nil {
+			return nil, false
+		}
+		token := m[1]
+		// The spec allows BREAKING-CHANGE as a synonym; normalise it.
+		if token == "BREAKING-CHANGE" {
+			token = "BREAKING CHANGE"
+		}
+		footers = append(footers, footer{Token: token, Separator: m[2], Value: m[3]})
+	}
+	return footers, len(footers) > 0
+}
+
+// splitParagraphs splits text on blank lines, dropping empty paragraphs.
+func splitParagraphs(text string) []string {
+	var paragraphs []string
+	var current []string
+	flush := func() {
+		if len(current) > 0 {
+			paragraphs = append(paragraphs, strings.Join(current, "\n"))
+			current = nil
+		}
+	}
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.TrimSpace(line) == "" {
+			flush()
+			continue
+		}
+		current = append(current, line)
+	}
+	flush()
+	return paragraphs
+}
+
+// String reassembles the message with the body wrapped at bodyWidth columns.
+func (m commitMessage) String() string {
+	parts := []string{m.Subject}
+	if m.Body != "" {
+		var wrapped []string
+		for _, paragraph := range splitParagraphs(m.Body) {
+			wrapped = append(wrapped, wrapParagraph(paragraph, bodyWidth))
+		}
+		parts = append(parts, strings.Join(wrapped, "\n\n"))
+	}
+	if len(m.Footers) > 0 {
+		lines := make([]string, len(m.Footers))
+		for i, f := range m.Footers {
+			lines[i] = f.String()
+		}
+		parts = append(parts, strings.Join(lines, "\n"))
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// wrapParagraph re-flows a paragraph to width columns. Bullet list items
+// ("- ", "* ") are kept on their own lines and wrapped with a hanging indent.
+func wrapParagraph(paragraph string, width int) string {
+	var items []string
+	for _, line := range strings.Split(paragraph, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if isBullet(trimmed) || len(items) == 0 {
+			items = append(items, trimmed)
+			continue
+		}
+		items[len(items)-1] += " " + trimmed
+	}
+
+	var out []string
+	for _, item := range items {
+		indent := ""
+		if isBullet(item) {
+			indent = "  "
+		}
+		out = append(out, wrapLine(item, width, indent))
+	}
+	return strings.Join(out, "\n")
+}
+
+// isBullet reports whether line starts a markdown-style list item.
+func isBullet(line string) bool {
+	return strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ")
+}
+
+// wrapLine greedily wraps a single line of words, prefixing continuation
+// lines with indent. Widths are measured in display columns, so CJK text
+// counts double. Words longer than width are only split when they are made
+// of wide characters (CJK text has no spaces); long URLs stay intact.
+func wrapLine(line string, width int, indent string) string {
+	words := strings.Fields(line)
+	if len(words) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	column := 0
+	for i, word := range words {
+		length := displayWidth(word)
+		switch {
+		case i == 0:
+		case column+1+length > width:
+			b.WriteString("\n" + indent)
+			column = len(indent)
+		default:
+			b.WriteString(" ")
+			column++
+		}
+		if column+length <= width || !hasWideRunes(word) {
+			b.WriteString(word)
+			column += length
+			continue
+		}
+		for _, r := range word {
+			if w := runeWidth(r); column+w > width && column > len(indent) {
+				b.WriteString("\n" + indent)
+				column = len(indent)
+			}
+			b.WriteRune(r)
+			column += runeWidth(r)
+		}
+	}
+	return b.String()
+}
+
+// hasWideRunes reports whether word contains double-width characters.
+func hasWideRunes(word string) bool {
+	for _, r := range word {
+		if runeWidth(r) == 2 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// ollamaModels lists the models on the configured Ollama instance.
+func ollamaModels(config *Config) ([]provider.OllamaModel, error) {
+	pc := config.providerConfig()
+	if name := strings.ToLower(pc.Provider); name != "" && name != "ollama" {
+		return nil, fmt.Errorf("listing models is only supported for Ollama, not %s", pc.Provider)
+	}
+	ctx, cancel := context.WithTimeout(appCtx, 10*time.Second)
+	defer cancel()
+	return provider.OllamaModels(ctx, pc)
+}
+
+// userConfigPath returns the config file `models use` writes to: --config,
+// $GCM_CONFIG or the default location.
+func userConfigPath(overrides *configFlags) (string, error) {
+	if overrides.path != "" {
+		return overrides.path, nil
+	}
+	if path := os.Getenv("GCM_CONFIG"); path != "" {
+		return path, nil
+	}
+	return defaultConfigPath()
+}
+
+// runModels implements `git-commit-message models` and `models use <name>`.
+func runModels(args []string) error {
+	flags := flag.NewFlagSet("models", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message models [flags] [use <name>]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+
+	use := flags.NArg() == 2 && flags.Arg(0) == "use"
+	if flags.NArg() != 0 && !use {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveSettings(overrides)
+	if use && errors.Is(err, fs.ErrNotExist) {
+		// `models use --config new.yaml` creates the file.
+		config, err = resolveSettings(nil)
+	}
+	if err != nil {
+		return err
+	}
+	models, err := ollamaModels(config)
+
+	if !use {
+		if err != nil {
+			return err
+		}
+		if len(models) == 0 {
+			fmt.Println("No models installed; pull one with `ollama pull <model>`.")
+			return nil
+		}
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "  NAME\tSIZE\tPARAMETERS\tQUANTIZATION\tMODIFIED")
+		for _, m := range models {
+			current := " "
+			if m.Name == config.Model {
+				current = "*"
+			}
+			fmt.Fprintf(w, "%s %s\t%s\t%s\t%s\t%s\n", current, m.Name, formatBytes(m.Size),
+				m.Details.ParameterSize, m.Details.QuantizationLevel, m.ModifiedAt.Format("2006-01-02"))
+		}
+		return w.Flush()
+	}
+
+	name := flags.Arg(1)
+	// Check the name when the server can be asked; a server that is down
+	// shouldn't stop you from writing the config.
+	if err == nil && !slices.Contains(modelNames(models), name) {
+		return fmt.Errorf("%s is not installed (installed: %s); pull it with `ollama pull %s`", name, strings.Join(modelNames(models), ", "), name)
+	}
+	path, err := userConfigPath(overrides)
+	if err != nil {
+		return err
+	}
+	if err := setConfigValue(path, "model", name); err != nil {
+		return err
+	}
+	fmt.Printf("✅ Set model to %s in %s\n", name, path)
+	return nil
+}
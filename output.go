@@ -0,0 +1,102 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+)
+
+// statusOut receives progress banners and other decoration. It is stdout
+// for humans and is redirected when stdout must carry only the result.
+var statusOut io.Writer = os.Stdout
+
+// jsonResult is the document printed by --output json.
+type jsonResult struct {
+	Subject    string   `json:"subject"`
+	Body       string   `json:"body"`
+	Type       string   `json:"type,omitempty"`
+	Scope      string   `json:"scope,omitempty"`
+	Breaking   bool     `json:"breaking,omitempty"`
+	Message    string   `json:"message"`
+	Candidates []string `json:"candidates,omitempty"`
+	Provider   string   `json:"provider"`
+	Model      string   `json:"model"`
+	DurationMS int64    `json:"duration_ms"`
+	// Build identifies the binary that produced the message.
+	Build buildInfo `json:"build"`
+}
+
+// newJSONResult splits message into the fields scripts care about.
+func newJSONResult(config *Config, message string, candidates []string, took time.Duration) jsonResult {
+	subject, body, _ := strings.Cut(message, "\n")
+	result := jsonResult{
+		Subject:    subject,
+		Body:       strings.TrimSpace(body),
+		Message:    message,
+		Provider:   config.Provider,
+		Model:      config.Model,
+		DurationMS: took.Milliseconds(),
+		Build:      currentBuild(),
+	}
+	if result.Provider == "" {
+		result.Provider = "ollama"
+	}
+	if len(candidates) > 1 {
+		result.Candidates = candidates
+	}
+	if commit, ok := parseConventional(subject); ok {
+		result.Type = commit.Type
+		result.Scope = commit.Scope
+		result.Breaking = commit.Breaking || strings.Contains(body, "BREAKING CHANGE")
+	}
+	return result
+}
+
+// printJSONResult writes the --output json document to stdout.
+func printJSONResult(config *Config, message string, candidates []string, took time.Duration) error {
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	encoder.SetEscapeHTML(false)
+	return encoder.Encode(newJSONResult(config, message, candidates, took))
+}
+
+// printDryRun writes the prompt that would be sent for diff to stdout, with
+// a short note about its size on statusOut. Large diffs are not summarised
+// since that would call the model.
+func printDryRun(config *Config, diff string) error {
+	summaries := placeholderSummaries(config, diff)
+	prompt, err := buildPrompt(config, diff, summaries)
+	if err != nil {
+		return err
+	}
+	examples := config.fewShotExamples()
+	size := fmt.Sprintf("~%d tokens", estimateTokens(examplesText(examples)+prompt))
+	if window := config.contextWindow(); window > 0 {
+		size += fmt.Sprintf(" of a ~%d token context window, %d kept for the reply", window, config.replyTokens())
+	}
+	fmt.Fprintf(statusOut, "🔍 Prompt for %s (%s):\n\n", providerLabel(config.providerConfig()), size)
+	if summaries != "" {
+		fmt.Fprintf(statusOut, "The diff is over the %d token budget, so each file would first be summarised by the model.\n\n", config.diffBudget())
+	}
+	checkContext(config, examplesText(examples)+prompt)
+	system, user := splitSystem(prompt)
+	if system == "" && len(examples) == 0 {
+		fmt.Println(user)
+		return nil
+	}
+	if system != "" {
+		label := "System"
+		if !config.SystemPrompt {
+			label = "Instructions (sent at the start of the first user message)"
+		}
+		fmt.Printf("%s:\n%s\n\n", label, system)
+	}
+	for _, example := range examples {
+		fmt.Printf("User:\n%s\n\nAssistant:\n%s\n\n", example.Input, example.Output)
+	}
+	fmt.Printf("User:\n%s\n", user)
+	return nil
+}
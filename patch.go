@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// formatPatchSignature matches the "-- " line and git version that end a
+// patch written by `git format-patch`.
+var formatPatchSignature = regexp.MustCompile(`\n-- \n[0-9][^\n]*\n*$`)
+
+// readPatch reads the diff for --diff from a file, or from stdin when
+// source is "-", and normalizes it with normalizePatch.
+func readPatch(source string) (string, error) {
+	var data []byte
+	var err error
+	if source == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(source)
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not read patch %s: %w", source, err)
+	}
+	return normalizePatch(string(data)), nil
+}
+
+// normalizePatch turns a patch from outside `git diff` into the form the
+// rest of the tool expects. The signature `git format-patch` ends each
+// patch with is dropped; its mail headers and diffstat come before the
+// first file and are ignored anyway. Plain unified diffs, e.g. from
+// `diff -u`, Mercurial or Subversion, get a "diff --git" header per file.
+func normalizePatch(patch string) string {
+	patch = strings.ReplaceAll(patch, "\r\n", "\n")
+	patch = formatPatchSignature.ReplaceAllString(patch, "\n")
+	if strings.HasPrefix(patch, "diff --git ") || strings.Contains(patch, "\ndiff --git ") {
+		return patch
+	}
+	lines := strings.SplitAfter(patch, "\n")
+	var b strings.Builder
+	for i, line := range lines {
+		if i+2 < len(lines) && strings.HasPrefix(line, "--- ") && strings.HasPrefix(lines[i+1], "+++ ") && strings.HasPrefix(lines[i+2], "@@") {
+			from, to := patchPath(line[4:], "a/"), patchPath(lines[i+1][4:], "b/")
+			// `diff -ru old new` puts the two directory names in front.
+			_, fromRest, ok1 := strings.Cut(from, "/")
+			_, toRest, ok2 := strings.Cut(to, "/")
+			if ok1 && ok2 && from != to && fromRest == toRest {
+				from, to = fromRest, toRest
+			}
+			switch {
+			case from == "/dev/null":
+				fmt.Fprintf(&b, "diff --git a/%s b/%s\nnew file mode 100644\n", to, to)
+			case to == "/dev/null":
+				fmt.Fprintf(&b, "diff --git a/%s b/%s\ndeleted file mode 100644\n", from, from)
+			default:
+				fmt.Fprintf(&b, "diff --git a/%s b/%s\n", from, to)
+			}
+			b.WriteString("--- " + prefixPath(from, "a/") + "\n")
+			b.WriteString("+++ " + prefixPath(to, "b/") + "\n")
+			continue
+		}
+		if i > 0 && strings.HasPrefix(lines[i-1], "--- ") && strings.HasPrefix(line, "+++ ") && i+1 < len(lines) && strings.HasPrefix(lines[i+1], "@@") {
+			continue
+		}
+		b.WriteString(line)
+	}
+	return b.String()
+}
+
+// patchPath returns the path on a ---/+++ line without its timestamp or
+// revision and without prefix.
+func patchPath(rest, prefix string) string {
+	path, _, _ := strings.Cut(strings.TrimRight(rest, "\n"), "\t")
+	path = strings.TrimSpace(path)
+	if path == "/dev/null" {
+		return path
+	}
+	return strings.TrimPrefix(path, prefix)
+}
+
+// prefixPath gives path git's a/ or b/ prefix, unless it is /dev/null.
+func prefixPath(path, prefix string) string {
+	if path == "/dev/null" {
+		return path
+	}
+	return prefix + path
+}
@@ -0,0 +1,72 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// defaultBitbucketURL is the API of Bitbucket Cloud.
+const defaultBitbucketURL = "https://api.bitbucket.org/2.0"
+
+// bitbucketHeaders authenticates with a repository, project or workspace
+// access token.
+func bitbucketHeaders(cfg Config) map[string]string {
+	if cfg.Token == "" {
+		return nil
+	}
+	return map[string]string{"Authorization": "Bearer " + cfg.Token}
+}
+
+// Bitbucket fetches issue number of repo ("workspace/repo"). Its kind
+// and priority are reported as labels.
+func Bitbucket(ctx context.Context, cfg Config, repo, number string) (Issue, error) {
+	var resp struct {
+		Title    string `json:"title"`
+		Kind     string `json:"kind"`
+		Priority string `json:"priority"`
+		Links    struct {
+			HTML struct {
+				Href string `json:"href"`
+			} `json:"html"`
+		} `json:"links"`
+	}
+	endpoint := fmt.Sprintf("%s/repositories/%s/issues/%s", baseURL(cfg, defaultBitbucketURL), repo, number)
+	if err := getJSON(ctx, cfg, endpoint, bitbucketHeaders(cfg), &resp); err != nil {
+		return Issue{}, fmt.Errorf("could not fetch Bitbucket issue #%s: %w", number, err)
+	}
+	issue := Issue{Ref: "#" + number, Title: resp.Title, URL: resp.Links.HTML.Href}
+	for _, label := range []string{resp.Kind, resp.Priority} {
+		if label != "" {
+			issue.Labels = append(issue.Labels, label)
+		}
+	}
+	return issue, nil
+}
+
+// BitbucketPullRequest fetches the open pull request from branch in repo.
+// ok is false when there is none.
+func BitbucketPullRequest(ctx context.Context, cfg Config, repo, branch string) (pr ChangeRequest, ok bool, err error) {
+	var resp struct {
+		Values []struct {
+			ID          int    `json:"id"`
+			Title       string `json:"title"`
+			Description string `json:"description"`
+			Links       struct {
+				HTML struct {
+					Href string `json:"href"`
+				} `json:"html"`
+			} `json:"links"`
+		} `json:"values"`
+	}
+	query := url.QueryEscape(fmt.Sprintf(`source.branch.name="%s" AND state="OPEN"`, branch))
+	endpoint := fmt.Sprintf("%s/repositories/%s/pullrequests?q=%s", baseURL(cfg, defaultBitbucketURL), repo, query)
+	if err := getJSON(ctx, cfg, endpoint, bitbucketHeaders(cfg), &resp); err != nil {
+		return ChangeRequest{}, false, fmt.Errorf("could not look up the Bitbucket pull request for %s: %w", branch, err)
+	}
+	if len(resp.Values) == 0 {
+		return ChangeRequest{}, false, nil
+	}
+	first := resp.Values[0]
+	return ChangeRequest{Ref: fmt.Sprintf("#%d", first.ID), Title: first.Title, Description: first.Description, URL: first.Links.HTML.Href}, true, nil
+}
@@ -0,0 +1,37 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+)
+
+// defaultGitHubURL is the API of github.com. GitHub Enterprise serves it
+// under https://<host>/api/v3.
+const defaultGitHubURL = "https://api.github.com"
+
+// GitHub fetches issue number of repo ("owner/repo").
+func GitHub(ctx context.Context, cfg Config, repo, number string) (Issue, error) {
+	headers := map[string]string{
+		"Accept":               "application/vnd.github+json",
+		"X-GitHub-Api-Version": "2022-11-28",
+	}
+	if cfg.Token != "" {
+		headers["Authorization"] = "Bearer " + cfg.Token
+	}
+	var resp struct {
+		Title   string `json:"title"`
+		HTMLURL string `json:"html_url"`
+		Labels  []struct {
+			Name string `json:"name"`
+		} `json:"labels"`
+	}
+	url := fmt.Sprintf("%s/repos/%s/issues/%s", baseURL(cfg, defaultGitHubURL), repo, number)
+	if err := getJSON(ctx, cfg, url, headers, &resp); err != nil {
+		return Issue{}, fmt.Errorf("could not fetch GitHub issue #%s: %w", number, err)
+	}
+	issue := Issue{Ref: "#" + number, Title: resp.Title, URL: resp.HTMLURL}
+	for _, label := range resp.Labels {
+		issue.Labels = append(issue.Labels, label.Name)
+	}
+	return issue, nil
+}
@@ -0,0 +1,54 @@
+package issues
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// defaultGitLabURL is the API of gitlab.com. Self-managed instances serve
+// it under https://<host>/api/v4.
+const defaultGitLabURL = "https://gitlab.com/api/v4"
+
+// gitLabHeaders authenticates with a personal, project or group access token.
+func gitLabHeaders(cfg Config) map[string]string {
+	if cfg.Token == "" {
+		return nil
+	}
+	return map[string]string{"PRIVATE-TOKEN": cfg.Token}
+}
+
+// GitLab fetches issue number of project ("group/project").
+func GitLab(ctx context.Context, cfg Config, project, number string) (Issue, error) {
+	var resp struct {
+		Title  string   `json:"title"`
+		Labels []string `json:"labels"`
+		WebURL string   `json:"web_url"`
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/issues/%s", baseURL(cfg, defaultGitLabURL), url.PathEscape(project), number)
+	if err := getJSON(ctx, cfg, endpoint, gitLabHeaders(cfg), &resp); err != nil {
+		return Issue{}, fmt.Errorf("could not fetch GitLab issue #%s: %w", number, err)
+	}
+	return Issue{Ref: "#" + number, Title: resp.Title, Labels: resp.Labels, URL: resp.WebURL}, nil
+}
+
+// GitLabMergeRequest fetches the open merge request from branch in
+// project. ok is false when there is none.
+func GitLabMergeRequest(ctx context.Context, cfg Config, project, branch string) (mr ChangeRequest, ok bool, err error) {
+	var resp []struct {
+		IID         int    `json:"iid"`
+		Title       string `json:"title"`
+		Description string `json:"description"`
+		WebURL      string `json:"web_url"`
+	}
+	endpoint := fmt.Sprintf("%s/projects/%s/merge_requests?state=opened&source_branch=%s",
+		baseURL(cfg, defaultGitLabURL), url.PathEscape(project), url.QueryEscape(branch))
+	if err := getJSON(ctx, cfg, endpoint, gitLabHeaders(cfg), &resp); err != nil {
+		return ChangeRequest{}, false, fmt.Errorf("could not look up the GitLab merge request for %s: %w", branch, err)
+	}
+	if len(resp) == 0 {
+		return ChangeRequest{}, false, nil
+	}
+	first := resp[0]
+	return ChangeRequest{Ref: fmt.Sprintf("!%d", first.IID), Title: first.Title, Description: first.Description, URL: first.WebURL}, true, nil
+}
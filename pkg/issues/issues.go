@@ -0,0 +1,98 @@
+// Package issues looks up issues on the platforms hosting a repository,
+// so git-commit-message can tell the model what the work is for.
+package issues
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultTimeout bounds a lookup when Config.Timeout is zero. Issue
+// details are a nice-to-have, so this is much shorter than for models.
+const DefaultTimeout = 10 * time.Second
+
+// Issue is what the model is told about an issue.
+type Issue struct {
+	// Ref is how the issue is referred to, e.g. "#42".
+	Ref    string
+	Title  string
+	Labels []string
+	// Description is only filled in where it is usually worth reading,
+	// such as Jira.
+	Description string
+	URL         string
+}
+
+// ChangeRequest is a merge or pull request under review: its
+// description usually says what the work on the branch is for.
+type ChangeRequest struct {
+	// Ref is how the request is referred to, e.g. "!7" on GitLab.
+	Ref         string
+	Title       string
+	Description string
+	URL         string
+}
+
+// Config tells a lookup where and how to connect.
+type Config struct {
+	// URL is the API base URL. Empty means the platform's public API.
+	URL string
+	// Token authenticates the request. Public issues don't need one.
+	Token string
+	// User goes with Token for platforms using basic authentication.
+	User    string
+	Timeout time.Duration
+	// Client sends the requests. Nil means http.DefaultClient.
+	Client *http.Client
+}
+
+// getJSON GETs url with headers and decodes the JSON response into v.
+func getJSON(ctx context.Context, cfg Config, url string, headers map[string]string, v any) error {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	client := cfg.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("failed to read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("API returned non-200 status: %s. Response: %s", resp.Status, strings.TrimSpace(string(body)))
+	}
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("failed to unmarshal response: %w", err)
+	}
+	return nil
+}
+
+// baseURL returns cfg.URL without a trailing slash, or fallback.
+func baseURL(cfg Config, fallback string) string {
+	if cfg.URL == "" {
+		return fallback
+	}
+	return strings.TrimRight(cfg.URL, "/")
+}
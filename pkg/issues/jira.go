@@ -0,0 +1,50 @@
+package issues
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+)
+
+// Jira fetches the issue with key (e.g. "PROJ-123") from the Jira site at
+// cfg.URL. With cfg.User set, as Jira Cloud expects, cfg.Token is sent as
+// the user's API token; otherwise it is a personal access token, as used
+// by Jira Data Center.
+func Jira(ctx context.Context, cfg Config, key string) (Issue, error) {
+	if cfg.URL == "" {
+		return Issue{}, fmt.Errorf("no Jira URL configured")
+	}
+	headers := map[string]string{}
+	switch {
+	case cfg.User != "":
+		headers["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(cfg.User+":"+cfg.Token))
+	case cfg.Token != "":
+		headers["Authorization"] = "Bearer " + cfg.Token
+	}
+	var resp struct {
+		Fields struct {
+			Summary     string   `json:"summary"`
+			Description string   `json:"description"`
+			Labels      []string `json:"labels"`
+			IssueType   struct {
+				Name string `json:"name"`
+			} `json:"issuetype"`
+		} `json:"fields"`
+	}
+	endpoint := fmt.Sprintf("%s/rest/api/2/issue/%s?fields=summary,description,labels,issuetype", baseURL(cfg, ""), url.PathEscape(key))
+	if err := getJSON(ctx, cfg, endpoint, headers, &resp); err != nil {
+		return Issue{}, fmt.Errorf("could not fetch Jira issue %s: %w", key, err)
+	}
+	issue := Issue{
+		Ref:         key,
+		Title:       resp.Fields.Summary,
+		Description: resp.Fields.Description,
+		URL:         baseURL(cfg, "") + "/browse/" + key,
+	}
+	if resp.Fields.IssueType.Name != "" {
+		issue.Labels = append(issue.Labels, resp.Fields.IssueType.Name)
+	}
+	issue.Labels = append(issue.Labels, resp.Fields.Labels...)
+	return issue, nil
+}
@@ -0,0 +1,106 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+const (
+	// defaultAnthropicURL is used when no URL is configured for the anthropic backend.
+	defaultAnthropicURL = "https://api.anthropic.com"
+	// anthropicVersion pins the Messages API version we speak.
+	anthropicVersion = "2023-06-01"
+	// defaultAnthropicMaxTokens is plenty for a commit message; the API requires a value.
+	defaultAnthropicMaxTokens = 256
+)
+
+// AnthropicMessage is a single turn in the Anthropic Messages API.
+type AnthropicMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// AnthropicRequest defines the payload for /v1/messages.
+type AnthropicRequest struct {
+	Model       string             `json:"model"`
+	MaxTokens   int                `json:"max_tokens"`
+	System      string             `json:"system,omitempty"`
+	Messages    []AnthropicMessage `json:"messages"`
+	Temperature float64            `json:"temperature"`
+}
+
+// AnthropicResponse holds the parts of the Messages API response we use.
+type AnthropicResponse struct {
+	Content []struct {
+		Type string `json:"type"`
+		Text string `json:"text"`
+	} `json:"content"`
+}
+
+// Anthropic talks to the Anthropic Messages API.
+type Anthropic struct {
+	cfg Config
+}
+
+// NewAnthropic returns an Anthropic backend for cfg.
+func NewAnthropic(cfg Config) *Anthropic {
+	return &Anthropic{cfg: cfg}
+}
+
+// Generate implements Generator.
+func (a *Anthropic) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	apiKey := a.cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("ANTHROPIC_API_KEY")
+	}
+	if apiKey == "" {
+		return "", fmt.Errorf("anthropic backend requires an API key or $ANTHROPIC_API_KEY")
+	}
+	maxTokens := opts.MaxTokens
+	if maxTokens <= 0 {
+		maxTokens = defaultAnthropicMaxTokens
+	}
+
+	system, turns := conversation(a.cfg, prompt, opts)
+	apiRequest := AnthropicRequest{
+		Model:       a.cfg.Model,
+		MaxTokens:   maxTokens,
+		System:      system,
+		Temperature: opts.Temperature,
+	}
+	for _, t := range turns {
+		apiRequest.Messages = append(apiRequest.Messages, AnthropicMessage{Role: t.role(), Content: t.content})
+	}
+
+	headers := map[string]string{
+		"x-api-key":         apiKey,
+		"anthropic-version": anthropicVersion,
+	}
+
+	url := fmt.Sprintf("%s/v1/messages", baseURL(a.cfg, defaultAnthropicURL))
+	body, err := postJSON(ctx, a.cfg, url, headers, apiRequest)
+	if err != nil {
+		return "", fmt.Errorf("Anthropic request failed: %w", err)
+	}
+
+	var anthropicResp AnthropicResponse
+	if err := json.Unmarshal(body, &anthropicResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Anthropic response: %w", err)
+	}
+
+	// The reply is a list of content blocks; we only care about the text ones.
+	var text strings.Builder
+	for _, block := range anthropicResp.Content {
+		if block.Type == "text" {
+			text.WriteString(block.Text)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("Anthropic response contained no text content")
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,71 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+)
+
+// defaultAzureAPIVersion is used when no api-version is configured.
+const defaultAzureAPIVersion = "2024-06-01"
+
+// AzureOpenAI talks to an Azure OpenAI deployment. The request and response
+// bodies are the OpenAI chat completions schema; only the URL layout and
+// authentication header differ.
+type AzureOpenAI struct {
+	cfg Config
+}
+
+// NewAzureOpenAI returns an Azure OpenAI backend for cfg.
+func NewAzureOpenAI(cfg Config) *AzureOpenAI {
+	return &AzureOpenAI{cfg: cfg}
+}
+
+// Generate implements Generator.
+func (a *AzureOpenAI) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	if a.cfg.URL == "" {
+		return "", fmt.Errorf("azure backend requires the resource endpoint URL (e.g. https://my-resource.openai.azure.com)")
+	}
+	if a.cfg.Deployment == "" {
+		return "", fmt.Errorf("azure backend requires a deployment name")
+	}
+	apiKey := a.cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("AZURE_OPENAI_API_KEY")
+	}
+	if apiKey == "" {
+		return "", fmt.Errorf("azure backend requires an API key")
+	}
+	apiVersion := a.cfg.APIVersion
+	if apiVersion == "" {
+		apiVersion = defaultAzureAPIVersion
+	}
+
+	// The deployment already pins the model, so Model is left out of the payload.
+	apiRequest := OpenAIRequest{
+		Messages:       chatMessages(a.cfg, prompt, opts),
+		Temperature:    opts.Temperature,
+		MaxTokens:      opts.MaxTokens,
+		Seed:           opts.Seed,
+		ResponseFormat: responseFormat(opts),
+	}
+
+	headers := map[string]string{"api-key": apiKey}
+
+	endpoint := fmt.Sprintf("%s/openai/deployments/%s/chat/completions?api-version=%s",
+		baseURL(a.cfg, ""), url.PathEscape(a.cfg.Deployment), url.QueryEscape(apiVersion))
+	if opts.OnToken != nil {
+		reply, err := streamChatCompletion(ctx, a.cfg, endpoint, headers, apiRequest, opts.OnToken)
+		if err != nil {
+			return "", fmt.Errorf("Azure OpenAI request failed: %w", err)
+		}
+		return reply, nil
+	}
+	body, err := postJSON(ctx, a.cfg, endpoint, headers, apiRequest)
+	if err != nil {
+		return "", fmt.Errorf("Azure OpenAI request failed: %w", err)
+	}
+
+	return decodeChatCompletion(body)
+}
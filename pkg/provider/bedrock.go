@@ -0,0 +1,107 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime"
+	"github.com/aws/aws-sdk-go-v2/service/bedrockruntime/types"
+)
+
+// Bedrock talks to AWS Bedrock through the model-agnostic Converse API.
+// Credentials come from the standard AWS chain (environment, shared
+// profile, SSO, IAM role), so no key is stored in the config.
+type Bedrock struct {
+	cfg Config
+}
+
+// NewBedrock returns a Bedrock backend for cfg.
+func NewBedrock(cfg Config) *Bedrock {
+	return &Bedrock{cfg: cfg}
+}
+
+// Generate implements Generator.
+func (b *Bedrock) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	var loadOptions []func(*awsconfig.LoadOptions) error
+	if b.cfg.AWSRegion != "" {
+		loadOptions = append(loadOptions, awsconfig.WithRegion(b.cfg.AWSRegion))
+	}
+	if b.cfg.AWSProfile != "" {
+		loadOptions = append(loadOptions, awsconfig.WithSharedConfigProfile(b.cfg.AWSProfile))
+	}
+	awsCfg, err := awsconfig.LoadDefaultConfig(ctx, loadOptions...)
+	if err != nil {
+		return "", fmt.Errorf("failed to load AWS configuration: %w", err)
+	}
+	if awsCfg.Region == "" {
+		return "", fmt.Errorf("bedrock backend requires an AWS region (set aws_region or $AWS_REGION)")
+	}
+
+	awsHTTPClient, err := httpClient(b.cfg)
+	if err != nil {
+		return "", err
+	}
+	client := bedrockruntime.NewFromConfig(awsCfg, func(o *bedrockruntime.Options) {
+		// Allows VPC endpoints or a local mock to stand in for the public endpoint.
+		if b.cfg.URL != "" {
+			o.BaseEndpoint = aws.String(b.cfg.URL)
+		}
+		// The SDK has its own backoff and Retry-After handling; just size it.
+		o.RetryMaxAttempts = b.cfg.Retries + 1
+		o.HTTPClient = awsHTTPClient
+	})
+
+	inferenceConfig := &types.InferenceConfiguration{
+		Temperature: aws.Float32(float32(opts.Temperature)),
+	}
+	if opts.MaxTokens > 0 {
+		inferenceConfig.MaxTokens = aws.Int32(int32(opts.MaxTokens))
+	}
+
+	system, turns := conversation(b.cfg, prompt, opts)
+	input := &bedrockruntime.ConverseInput{
+		ModelId:         aws.String(b.cfg.Model),
+		InferenceConfig: inferenceConfig,
+	}
+	for _, t := range turns {
+		role := types.ConversationRoleUser
+		if t.assistant {
+			role = types.ConversationRoleAssistant
+		}
+		input.Messages = append(input.Messages, types.Message{
+			Role:    role,
+			Content: []types.ContentBlock{&types.ContentBlockMemberText{Value: t.content}},
+		})
+	}
+	if system != "" {
+		input.System = []types.SystemContentBlock{&types.SystemContentBlockMemberText{Value: system}}
+	}
+
+	started := time.Now()
+	output, err := client.Converse(ctx, input)
+	slog.Info("bedrock converse", "model", b.cfg.Model, "region", awsCfg.Region, "duration", time.Since(started), "err", err)
+	if err != nil {
+		return "", fmt.Errorf("Bedrock request failed: %w", err)
+	}
+
+	message, ok := output.Output.(*types.ConverseOutputMemberMessage)
+	if !ok {
+		return "", fmt.Errorf("Bedrock response contained no message")
+	}
+	var text strings.Builder
+	for _, block := range message.Value.Content {
+		if textBlock, ok := block.(*types.ContentBlockMemberText); ok {
+			text.WriteString(textBlock.Value)
+		}
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("Bedrock returned no text (stop reason: %s)", output.StopReason)
+	}
+
+	return text.String(), nil
+}
@@ -0,0 +1,47 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Chain is a Generator that tries each backend in order and returns the
+// first successful reply, e.g. a local Ollama model with a hosted fallback.
+type Chain struct {
+	// Backends are tried in order.
+	Backends []Generator
+	// Names label Backends in errors and OnFallback, e.g. "ollama/llama3".
+	Names []string
+	// OnFallback, if set, is called when a backend fails and the next one
+	// is about to be tried.
+	OnFallback func(failed, next string, err error)
+}
+
+// Generate implements Generator.
+func (c *Chain) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	var errs []error
+	for i, backend := range c.Backends {
+		reply, err := backend.Generate(ctx, prompt, opts)
+		if err == nil {
+			return reply, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", c.name(i), err))
+		// A cancelled run should stop, not fall through.
+		if ctx.Err() != nil {
+			break
+		}
+		if i+1 < len(c.Backends) && c.OnFallback != nil {
+			c.OnFallback(c.name(i), c.name(i+1), err)
+		}
+	}
+	return "", fmt.Errorf("all providers failed: %w", errors.Join(errs...))
+}
+
+// name returns the label of backend i.
+func (c *Chain) name(i int) string {
+	if i < len(c.Names) {
+		return c.Names[i]
+	}
+	return fmt.Sprintf("provider #%d", i+1)
+}
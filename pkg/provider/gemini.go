@@ -0,0 +1,130 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultGeminiURL is used when no URL is configured for the gemini backend.
+const defaultGeminiURL = "https://generativelanguage.googleapis.com/v1beta"
+
+// SafetySetting is passed through verbatim to the Gemini API, e.g.
+// {category: HARM_CATEGORY_DANGEROUS_CONTENT, threshold: BLOCK_ONLY_HIGH}.
+type SafetySetting struct {
+	Category  string `yaml:"category" json:"category"`
+	Threshold string `yaml:"threshold" json:"threshold"`
+}
+
+// GeminiPart is a single piece of content in a Gemini message.
+type GeminiPart struct {
+	Text string `json:"text"`
+}
+
+// GeminiContent is a single turn in a Gemini conversation.
+type GeminiContent struct {
+	Role  string       `json:"role,omitempty"`
+	Parts []GeminiPart `json:"parts"`
+}
+
+// GeminiRequest defines the payload for models/{model}:generateContent.
+type GeminiRequest struct {
+	Contents          []GeminiContent `json:"contents"`
+	SystemInstruction *GeminiContent  `json:"systemInstruction,omitempty"`
+	SafetySettings    []SafetySetting `json:"safetySettings,omitempty"`
+	GenerationConfig  struct {
+		Temperature     float64 `json:"temperature"`
+		MaxOutputTokens int     `json:"maxOutputTokens,omitempty"`
+		Seed            *int    `json:"seed,omitempty"`
+		// ResponseMimeType is "application/json" for structured replies.
+		// Gemini's schema dialect lacks additionalProperties, so the
+		// schema itself is left to the prompt.
+		ResponseMimeType string `json:"responseMimeType,omitempty"`
+	} `json:"generationConfig"`
+}
+
+// GeminiResponse holds the parts of the generateContent response we use.
+type GeminiResponse struct {
+	Candidates []struct {
+		Content      GeminiContent `json:"content"`
+		FinishReason string        `json:"finishReason"`
+	} `json:"candidates"`
+	PromptFeedback struct {
+		BlockReason string `json:"blockReason"`
+	} `json:"promptFeedback"`
+}
+
+// Gemini talks to Google's Generative Language (Gemini) API.
+type Gemini struct {
+	cfg Config
+}
+
+// NewGemini returns a Gemini backend for cfg.
+func NewGemini(cfg Config) *Gemini {
+	return &Gemini{cfg: cfg}
+}
+
+// Generate implements Generator.
+func (g *Gemini) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	apiKey := g.cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("GEMINI_API_KEY")
+	}
+	if apiKey == "" {
+		return "", fmt.Errorf("gemini backend requires an API key or $GEMINI_API_KEY")
+	}
+
+	system, turns := conversation(g.cfg, prompt, opts)
+	apiRequest := GeminiRequest{
+		SafetySettings: g.cfg.SafetySettings,
+	}
+	for _, t := range turns {
+		// Gemini calls the assistant "model".
+		role := "user"
+		if t.assistant {
+			role = "model"
+		}
+		apiRequest.Contents = append(apiRequest.Contents, GeminiContent{Role: role, Parts: []GeminiPart{{Text: t.content}}})
+	}
+	if system != "" {
+		apiRequest.SystemInstruction = &GeminiContent{Parts: []GeminiPart{{Text: system}}}
+	}
+	apiRequest.GenerationConfig.Temperature = opts.Temperature
+	apiRequest.GenerationConfig.MaxOutputTokens = opts.MaxTokens
+	apiRequest.GenerationConfig.Seed = opts.Seed
+	if opts.Schema != nil {
+		apiRequest.GenerationConfig.ResponseMimeType = "application/json"
+	}
+
+	// The key goes in a header rather than the query string so it doesn't end up in error messages.
+	headers := map[string]string{"x-goog-api-key": apiKey}
+
+	url := fmt.Sprintf("%s/models/%s:generateContent", baseURL(g.cfg, defaultGeminiURL), g.cfg.Model)
+	body, err := postJSON(ctx, g.cfg, url, headers, apiRequest)
+	if err != nil {
+		return "", fmt.Errorf("Gemini request failed: %w", err)
+	}
+
+	var geminiResp GeminiResponse
+	if err := json.Unmarshal(body, &geminiResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Gemini response: %w", err)
+	}
+	if reason := geminiResp.PromptFeedback.BlockReason; reason != "" {
+		return "", fmt.Errorf("Gemini blocked the prompt: %s", reason)
+	}
+	if len(geminiResp.Candidates) == 0 {
+		return "", fmt.Errorf("Gemini response contained no candidates")
+	}
+
+	var text strings.Builder
+	for _, part := range geminiResp.Candidates[0].Content.Parts {
+		text.WriteString(part.Text)
+	}
+	if text.Len() == 0 {
+		return "", fmt.Errorf("Gemini returned no text (finish reason: %s)", geminiResp.Candidates[0].FinishReason)
+	}
+
+	return text.String(), nil
+}
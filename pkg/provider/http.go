@@ -0,0 +1,340 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultTimeout bounds a single request when Config.Timeout is zero.
+	// Local models can take a while to load on first use.
+	DefaultTimeout = 60 * time.Second
+	// DefaultConnectTimeout bounds connecting when Config.ConnectTimeout is zero.
+	DefaultConnectTimeout = 10 * time.Second
+
+	// retryBaseDelay is the first backoff delay; it doubles on every retry.
+	retryBaseDelay = time.Second
+	// retryMaxDelay caps both the backoff and any Retry-After the server asks for.
+	retryMaxDelay = 60 * time.Second
+)
+
+// clients holds one *http.Client per clientKey, so repeated requests
+// (candidates, summaries, a long-running daemon) reuse warm connections.
+var clients sync.Map
+
+// clientKey is everything in Config that shapes the *http.Client.
+type clientKey struct {
+	timeout, connectTimeout time.Duration
+	caBundle                string
+	insecureSkipVerify      bool
+}
+
+// tlsConfig returns the TLS settings for cfg, or nil for Go's defaults.
+func tlsConfig(cfg Config) (*tls.Config, error) {
+	if cfg.CABundle == "" && !cfg.InsecureSkipVerify {
+		return nil, nil
+	}
+	config := &tls.Config{InsecureSkipVerify: cfg.InsecureSkipVerify}
+	if cfg.CABundle != "" {
+		pem, err := os.ReadFile(cfg.CABundle)
+		if err != nil {
+			return nil, fmt.Errorf("could not read CA bundle: %w", err)
+		}
+		// Extend the system roots so public endpoints keep working.
+		pool, err := x509.SystemCertPool()
+		if err != nil {
+			pool = x509.NewCertPool()
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no PEM certificates found in CA bundle %s", cfg.CABundle)
+		}
+		config.RootCAs = pool
+	}
+	return config, nil
+}
+
+// httpClient returns a client configured with cfg's timeouts and TLS
+// settings. Proxies come from the environment, as for http.DefaultTransport.
+func httpClient(cfg Config) (*http.Client, error) {
+	timeout := cfg.Timeout
+	if timeout <= 0 {
+		timeout = DefaultTimeout
+	}
+	connectTimeout := cfg.ConnectTimeout
+	if connectTimeout <= 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+
+	key := clientKey{timeout, connectTimeout, cfg.CABundle, cfg.InsecureSkipVerify}
+	if client, ok := clients.Load(key); ok {
+		return client.(*http.Client), nil
+	}
+
+	tlsCfg, err := tlsConfig(cfg)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.InsecureSkipVerify {
+		slog.Warn("TLS certificate verification is disabled (insecure_skip_verify)")
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.DialContext = (&net.Dialer{Timeout: connectTimeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = connectTimeout
+	if tlsCfg != nil {
+		transport.TLSClientConfig = tlsCfg
+	}
+	client, _ := clients.LoadOrStore(key, &http.Client{Timeout: timeout, Transport: transport})
+	return client.(*http.Client), nil
+}
+
+// HTTPClient returns the client requests under cfg are sent with, so that
+// other lookups, such as issue details, use the same TLS settings.
+func HTTPClient(cfg Config) (*http.Client, error) {
+	return httpClient(cfg)
+}
+
+// statusError is returned for non-200 responses.
+type statusError struct {
+	Status     string
+	StatusCode int
+	Body       string
+	RetryAfter time.Duration
+}
+
+func (e *statusError) Error() string {
+	return fmt.Sprintf("API returned non-200 status: %s. Response: %s", e.Status, e.Body)
+}
+
+// retryable reports whether a request that failed with err is worth retrying.
+func retryable(err error) bool {
+	var se *statusError
+	if errors.As(err, &se) {
+		switch se.StatusCode {
+		case http.StatusTooManyRequests, http.StatusInternalServerError, http.StatusBadGateway,
+			http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+			return true
+		}
+		return false
+	}
+	// Anything else is a transport-level failure (refused, reset, timeout).
+	return true
+}
+
+// backoff returns the delay before retry number attempt (starting at 1):
+// exponential with full jitter, or the server's Retry-After if it gave one.
+func backoff(attempt int, err error) time.Duration {
+	var se *statusError
+	if errors.As(err, &se) && se.RetryAfter > 0 {
+		return min(se.RetryAfter, retryMaxDelay)
+	}
+	ceiling := min(retryBaseDelay<<(attempt-1), retryMaxDelay)
+	return ceiling/2 + rand.N(ceiling/2+1)
+}
+
+// parseRetryAfter understands both forms of the Retry-After header.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if at, err := http.ParseTime(value); err == nil {
+		return time.Until(at)
+	}
+	return 0
+}
+
+// requestHeaders merges cfg's authentication and extra headers with the
+// backend's own headers for one request.
+func requestHeaders(cfg Config, headers map[string]string) map[string]string {
+	merged := make(map[string]string, len(headers)+len(cfg.Headers)+1)
+	switch {
+	case cfg.AuthToken != "":
+		merged["Authorization"] = "Bearer " + cfg.AuthToken
+	case cfg.BasicAuth != nil:
+		credentials := cfg.BasicAuth.Username + ":" + cfg.BasicAuth.Password
+		merged["Authorization"] = "Basic " + base64.StdEncoding.EncodeToString([]byte(credentials))
+	}
+	for key, value := range headers {
+		merged[key] = value
+	}
+	for key, value := range cfg.Headers {
+		merged[key] = value
+	}
+	return merged
+}
+
+// postJSON marshals payload, POSTs it to url with the given headers and
+// returns the response body. Non-200 responses are reported as errors.
+// Transient failures are retried up to cfg.Retries times.
+func postJSON(ctx context.Context, cfg Config, url string, headers map[string]string, payload any) ([]byte, error) {
+	// Marshal the request payload to JSON
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal request to JSON: %w", err)
+	}
+
+	client, err := httpClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	for attempt := 0; ; attempt++ {
+		started := time.Now()
+		body, err := sendOnce(ctx, client, "POST", url, requestHeaders(cfg, headers), jsonData)
+		logRoundTrip(url, attempt, time.Since(started), err)
+		if err == nil || attempt >= cfg.Retries || !retryable(err) || ctx.Err() != nil {
+			return body, err
+		}
+		select {
+		case <-time.After(backoff(attempt+1, err)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// getJSON GETs url with the given headers and returns the response body.
+// It is meant for quick metadata lookups that are often used as probes, so
+// it is not retried and failures are only logged at debug level.
+func getJSON(ctx context.Context, cfg Config, url string, headers map[string]string) ([]byte, error) {
+	client, err := httpClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	started := time.Now()
+	body, err := sendOnce(ctx, client, "GET", url, requestHeaders(cfg, headers), nil)
+	slog.Debug("http request", "method", "GET", "url", url, "duration", time.Since(started), "err", err)
+	return body, err
+}
+
+// postStream POSTs payload to url and calls onLine for every non-empty
+// line of the response as it arrives, for newline-delimited JSON streams.
+// The client timeout doesn't apply, as streams can legitimately run for
+// minutes; ctx bounds the request instead. Streams are not retried.
+func postStream(ctx context.Context, cfg Config, url string, headers map[string]string, payload any, onLine func(line []byte) error) error {
+	jsonData, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request to JSON: %w", err)
+	}
+	client, err := httpClient(cfg)
+	if err != nil {
+		return err
+	}
+	streaming := *client
+	streaming.Timeout = 0
+
+	req, err := http.NewRequestWithContext(ctx, "POST", url, bytes.NewReader(jsonData))
+	if err != nil {
+		return fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range requestHeaders(cfg, headers) {
+		req.Header.Set(key, value)
+	}
+	started := time.Now()
+	resp, err := streaming.Do(req)
+	if err != nil {
+		err = fmt.Errorf("failed to send request to %s: %w", url, err)
+		logRoundTrip(url, 0, time.Since(started), err)
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		err := &statusError{Status: resp.Status, StatusCode: resp.StatusCode, Body: string(body)}
+		logRoundTrip(url, 0, time.Since(started), err)
+		return err
+	}
+	logRoundTrip(url, 0, time.Since(started), nil)
+
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		if err := onLine(line); err != nil {
+			return err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read response stream: %w", err)
+	}
+	return nil
+}
+
+// logRoundTrip records one request attempt on the default slog logger.
+func logRoundTrip(url string, attempt int, took time.Duration, err error) {
+	status := "200 OK"
+	var se *statusError
+	switch {
+	case errors.As(err, &se):
+		status = se.Status
+	case err != nil:
+		slog.Warn("http request failed", "url", url, "attempt", attempt+1, "duration", took, "err", err)
+		return
+	}
+	slog.Info("http request", "url", url, "attempt", attempt+1, "status", status, "duration", took)
+}
+
+// sendOnce performs a single request attempt. jsonData is sent as the
+// body unless it is nil.
+func sendOnce(ctx context.Context, client *http.Client, method, url string, headers map[string]string, jsonData []byte) ([]byte, error) {
+	// Create the HTTP request
+	var reqBody io.Reader
+	if jsonData != nil {
+		reqBody = bytes.NewReader(jsonData)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reqBody)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create HTTP request: %w", err)
+	}
+	if jsonData != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	// Execute the request
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to send request to %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+
+	// Read and check the response
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read response body: %w", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, &statusError{
+			Status:     resp.Status,
+			StatusCode: resp.StatusCode,
+			Body:       string(body),
+			RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+		}
+	}
+
+	return body, nil
+}
@@ -0,0 +1,209 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// defaultOllamaURL is used when no URL is configured for the ollama backend.
+const defaultOllamaURL = "http://localhost:11434"
+
+// OllamaMessage is a single chat message in the Ollama chat API.
+type OllamaMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OllamaRequest defines the structure for the JSON payload sent to Ollama's /api/chat.
+type OllamaRequest struct {
+	Model     string          `json:"model"`
+	Messages  []OllamaMessage `json:"messages"`
+	Stream    bool            `json:"stream"`
+	KeepAlive string          `json:"keep_alive,omitempty"`
+	// Format is a JSON schema the reply must follow.
+	Format  json.RawMessage `json:"format,omitempty"`
+	Options struct {
+		Temperature   float64 `json:"temperature"`
+		NumPredict    int     `json:"num_predict,omitempty"`
+		TopP          float64 `json:"top_p,omitempty"`
+		TopK          int     `json:"top_k,omitempty"`
+		RepeatPenalty float64 `json:"repeat_penalty,omitempty"`
+		NumCtx        int     `json:"num_ctx,omitempty"`
+		Seed          *int    `json:"seed,omitempty"`
+	} `json:"options"`
+}
+
+// OllamaResponse defines the structure to decode the JSON response from Ollama.
+type OllamaResponse struct {
+	Message OllamaMessage `json:"message"`
+}
+
+// Ollama talks to a local or remote Ollama instance via /api/chat.
+type Ollama struct {
+	cfg Config
+}
+
+// NewOllama returns an Ollama backend for cfg.
+func NewOllama(cfg Config) *Ollama {
+	return &Ollama{cfg: cfg}
+}
+
+// Generate implements Generator.
+func (o *Ollama) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	// Construct the request payload
+	system, turns := conversation(o.cfg, prompt, opts)
+	apiRequest := OllamaRequest{
+		Model:     o.cfg.Model,
+		Stream:    false, // We want a single response, not a stream
+		KeepAlive: o.cfg.KeepAlive,
+	}
+	if system != "" {
+		apiRequest.Messages = append(apiRequest.Messages, OllamaMessage{Role: "system", Content: system})
+	}
+	for _, t := range turns {
+		apiRequest.Messages = append(apiRequest.Messages, OllamaMessage{Role: t.role(), Content: t.content})
+	}
+	apiRequest.Options.Temperature = opts.Temperature
+	apiRequest.Options.NumPredict = opts.MaxTokens
+	apiRequest.Options.TopP = opts.TopP
+	apiRequest.Options.TopK = opts.TopK
+	apiRequest.Options.RepeatPenalty = opts.RepeatPenalty
+	apiRequest.Options.NumCtx = opts.NumCtx
+	apiRequest.Options.Seed = opts.Seed
+	apiRequest.Format = opts.Schema
+
+	url := fmt.Sprintf("%s/api/chat", baseURL(o.cfg, defaultOllamaURL))
+	if opts.OnToken != nil {
+		return o.stream(ctx, url, apiRequest, opts.OnToken)
+	}
+	body, err := postJSON(ctx, o.cfg, url, nil, apiRequest)
+	if err != nil {
+		return "", o.requestError(err)
+	}
+
+	// Unmarshal the response
+	var ollamaResp OllamaResponse
+	if err := json.Unmarshal(body, &ollamaResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal Ollama response: %w", err)
+	}
+
+	return ollamaResp.Message.Content, nil
+}
+
+// stream sends apiRequest with streaming on, passing each piece of the
+// reply to onToken, and returns the whole reply.
+func (o *Ollama) stream(ctx context.Context, url string, apiRequest OllamaRequest, onToken func(string)) (string, error) {
+	apiRequest.Stream = true
+	var reply strings.Builder
+	err := postStream(ctx, o.cfg, url, nil, apiRequest, func(line []byte) error {
+		var chunk struct {
+			OllamaResponse
+			Error string `json:"error"`
+		}
+		if err := json.Unmarshal(line, &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal Ollama response: %w", err)
+		}
+		if chunk.Error != "" {
+			return errors.New(chunk.Error)
+		}
+		reply.WriteString(chunk.Message.Content)
+		onToken(chunk.Message.Content)
+		return nil
+	})
+	if err != nil {
+		return "", o.requestError(err)
+	}
+	return reply.String(), nil
+}
+
+// OllamaModel describes one model installed on an Ollama instance.
+type OllamaModel struct {
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Details    struct {
+		ParameterSize     string `json:"parameter_size"`
+		QuantizationLevel string `json:"quantization_level"`
+	} `json:"details"`
+}
+
+// OllamaModels lists the models installed on the Ollama instance at cfg.URL
+// (or the default local one), for setting up and checking the config.
+func OllamaModels(ctx context.Context, cfg Config) ([]OllamaModel, error) {
+	url := fmt.Sprintf("%s/api/tags", baseURL(cfg, defaultOllamaURL))
+	body, err := getJSON(ctx, cfg, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not list Ollama models: %w", err)
+	}
+	var tags struct {
+		Models []OllamaModel `json:"models"`
+	}
+	if err := json.Unmarshal(body, &tags); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal Ollama model list: %w", err)
+	}
+	return tags.Models, nil
+}
+
+// Preload implements Preloader. A generate request without a prompt makes
+// Ollama load the model and keep it for cfg.KeepAlive.
+func (o *Ollama) Preload(ctx context.Context) error {
+	url := fmt.Sprintf("%s/api/generate", baseURL(o.cfg, defaultOllamaURL))
+	payload := map[string]any{"model": o.cfg.Model, "keep_alive": o.cfg.KeepAlive}
+	if _, err := postJSON(ctx, o.cfg, url, nil, payload); err != nil {
+		return fmt.Errorf("could not preload Ollama model %s: %w", o.cfg.Model, o.requestError(err))
+	}
+	return nil
+}
+
+// ErrModelNotFound is returned (wrapped) when Ollama doesn't have the
+// configured model, so callers can offer to pull it with PullOllamaModel.
+var ErrModelNotFound = errors.New("model not found")
+
+// requestError wraps a failed Ollama request, recognising the 404 Ollama
+// answers with for models that aren't installed.
+func (o *Ollama) requestError(err error) error {
+	var se *statusError
+	if errors.As(err, &se) && se.StatusCode == http.StatusNotFound && strings.Contains(strings.ToLower(se.Body), "not found") {
+		return fmt.Errorf("%w: Ollama has no model %q; pull it with `ollama pull %s`", ErrModelNotFound, o.cfg.Model, o.cfg.Model)
+	}
+	return fmt.Errorf("Ollama request failed: %w", err)
+}
+
+// PullProgress is one progress update from PullOllamaModel. Total and
+// Completed are byte counts for the layer being downloaded, when known.
+type PullProgress struct {
+	Status    string `json:"status"`
+	Digest    string `json:"digest"`
+	Total     int64  `json:"total"`
+	Completed int64  `json:"completed"`
+	Error     string `json:"error"`
+}
+
+// PullOllamaModel downloads cfg.Model onto the Ollama instance at cfg.URL,
+// calling progress for every update Ollama streams back.
+func PullOllamaModel(ctx context.Context, cfg Config, progress func(PullProgress)) error {
+	url := fmt.Sprintf("%s/api/pull", baseURL(cfg, defaultOllamaURL))
+	payload := map[string]any{"model": cfg.Model, "stream": true}
+	err := postStream(ctx, cfg, url, nil, payload, func(line []byte) error {
+		var update PullProgress
+		if err := json.Unmarshal(line, &update); err != nil {
+			return fmt.Errorf("failed to unmarshal Ollama pull progress: %w", err)
+		}
+		if update.Error != "" {
+			return errors.New(update.Error)
+		}
+		if progress != nil {
+			progress(update)
+		}
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("could not pull Ollama model %s: %w", cfg.Model, err)
+	}
+	return nil
+}
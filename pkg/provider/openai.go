@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// defaultOpenAIURL is used when no URL is configured for the openai backend.
+const defaultOpenAIURL = "https://api.openai.com/v1"
+
+// OpenAIMessage is a single chat message in the OpenAI chat completions API.
+type OpenAIMessage struct {
+	Role    string `json:"role"`
+	Content string `json:"content"`
+}
+
+// OpenAIRequest defines the payload for /v1/chat/completions.
+type OpenAIRequest struct {
+	Model       string          `json:"model,omitempty"`
+	Messages    []OpenAIMessage `json:"messages"`
+	Temperature float64         `json:"temperature"`
+	MaxTokens   int             `json:"max_tokens,omitempty"`
+	Seed        *int            `json:"seed,omitempty"`
+	Stream      bool            `json:"stream,omitempty"`
+	// ResponseFormat asks for a reply matching a JSON schema.
+	ResponseFormat *OpenAIResponseFormat `json:"response_format,omitempty"`
+}
+
+// OpenAIResponseFormat is the response_format of a chat completions request.
+type OpenAIResponseFormat struct {
+	Type       string `json:"type"`
+	JSONSchema struct {
+		Name   string          `json:"name"`
+		Schema json.RawMessage `json:"schema"`
+		Strict bool            `json:"strict"`
+	} `json:"json_schema"`
+}
+
+// responseFormat returns the response_format for opts, or nil when no
+// schema is requested.
+func responseFormat(opts Options) *OpenAIResponseFormat {
+	if opts.Schema == nil {
+		return nil
+	}
+	format := &OpenAIResponseFormat{Type: "json_schema"}
+	format.JSONSchema.Name = "commit_message"
+	format.JSONSchema.Schema = opts.Schema
+	format.JSONSchema.Strict = true
+	return format
+}
+
+// OpenAIResponse holds the parts of the chat completions response we use.
+type OpenAIResponse struct {
+	Choices []struct {
+		Message OpenAIMessage `json:"message"`
+	} `json:"choices"`
+}
+
+// OpenAI talks to any OpenAI-compatible chat completions endpoint
+// (OpenAI, vLLM, LM Studio, LiteLLM, ...).
+type OpenAI struct {
+	cfg Config
+}
+
+// NewOpenAI returns an OpenAI-compatible backend for cfg.
+func NewOpenAI(cfg Config) *OpenAI {
+	return &OpenAI{cfg: cfg}
+}
+
+// Generate implements Generator.
+func (o *OpenAI) Generate(ctx context.Context, prompt string, opts Options) (string, error) {
+	// Fall back to the conventional environment variable so keys can stay out of config.yaml.
+	apiKey := o.cfg.APIKey
+	if apiKey == "" {
+		apiKey = os.Getenv("OPENAI_API_KEY")
+	}
+
+	apiRequest := OpenAIRequest{
+		Model:          o.cfg.Model,
+		Messages:       chatMessages(o.cfg, prompt, opts),
+		Temperature:    opts.Temperature,
+		MaxTokens:      opts.MaxTokens,
+		Seed:           opts.Seed,
+		ResponseFormat: responseFormat(opts),
+	}
+
+	headers := map[string]string{}
+	// Local servers such as LM Studio don't require a key, so only send one when configured.
+	if apiKey != "" {
+		headers["Authorization"] = "Bearer " + apiKey
+	}
+
+	url := fmt.Sprintf("%s/chat/completions", baseURL(o.cfg, defaultOpenAIURL))
+	if opts.OnToken != nil {
+		reply, err := streamChatCompletion(ctx, o.cfg, url, headers, apiRequest, opts.OnToken)
+		if err != nil {
+			return "", fmt.Errorf("OpenAI-compatible request failed: %w", err)
+		}
+		return reply, nil
+	}
+	body, err := postJSON(ctx, o.cfg, url, headers, apiRequest)
+	if err != nil {
+		return "", fmt.Errorf("OpenAI-compatible request failed: %w", err)
+	}
+
+	return decodeChatCompletion(body)
+}
+
+// chatMessages returns the chat completions messages for a request,
+// starting with the system message when there is one. It is shared with
+// the Azure backend.
+func chatMessages(cfg Config, prompt string, opts Options) []OpenAIMessage {
+	system, turns := conversation(cfg, prompt, opts)
+	var chat []OpenAIMessage
+	if system != "" {
+		chat = append(chat, OpenAIMessage{Role: "system", Content: system})
+	}
+	for _, t := range turns {
+		chat = append(chat, OpenAIMessage{Role: t.role(), Content: t.content})
+	}
+	return chat
+}
+
+// streamChatCompletion sends apiRequest with streaming on and reads the
+// server-sent events, passing each piece of the reply to onToken. Servers
+// that ignore "stream" and answer with a plain response are handled too.
+// It is shared with the Azure backend.
+func streamChatCompletion(ctx context.Context, cfg Config, url string, headers map[string]string, apiRequest OpenAIRequest, onToken func(string)) (string, error) {
+	apiRequest.Stream = true
+	var reply strings.Builder
+	var plain []byte
+	err := postStream(ctx, cfg, url, headers, apiRequest, func(line []byte) error {
+		data, ok := bytes.CutPrefix(line, []byte("data:"))
+		if !ok {
+			if !bytes.HasPrefix(line, []byte("event:")) && !bytes.HasPrefix(line, []byte(":")) {
+				plain = append(plain, line...)
+			}
+			return nil
+		}
+		data = bytes.TrimSpace(data)
+		if string(data) == "[DONE]" {
+			return nil
+		}
+		var chunk struct {
+			Choices []struct {
+				Delta OpenAIMessage `json:"delta"`
+			} `json:"choices"`
+		}
+		if err := json.Unmarshal(data, &chunk); err != nil {
+			return fmt.Errorf("failed to unmarshal OpenAI response: %w", err)
+		}
+		for _, choice := range chunk.Choices {
+			reply.WriteString(choice.Delta.Content)
+			onToken(choice.Delta.Content)
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	if reply.Len() == 0 && len(plain) > 0 {
+		text, err := decodeChatCompletion(plain)
+		if err == nil {
+			onToken(text)
+		}
+		return text, err
+	}
+	if reply.Len() == 0 {
+		return "", fmt.Errorf("OpenAI response contained no choices")
+	}
+	return reply.String(), nil
+}
+
+// decodeChatCompletion extracts the first choice from a chat completions
+// response. It is shared with the Azure backend, which uses the same schema.
+func decodeChatCompletion(body []byte) (string, error) {
+	var openAIResp OpenAIResponse
+	if err := json.Unmarshal(body, &openAIResp); err != nil {
+		return "", fmt.Errorf("failed to unmarshal OpenAI response: %w", err)
+	}
+	if len(openAIResp.Choices) == 0 {
+		return "", fmt.Errorf("OpenAI response contained no choices")
+	}
+
+	return openAIResp.Choices[0].Message.Content, nil
+}
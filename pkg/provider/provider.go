@@ -0,0 +1,185 @@
+// Package provider contains the model backends git-commit-message can talk to.
+// Every backend implements Generator, so other Go programs can reuse them
+// without going through the CLI.
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Generator is implemented by every model backend.
+type Generator interface {
+	// Generate sends the prompt to the model and returns its raw reply.
+	Generate(ctx context.Context, prompt string, opts Options) (string, error)
+}
+
+// Options tune a single generation request.
+type Options struct {
+	// Temperature is a value between 0.0 (deterministic) and 1.0 (creative).
+	Temperature float64
+	// MaxTokens caps the length of the reply. Zero means the backend default.
+	MaxTokens int
+	// System carries the instructions as a system prompt, with the prompt
+	// itself as the user message. Empty sends only the prompt.
+	System string
+	// Examples are sent as earlier turns of the conversation, each a user
+	// message answered by the assistant, to show the expected replies.
+	Examples []Example
+	// OnToken, when set, streams the reply: it is called with each piece
+	// as it arrives. Backends that can't stream ignore it and reply at once.
+	OnToken func(token string) `json:"-"`
+	// Schema, when set, asks for a JSON reply matching this JSON schema.
+	// The ollama, openai and azure backends enforce it; gemini is asked
+	// for JSON and the others rely on the prompt.
+	Schema json.RawMessage
+
+	// TopP, TopK, RepeatPenalty, NumCtx and Seed are Ollama sampling
+	// options; zero (nil for Seed) leaves the model's default. The openai,
+	// azure and gemini backends also send Seed.
+	TopP          float64
+	TopK          int
+	RepeatPenalty float64
+	NumCtx        int
+	Seed          *int
+}
+
+// Config selects a backend and tells it where and how to connect.
+type Config struct {
+	// Provider is one of "ollama" (the default), "openai", "azure",
+	// "anthropic", "gemini" or "bedrock".
+	Provider string
+	// URL is the API base URL. Empty means the backend's public default.
+	URL string
+	// APIKey authenticates against hosted APIs. Each backend also falls
+	// back to its conventional environment variable.
+	APIKey string
+	// Model is the model name as understood by the backend.
+	Model string
+	// SafetySettings is only used by the gemini backend.
+	SafetySettings []SafetySetting
+	// Deployment and APIVersion are only used by the azure backend.
+	Deployment string
+	APIVersion string
+	// AWSRegion and AWSProfile are only used by the bedrock backend. Empty
+	// values defer to the standard AWS environment and shared config.
+	AWSRegion  string
+	AWSProfile string
+
+	// Timeout bounds a single HTTP attempt and ConnectTimeout the TCP/TLS
+	// connect within it. Zero values use DefaultTimeout and DefaultConnectTimeout.
+	Timeout        time.Duration
+	ConnectTimeout time.Duration
+	// Retries is how many times a failed request is retried: on network
+	// errors, 429 and 5xx responses. Zero means a single attempt.
+	Retries int
+
+	// CABundle is a PEM file of extra certificate authorities to trust,
+	// e.g. a corporate TLS-intercepting proxy's. InsecureSkipVerify turns
+	// certificate verification off altogether. Proxies are taken from
+	// HTTP_PROXY, HTTPS_PROXY and NO_PROXY.
+	CABundle           string
+	InsecureSkipVerify bool
+
+	// AuthToken is sent as a bearer token and BasicAuth as HTTP basic
+	// credentials, for servers behind an authenticating reverse proxy.
+	// Neither replaces an Authorization header the backend sets itself.
+	// Headers are sent with every request and override everything else.
+	// Bedrock signs its own requests and ignores all three.
+	AuthToken string
+	BasicAuth *BasicAuth
+	Headers   map[string]string
+
+	// KeepAlive is only used by the ollama backend: how long the model
+	// stays loaded after a request, e.g. "30m" or "-1" for ever. Empty
+	// uses the server default.
+	KeepAlive string
+
+	// NoSystemPrompt sends Options.System as part of the user message, for
+	// models that ignore or reject system prompts.
+	NoSystemPrompt bool
+}
+
+// Example is a sample exchange for few-shot prompting: Input stands for
+// the user's message and Output for the reply the model should give.
+type Example struct {
+	Input  string `yaml:"input" json:"input"`
+	Output string `yaml:"output" json:"output"`
+}
+
+// turn is one message of a conversation.
+type turn struct {
+	assistant bool
+	content   string
+}
+
+// conversation returns the system prompt and the turns for a request: the
+// examples, then prompt as the last user turn. Without a system prompt, or
+// with NoSystemPrompt, system is empty and the instructions open the first
+// user turn instead.
+func conversation(cfg Config, prompt string, opts Options) (system string, turns []turn) {
+	for _, example := range opts.Examples {
+		turns = append(turns, turn{content: example.Input}, turn{assistant: true, content: example.Output})
+	}
+	turns = append(turns, turn{content: prompt})
+	if opts.System == "" {
+		return "", turns
+	}
+	if cfg.NoSystemPrompt {
+		turns[0].content = opts.System + "\n\n" + turns[0].content
+		return "", turns
+	}
+	return opts.System, turns
+}
+
+// role returns the role of the turn in the OpenAI, Anthropic and Ollama APIs.
+func (t turn) role() string {
+	if t.assistant {
+		return "assistant"
+	}
+	return "user"
+}
+
+// BasicAuth holds HTTP basic authentication credentials.
+type BasicAuth struct {
+	Username string `yaml:"username" json:"username"`
+	Password string `yaml:"password" json:"password"`
+}
+
+// Preloader is implemented by backends that can load the model ahead of
+// the first request, so it is warm when a prompt arrives.
+type Preloader interface {
+	Preload(ctx context.Context) error
+}
+
+// New returns the backend selected by cfg.Provider.
+// An empty value keeps the original Ollama behaviour.
+func New(cfg Config) (Generator, error) {
+	switch strings.ToLower(cfg.Provider) {
+	case "", "ollama":
+		return NewOllama(cfg), nil
+	case "openai":
+		return NewOpenAI(cfg), nil
+	case "azure":
+		return NewAzureOpenAI(cfg), nil
+	case "anthropic":
+		return NewAnthropic(cfg), nil
+	case "gemini":
+		return NewGemini(cfg), nil
+	case "bedrock":
+		return NewBedrock(cfg), nil
+	default:
+		return nil, fmt.Errorf("unknown provider %q (expected ollama, openai, azure, anthropic, gemini or bedrock)", cfg.Provider)
+	}
+}
+
+// baseURL returns cfg.URL without a trailing slash, or fallback when unset.
+func baseURL(cfg Config, fallback string) string {
+	if cfg.URL == "" {
+		return fallback
+	}
+	return strings.TrimSuffix(cfg.URL, "/")
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// prPrompt asks for a pull request title and Markdown description.
+const prPrompt = "Write a pull request for the changes below, which merge branch '{{.Branch}}' into '{{.Base}}'. On the first line, write a concise title of at most 72 characters with no markdown. Then a blank line, then a Markdown description with these sections:\n\n## Summary\nOne or two sentences on what the change does and why.\n\n## Changes\nA bullet list of the notable changes.\n\n## Testing\nHow the change can be verified, based on the tests and code in the diff.\n\n{{.LanguageInstructions}}Do not include any preamble or explanation outside the pull request itself.\n\n" + systemSeparator + "Commits:\n{{range .Commits}}- {{.}}\n{{end}}\n" + changesSection
+
+// prData is what prPrompt is executed against.
+type prData struct {
+	*promptData
+	// Base is the branch the pull request targets.
+	Base string
+	// Commits are the subjects of the commits on the branch, oldest first.
+	Commits []string
+}
+
+// defaultBaseBranch guesses the branch pull requests target: the remote's
+// default branch if known, otherwise a local main or master.
+func defaultBaseBranch() (string, error) {
+	if ref, err := runGit("symbolic-ref", "--quiet", "--short", "refs/remotes/origin/HEAD"); err == nil {
+		return strings.TrimSpace(ref), nil
+	}
+	for _, branch := range []string{"main", "master"} {
+		if _, err := runGit("rev-parse", "--verify", "--quiet", branch); err == nil {
+			return branch, nil
+		}
+	}
+	return "", fmt.Errorf("could not find a base branch; pass one, e.g. `git-commit-message pr main`")
+}
+
+// rangeSubjects returns the non-merge commit subjects in revRange, oldest first.
+func rangeSubjects(revRange string) ([]string, error) {
+	output, err := runGit("log", "--reverse", "--no-merges", "--format=%s", revRange)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(output) == "" {
+		return nil, nil
+	}
+	return strings.Split(strings.TrimSpace(output), "\n"), nil
+}
+
+// splitTitle splits a generated document into its first line, without any
+// heading markers, and the rest.
+func splitTitle(reply string) (string, string) {
+	title, body, _ := strings.Cut(stripOuterFence(reply), "\n")
+	title = strings.TrimSpace(strings.TrimLeft(title, "# "))
+	title = strings.TrimPrefix(title, "Title: ")
+	return strings.Trim(title, messageQuotes+"*"), strings.TrimSpace(body)
+}
+
+// runPR implements `git-commit-message pr [base-branch]`: describe the
+// current branch's changes against the base as a pull request.
+func runPR(args []string) error {
+	flags := flag.NewFlagSet("pr", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message pr [flags] [base-branch]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	title, body, err := describePR(appCtx, config, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Println(title)
+	fmt.Println()
+	fmt.Println(body)
+	return nil
+}
+
+// describePR writes the title and description of a pull request merging
+// the current branch into base, or into defaultBaseBranch if base is "".
+func describePR(ctx context.Context, config *Config, base string) (title, body string, err error) {
+	if base == "" {
+		if base, err = defaultBaseBranch(); err != nil {
+			return "", "", err
+		}
+	} else if _, err := runGit("rev-parse", "--verify", "--quiet", base); err != nil {
+		return "", "", fmt.Errorf("unknown base branch %q", base)
+	}
+
+	commits, err := rangeSubjects(base + "..HEAD")
+	if err != nil {
+		return "", "", err
+	}
+	if len(commits) == 0 {
+		return "", "", fmt.Errorf("no commits between %s and HEAD", base)
+	}
+	// Three dots: only what the branch changed since it forked from base.
+	diff, err := runGit("diff", base+"...HEAD")
+	if err != nil {
+		return "", "", err
+	}
+
+	fmt.Fprintf(os.Stderr, "🤖 Describing %d commit(s) against %s...\n", len(commits), base)
+	diff = prepareDiff(config, diff)
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", "", err
+	}
+	prompt, err := renderPrompt(prPrompt, &prData{
+		promptData: newPromptData(config, diff, summaries),
+		Base:       base,
+		Commits:    commits,
+	})
+	if err != nil {
+		return "", "", err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", "", err
+	}
+	title, body = splitTitle(reply)
+	return title, body, nil
+}
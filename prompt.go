@@ -0,0 +1,320 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// defaultPrompt is used for single-line messages when no template is configured.
+// The prompt is crucial. It instructs the AI to act as an expert and provide a single-line message.
+const defaultPrompt = "Based on the following git diff, generate a concise, single-line git commit message{{if .SubjectLength}} of at most {{.SubjectLength}} characters{{end}} {{.Convention}}. {{.LanguageInstructions}}{{.TypeInstructions}}Do not include any explanation, preamble, or markdown formatting. Just the commit message itself.\n\n{{.StyleExamples}}" + systemSeparator + changesSection
+
+// bodyPrompt is used with --body when no template is configured.
+const bodyPrompt = "Based on the following git diff, generate a git commit message. Start with a concise subject line{{if .SubjectLength}} of at most {{.SubjectLength}} characters{{end}} {{.Convention}}, then a blank line, then a short body in plain prose explaining what changed and why. If appropriate, end with a blank line and footers such as 'BREAKING CHANGE: <description>' or 'Refs: <reference>'. {{.LanguageInstructions}}{{.TypeInstructions}}Do not include any explanation, preamble, or markdown formatting. Just the commit message itself.\n\n{{.StyleExamples}}" + systemSeparator + changesSection
+
+// systemSeparator ends the instructions in a prompt. What comes before it
+// is sent as the system prompt and the rest, the changes themselves, as
+// the user message.
+const systemSeparator = "<<<user>>>\n"
+
+// changesSection ends the built-in prompts. Large diffs are replaced by
+// per-file summaries, see summarizeDiff.
+const changesSection = "{{.Issue}}{{.Overview}}{{.Submodules}}{{if .Summaries}}Summary of the changes per file:\n{{.Summaries}}{{else}}Git Diff:\n```diff\n{{.Diff}}\n```{{end}}"
+
+// maxOverviewFiles caps how many paths {{.Overview}} lists per category.
+const maxOverviewFiles = 50
+
+// recentCommitCount is how many subjects {{.RecentCommits}} returns.
+const recentCommitCount = 10
+
+// conventionalInstructions is the {{.Convention}} text for the default convention.
+const conventionalInstructions = "in the conventional commit format (e.g., 'feat: add user login' or 'fix: resolve race condition')"
+
+// promptData is what prompt templates are executed against. Git-derived
+// values are methods, so git is only asked for what a template actually uses.
+type promptData struct {
+	// Diff is the diff being described. When it is over the token budget
+	// this is a truncated copy and Summaries is set.
+	Diff string
+	// full is the untruncated diff, for the overview.
+	full string
+	// Summaries holds one "- path: summary" line per file for diffs over
+	// the token budget, and is empty otherwise.
+	Summaries string
+	// Convention describes the expected message format, e.g. "in the
+	// conventional commit format (...)".
+	Convention string
+	// SubjectLength is the longest subject line accepted, in columns, or 0.
+	SubjectLength int
+	// Language is the configured message language, e.g. "German", or "".
+	Language string
+	// HistoryExamples is how many recent subjects StyleExamples includes.
+	HistoryExamples int
+	// HistoryBudget caps StyleExamples, in tokens. 0 means no cap.
+	HistoryBudget int
+	// Issue describes the issues the change is for and the merge request
+	// open for the branch, when issue_context is on, and is "" otherwise.
+	Issue string
+	// hint is the commit type the diff settles on its own, if any.
+	hint changeHint
+}
+
+// StyleExamples returns a prompt section listing recent commit subjects so
+// the model picks up the project's tense, casing and scopes, or "" when
+// disabled or there is no history yet.
+func (p *promptData) StyleExamples() string {
+	if p.HistoryExamples <= 0 {
+		return ""
+	}
+	subjects := styleSubjects(p.HistoryExamples)
+	for ; len(subjects) > 0; subjects = subjects[:len(subjects)-1] {
+		section := styleSection(subjects)
+		if p.HistoryBudget <= 0 || estimateTokens(section) <= p.HistoryBudget {
+			return section
+		}
+	}
+	return ""
+}
+
+// styleSection formats subjects as the StyleExamples prompt section.
+func styleSection(subjects []string) string {
+	var b strings.Builder
+	b.WriteString("Recent commit messages in this repository. Match their style (tense, capitalization, scopes, prefixes) where it doesn't conflict with the format above:\n")
+	for _, subject := range subjects {
+		b.WriteString("- " + subject + "\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// LanguageInstructions returns a sentence asking for the configured
+// language, or "" when none is set.
+func (p *promptData) LanguageInstructions() string {
+	if p.Language == "" {
+		return ""
+	}
+	return fmt.Sprintf("Write the message in %s, but keep any type prefix, emoji and footer keys exactly as specified. ", p.Language)
+}
+
+// TypeInstructions returns a sentence asking for the commit type that
+// classifyDiff found and the package scope, or "" when there is neither.
+func (p *promptData) TypeInstructions() string {
+	if p.hint.Type == "" && p.hint.Scope == "" {
+		return ""
+	}
+	return p.hint.instructions()
+}
+
+// Branch returns the current branch name.
+func (p *promptData) Branch() string {
+	return currentBranch()
+}
+
+// Stats returns a `git diff --stat` style summary of the whole diff, even
+// when Diff itself is truncated.
+func (p *promptData) Stats() string {
+	if strings.TrimSpace(p.full) == "" {
+		return ""
+	}
+	stats, err := runGitInput(p.full, "apply", "--stat", "-")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimRight(stats, "\n")
+}
+
+// Files returns the changed paths grouped by what happened to them, one
+// "Added: a, b" line per non-empty category.
+func (p *promptData) Files() string {
+	groups := make(map[string][]string)
+	for _, f := range splitDiff(p.full) {
+		status := fileStatus(f)
+		path := f.Path
+		if from := renameSource(f); from != "" {
+			path = from + " → " + f.Path
+		}
+		groups[status] = append(groups[status], path)
+	}
+	var b strings.Builder
+	for _, status := range []string{"added", "modified", "deleted", "renamed"} {
+		paths := groups[status]
+		if len(paths) == 0 {
+			continue
+		}
+		more := ""
+		if len(paths) > maxOverviewFiles {
+			more = fmt.Sprintf(" and %d more", len(paths)-maxOverviewFiles)
+			paths = paths[:maxOverviewFiles]
+		}
+		fmt.Fprintf(&b, "%s%s: %s%s\n", strings.ToUpper(status[:1]), status[1:], strings.Join(paths, ", "), more)
+	}
+	return b.String()
+}
+
+// Overview returns the diff stat and the categorised file list as a
+// prompt section, so the model sees the whole change even when the diff
+// is truncated. It is "" for an empty diff.
+func (p *promptData) Overview() string {
+	files := p.Files()
+	if files == "" {
+		return ""
+	}
+	overview := "Changed files:\n" + files
+	if stats := p.Stats(); stats != "" {
+		overview += "\nDiff stat:\n" + stats + "\n"
+	}
+	return overview + "\n"
+}
+
+// RecentCommits returns the subjects of the most recent commits on HEAD.
+func (p *promptData) RecentCommits() []string {
+	return recentSubjects(recentCommitCount)
+}
+
+// promptTemplate returns the template source selected by the config.
+func promptTemplate(config *Config) (string, error) {
+	switch {
+	case config.PromptTemplate != "":
+		return config.PromptTemplate, nil
+	case config.PromptTemplateFile != "":
+		path, err := expandHome(config.PromptTemplateFile)
+		if err != nil {
+			return "", err
+		}
+		source, err := os.ReadFile(path)
+		if err != nil {
+			return "", fmt.Errorf("could not read prompt template %s: %w", path, err)
+		}
+		return string(source), nil
+	case config.StructuredOutput:
+		return structuredTemplate(config.Body), nil
+	case config.Body:
+		return bodyPrompt, nil
+	default:
+		return defaultPrompt, nil
+	}
+}
+
+// expandHome replaces a leading ~/ in path with the user's home directory.
+func expandHome(path string) (string, error) {
+	rest, ok := strings.CutPrefix(path, "~/")
+	if !ok {
+		return path, nil
+	}
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", fmt.Errorf("could not get user home directory: %w", err)
+	}
+	return filepath.Join(homeDir, rest), nil
+}
+
+// buildPrompt renders the instructions sent to the model for diff and,
+// for large diffs, its per-file summaries.
+func buildPrompt(config *Config, diff, summaries string) (string, error) {
+	source, err := promptTemplate(config)
+	if err != nil {
+		return "", err
+	}
+	data := newPromptData(config, diff, summaries)
+	// Gitmoji prompts ask for an emoji rather than a type prefix.
+	if config.Convention != conventionGitmoji {
+		data.hint = changeHintFor(config, diff)
+	}
+	if config.IssueContext {
+		data.Issue = issueContext(config)
+	}
+	if config.Jira.URL != "" {
+		data.Issue += jiraContext(config)
+	}
+	return renderPrompt(source, data)
+}
+
+// newPromptData returns the data shared by every prompt for diff.
+func newPromptData(config *Config, diff, summaries string) *promptData {
+	data := &promptData{
+		Diff:            diff,
+		full:            diff,
+		Summaries:       summaries,
+		Convention:      conventionalInstructions,
+		SubjectLength:   config.subjectLimit(),
+		HistoryExamples: config.HistoryExamples,
+		HistoryBudget:   config.historyBudget(),
+	}
+	if overBudget(config, diff) {
+		data.Diff = truncateToTokens(diff, config.diffBudget())
+	}
+	if config.Language != "" {
+		data.Language = languageName(config.Language)
+	}
+	if config.Convention == conventionGitmoji {
+		data.Convention = gitmojiInstructions()
+	}
+	return data
+}
+
+// splitSystem splits prompt at systemSeparator into the system prompt and
+// the user message. Prompts without one are all user message.
+func splitSystem(prompt string) (system, user string) {
+	system, user, ok := strings.Cut(prompt, systemSeparator)
+	if !ok {
+		return "", prompt
+	}
+	return strings.TrimSpace(system), user
+}
+
+// Example is a sample change and the commit message you would write for
+// it. Examples are sent as earlier turns of the conversation to show the
+// model the house style.
+type Example struct {
+	// Diff is the change, usually summarised, e.g. "renamed the retry flag".
+	Diff string `yaml:"diff"`
+	// Message is the commit message for it.
+	Message string `yaml:"message"`
+}
+
+// structured reports whether structured output applies: it is configured
+// and no custom prompt template is set, which wouldn't ask for the JSON.
+func (c *Config) structured() bool {
+	return c.StructuredOutput && c.PromptTemplate == "" && c.PromptTemplateFile == ""
+}
+
+// fewShotExamples returns the configured examples as conversation turns.
+// With structured output, the answers are given as JSON too.
+func (c *Config) fewShotExamples() []provider.Example {
+	var examples []provider.Example
+	for _, example := range c.Examples {
+		output := example.Message
+		if c.structured() {
+			output = structuredExample(output)
+		}
+		examples = append(examples, provider.Example{Input: example.Diff, Output: output})
+	}
+	return examples
+}
+
+// examplesText joins examples for estimating their size.
+func examplesText(examples []provider.Example) string {
+	var b strings.Builder
+	for _, example := range examples {
+		b.WriteString(example.Input + "\n" + example.Output + "\n")
+	}
+	return b.String()
+}
+
+// renderPrompt executes the template source against data.
+func renderPrompt(source string, data any) (string, error) {
+	tmpl, err := template.New("prompt").Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("could not parse prompt template: %w", err)
+	}
+	var prompt strings.Builder
+	if err := tmpl.Execute(&prompt, data); err != nil {
+		return "", fmt.Errorf("could not render prompt template: %w", err)
+	}
+	return prompt.String(), nil
+}
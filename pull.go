@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// pulls remembers the outcome of offerPull per model, so concurrent
+// requests (candidates, summaries) ask and pull only once.
+var pulls = struct {
+	sync.Mutex
+	done map[string]bool
+}{done: make(map[string]bool)}
+
+// offerPull pulls the configured Ollama model after it turned out to be
+// missing: straight away with auto_pull, after asking when stdin is a
+// terminal, and not at all otherwise. It reports whether the model was pulled.
+func offerPull(ctx context.Context, config *Config) (bool, error) {
+	pc := config.providerConfig()
+	if name := strings.ToLower(pc.Provider); name != "" && name != "ollama" {
+		return false, nil
+	}
+	pulls.Lock()
+	defer pulls.Unlock()
+	if pulled, asked := pulls.done[pc.Model]; asked {
+		return pulled, nil
+	}
+	pulls.done[pc.Model] = false
+
+	if !config.AutoPull {
+		if !isTerminal(os.Stdin) {
+			return false, nil
+		}
+		fmt.Fprintf(os.Stderr, "⚠️  The model %s is not installed on Ollama. Pull it now? [Y/n] ", pc.Model)
+		answer, err := readLine()
+		if err != nil {
+			return false, err
+		}
+		if a := strings.ToLower(answer); a != "" && a != "y" && a != "yes" {
+			return false, nil
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "⬇️  Pulling %s...\n", pc.Model)
+	// Download progress is redrawn in place; other statuses get a line each.
+	status, inline := "", false
+	err := provider.PullOllamaModel(ctx, pc, func(p provider.PullProgress) {
+		switch {
+		case p.Total > 0:
+			if inline && p.Status != status {
+				fmt.Fprintln(os.Stderr)
+			}
+			fmt.Fprintf(os.Stderr, "\r   %s: %3d%% of %s", p.Status, p.Completed*100/p.Total, formatBytes(p.Total))
+			inline = true
+		case p.Status != status:
+			if inline {
+				fmt.Fprintln(os.Stderr)
+			}
+			fmt.Fprintf(os.Stderr, "   %s\n", p.Status)
+			inline = false
+		}
+		status = p.Status
+	})
+	if inline {
+		fmt.Fprintln(os.Stderr)
+	}
+	if err != nil {
+		return false, err
+	}
+	pulls.done[pc.Model] = true
+	return true, nil
+}
+
+// formatBytes formats n as a human readable size, e.g. "4.7 GB".
+func formatBytes(n int64) string {
+	const unit = 1000
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(n)/float64(div), "kMGTPE"[exp])
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretRule finds one kind of secret. When the pattern has a capture group
+// named "secret", only that group is masked, so e.g. `password = "..."`
+// keeps its key.
+type secretRule struct {
+	Kind    string
+	Pattern *regexp.Regexp
+}
+
+// secretRules are applied to every diff before it leaves the machine.
+var secretRules = []secretRule{
+	{"private-key", regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(?:-----END [A-Z ]*PRIVATE KEY-----|$)`)},
+	{"aws-access-key", regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{"github-token", regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`)},
+	{"gitlab-token", regexp.MustCompile(`\bglpat-[A-Za-z0-9_-]{20,}\b`)},
+	{"slack-token", regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}\b`)},
+	{"anthropic-key", regexp.MustCompile(`\bsk-ant-[A-Za-z0-9_-]{20,}\b`)},
+	{"openai-key", regexp.MustCompile(`\bsk-(?:proj-)?[A-Za-z0-9_-]{20,}\b`)},
+	{"google-api-key", regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`)},
+	{"stripe-key", regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{20,}\b`)},
+	{"jwt", regexp.MustCompile(`\beyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}\b`)},
+	{"bearer-token", regexp.MustCompile(`(?i)\bbearer\s+(?P<secret>[A-Za-z0-9._~+/=-]{16,})`)},
+	{"url-credentials", regexp.MustCompile(`[a-z][a-z0-9+.-]*://[^/\s:@]+:(?P<secret>[^/\s@]+)@`)},
+	{"assignment", regexp.MustCompile(`(?i)(?:password|passwd|pwd|secret|token|api[_-]?key|access[_-]?key|private[_-]?key|client[_-]?secret|auth)[A-Za-z0-9_-]*["']?\s*[:=]\s*["']?(?P<secret>[^\s"',;]{6,})`)},
+}
+
+// authSchemes are HTTP auth scheme names that can look like an assigned secret.
+var authSchemes = map[string]bool{"bearer": true, "basic": true, "digest": true, "token": true}
+
+// envAssignment matches KEY=value lines in .env files, whose values are
+// all treated as secrets.
+var envAssignment = regexp.MustCompile(`(?m)^([+ -]\s*(?:export\s+)?[A-Za-z_][A-Za-z0-9_]*\s*=\s*)(.+)$`)
+
+// redaction records what was masked, for the report.
+type redaction struct {
+	Kind string
+	Path string
+}
+
+// redactedValue is what a masked secret is replaced with.
+func redactedValue(kind string) string {
+	return "[REDACTED:" + kind + "]"
+}
+
+// isEnvFile reports whether p is a dotenv-style file.
+func isEnvFile(p string) bool {
+	base := path.Base(p)
+	return base == ".env" || strings.HasPrefix(base, ".env.") || strings.HasSuffix(base, ".env")
+}
+
+// redactSecrets masks credentials in diff and reports what it masked.
+func redactSecrets(diff string) (string, []redaction) {
+	files := splitDiff(diff)
+	if len(files) == 0 {
+		files = []fileDiff{{Text: diff}}
+	}
+
+	var found []redaction
+	for i, f := range files {
+		text := f.Text
+		for _, rule := range secretRules {
+			secretIndex := rule.Pattern.SubexpIndex("secret")
+			text = rule.Pattern.ReplaceAllStringFunc(text, func(match string) string {
+				// Don't report our own placeholders from an earlier rule.
+				if strings.Contains(match, "[REDACTED:") {
+					return match
+				}
+				if secretIndex < 0 {
+					found = append(found, redaction{Kind: rule.Kind, Path: f.Path})
+					return redactedValue(rule.Kind)
+				}
+				m := rule.Pattern.FindStringSubmatchIndex(match)
+				start, end := m[2*secretIndex], m[2*secretIndex+1]
+				// `auth = "Bearer ..."`: the scheme isn't the secret, the
+				// bearer-token rule handles the value.
+				if authSchemes[strings.ToLower(match[start:end])] {
+					return match
+				}
+				found = append(found, redaction{Kind: rule.Kind, Path: f.Path})
+				return match[:start] + redactedValue(rule.Kind) + match[end:]
+			})
+		}
+		if isEnvFile(f.Path) {
+			text = envAssignment.ReplaceAllStringFunc(text, func(line string) string {
+				m := envAssignment.FindStringSubmatch(line)
+				if strings.Contains(m[2], "[REDACTED:") {
+					return line
+				}
+				found = append(found, redaction{Kind: "env-value", Path: f.Path})
+				return m[1] + redactedValue("env-value")
+			})
+		}
+		files[i].Text = text
+	}
+	return joinDiff(files), found
+}
+
+// redactionReport summarises redactions as "2 openai-key in app.py, ...".
+func redactionReport(found []redaction) string {
+	counts := make(map[redaction]int)
+	for _, r := range found {
+		counts[r]++
+	}
+	var parts []string
+	for r, n := range counts {
+		where := r.Path
+		if where == "" {
+			where = "diff"
+		}
+		parts = append(parts, fmt.Sprintf("%d %s in %s", n, r.Kind, where))
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
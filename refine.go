@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/miteshbsjat/git-commit-message/pkg/provider"
+)
+
+// feedbackPrompt is the user turn asking for a revised message.
+const feedbackPrompt = "Revise the commit message following this feedback: %s\n\nKeep the same format and describe the same diff. Reply with only the revised commit message."
+
+// historyKey is the context key for withHistory.
+type historyKey struct{}
+
+// withHistory returns a context whose commit message requests continue
+// the conversation in turns, sent after the configured examples.
+func withHistory(ctx context.Context, turns []provider.Example) context.Context {
+	return context.WithValue(ctx, historyKey{}, turns)
+}
+
+// historyFrom returns the turns set by withHistory, or nil.
+func historyFrom(ctx context.Context) []provider.Example {
+	turns, _ := ctx.Value(historyKey{}).([]provider.Example)
+	return turns
+}
+
+// refinement is a conversation in which the user asks the model to revise
+// its message. Each revision sees the diff, the earlier attempts and the
+// feedback on them, rather than starting from scratch.
+type refinement struct {
+	config *Config
+	diff   string
+	hint   changeHint
+	// system is the instructions of the original prompt, built on the
+	// first revision.
+	system string
+	// turns are the earlier requests, each answered by the message shown
+	// for it. pending is the request the current message answers.
+	turns   []provider.Example
+	pending string
+}
+
+// newRefinement starts a conversation about the message for diff.
+func newRefinement(config *Config, diff string) *refinement {
+	return &refinement{config: config, diff: diff, hint: changeHintFor(config, diff)}
+}
+
+// revise asks the model to change message as feedback says, and returns
+// the revision, cleaned up and decorated like any other suggestion.
+func (r *refinement) revise(ctx context.Context, message, feedback string) (string, error) {
+	if r.pending == "" {
+		prompt, err := preparePrompt(ctx, r.config, r.diff)
+		if err != nil {
+			return "", err
+		}
+		r.system, r.pending = splitSystem(prompt)
+	}
+	request := fmt.Sprintf(feedbackPrompt, feedback)
+	turns := append(r.turns, provider.Example{Input: r.pending, Output: message})
+	revised, err := suggestFromPrompt(withHistory(ctx, turns), r.config, r.diff, r.system+"\n"+systemSeparator+request, r.hint)
+	if err != nil {
+		return "", err
+	}
+	r.turns, r.pending = turns, request
+	return revised, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// releaseNotesPrompt asks for user-facing release notes. Unlike the
+// changelog, it is written for people upgrading, not for contributors.
+const releaseNotesPrompt = "Write release notes in Markdown for version {{.Version}} of this project, for the people who use it rather than the people who develop it. Use these sections, leaving out any that would be empty:\n\n## Highlights\nA few bullets on the most important new features and improvements, explained in terms of what users can now do.\n\n## Breaking Changes\nEvery change that can break existing users, with what they need to change.\n\n## Upgrade Notes\nAnything else users should do or know when upgrading, such as new configuration or deprecations.\n\nLeave out refactoring, tests, CI and other internal changes. {{.LanguageInstructions}}Do not include any preamble or explanation outside the release notes.\n\n" + systemSeparator + "Commits since {{.Previous}}:\n{{range .Commits}}- {{.}}\n{{end}}\n" + changesSection
+
+// releaseNotesData is what releaseNotesPrompt is executed against.
+type releaseNotesData struct {
+	*promptData
+	// Version is the tag being released.
+	Version string
+	// Previous is the previous tag, or "the first commit".
+	Previous string
+	// Commits lists the subjects, with the body included for breaking changes.
+	Commits []string
+}
+
+// previousTag returns the most recent tag reachable from rev's parent, or
+// "" when there is none.
+func previousTag(rev string) string {
+	tag, err := runGit("describe", "--tags", "--abbrev=0", rev+"^")
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(tag)
+}
+
+// emptyTree returns the object name of the empty tree, for diffing the
+// whole history of a project that has no earlier tag.
+func emptyTree() (string, error) {
+	tree, err := runGitInput("", "hash-object", "-t", "tree", "--stdin")
+	return strings.TrimSpace(tree), err
+}
+
+// releaseRange works out what a release of tag covers. An existing tag
+// is described from the previous tag; a tag that doesn't exist yet
+// describes HEAD since the latest tag.
+func releaseRange(tag string) (from, to string) {
+	to = tag
+	if _, err := runGit("rev-parse", "--verify", "--quiet", tag+"^{commit}"); err != nil {
+		to = "HEAD"
+		if latest, err := runGit("describe", "--tags", "--abbrev=0", "HEAD"); err == nil {
+			return strings.TrimSpace(latest), to
+		}
+		return "", to
+	}
+	return previousTag(tag), to
+}
+
+// runReleaseNotes implements `git-commit-message release-notes <tag>`.
+func runReleaseNotes(args []string) error {
+	flags := flag.NewFlagSet("release-notes", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message release-notes [flags] <tag>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	tag := flags.Arg(0)
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+
+	from, to := releaseRange(tag)
+	revRange, previous, base := to, "the first commit", from
+	if from != "" {
+		revRange = from + ".." + to
+		previous = from
+	} else if base, err = emptyTree(); err != nil {
+		return err
+	}
+
+	commits, err := rangeCommits(revRange)
+	if err != nil {
+		return err
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits between %s and %s", previous, to)
+	}
+	var lines []string
+	for i := len(commits) - 1; i >= 0; i-- {
+		line := commits[i].Subject
+		if isBreaking(commits[i].Subject, commits[i].Body) && commits[i].Body != "" {
+			line += "\n  " + strings.ReplaceAll(commits[i].Body, "\n", "\n  ")
+		}
+		lines = append(lines, line)
+	}
+	diff, err := runGit("diff", base, to)
+	if err != nil {
+		return err
+	}
+
+	ctx := appCtx
+	fmt.Fprintf(os.Stderr, "🤖 Writing release notes for %s (%d commits since %s)...\n", tag, len(commits), previous)
+	diff = prepareDiff(config, diff)
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return err
+	}
+	prompt, err := renderPrompt(releaseNotesPrompt, &releaseNotesData{
+		promptData: newPromptData(config, diff, summaries),
+		Version:    tag,
+		Previous:   previous,
+		Commits:    lines,
+	})
+	if err != nil {
+		return err
+	}
+	notes, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return err
+	}
+	fmt.Println(stripOuterFence(notes))
+	return nil
+}
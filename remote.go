@@ -0,0 +1,85 @@
+package main
+
+import (
+	"net/url"
+	"strings"
+)
+
+// Supported values for Config.IssuePlatform.
+const (
+	platformGitHub    = "github"
+	platformGitLab    = "gitlab"
+	platformBitbucket = "bitbucket"
+)
+
+// platformJira names Jira's keyring entry. It is not an IssuePlatform,
+// since Jira tickets come from the branch rather than a remote.
+const platformJira = "jira"
+
+// defaultIssueRemote is the remote whose host decides the issue platform.
+const defaultIssueRemote = "origin"
+
+// remoteRepo is the hosting of a git remote.
+type remoteRepo struct {
+	// Host is e.g. "github.com".
+	Host string
+	// Path is the repository path without ".git", e.g. "owner/repo".
+	Path string
+}
+
+// parseRemoteURL parses the URL of a git remote: scp-like SSH addresses
+// ("git@host:owner/repo.git") as well as ssh://, git:// and http(s):// URLs.
+func parseRemoteURL(raw string) (remoteRepo, bool) {
+	raw = strings.TrimSpace(raw)
+	var host, path string
+	if u, err := url.Parse(raw); err == nil && u.Scheme != "" && u.Host != "" {
+		host, path = u.Hostname(), u.Path
+	} else if at, rest, ok := strings.Cut(raw, ":"); ok && !strings.Contains(at, "/") {
+		_, host, _ = strings.Cut(at, "@")
+		if host == "" {
+			host = at
+		}
+		path = rest
+	} else {
+		return remoteRepo{}, false
+	}
+	path = strings.TrimSuffix(strings.Trim(path, "/"), ".git")
+	if host == "" || !strings.Contains(path, "/") {
+		return remoteRepo{}, false
+	}
+	return remoteRepo{Host: strings.ToLower(host), Path: path}, true
+}
+
+// Platform guesses the hosting platform from the host name, or "" when
+// it isn't one of the known ones.
+func (r remoteRepo) Platform() string {
+	for _, platform := range []string{platformGitHub, platformGitLab, platformBitbucket} {
+		if strings.Contains(r.Host, platform) {
+			return platform
+		}
+	}
+	return ""
+}
+
+// issueRemote returns the repository of the configured issue remote.
+func issueRemote(config *Config) (remoteRepo, bool) {
+	name := config.IssueRemote
+	if name == "" {
+		name = defaultIssueRemote
+	}
+	raw, err := runGit("remote", "get-url", name)
+	if err != nil {
+		return remoteRepo{}, false
+	}
+	return parseRemoteURL(raw)
+}
+
+// issuePlatform returns the configured issue platform or, when none is
+// set, the one the issue remote is hosted on.
+func issuePlatform(config *Config) string {
+	if config.IssuePlatform != "" {
+		return config.IssuePlatform
+	}
+	repo, _ := issueRemote(config)
+	return repo.Platform()
+}
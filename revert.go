@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// revertPrompt asks for the body of a commit that reverts another; the
+// subject and the Refs footer are added without the model.
+const revertPrompt = "A developer is reverting the git commit shown below. Write the body of the revert's commit message in plain prose: what the reverted commit did and what changes back now that it is undone, based on its message and diff.{{if .Reason}} The developer gave this reason for the revert, so explain it: {{.Reason}}{{else}} No reason was given, so don't invent one.{{end}} Do not write a subject line or footers. {{.LanguageInstructions}}Do not include any explanation, preamble, or markdown formatting. Just the body itself.\n\n" + systemSeparator + "Reverted commit {{.SHA}}:\n```\n{{.Original}}\n```\n\n" + changesSection
+
+// revertData is what revertPrompt is executed against.
+type revertData struct {
+	*promptData
+	// SHA is the full name of the reverted commit.
+	SHA string
+	// Original is the reverted commit's full message.
+	Original string
+	// Reason is why the commit is reverted, from --reason, or "".
+	Reason string
+}
+
+// runRevert implements `git-commit-message revert <commit>`: a message for
+// reverting commit in the conventional `revert:` format, explaining what
+// is undone, with a Refs footer naming the original. With --commit it runs
+// `git revert` with it.
+func runRevert(args []string) error {
+	flags := flag.NewFlagSet("revert", flag.ExitOnError)
+	reason := flags.String("reason", "", "why the commit is being reverted, to explain in the message")
+	commit := flags.Bool("commit", false, "revert the commit with `git revert` and the generated message")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message revert [flags] <commit>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	sha, err := runGit("rev-parse", "--verify", "--quiet", flags.Arg(0)+"^{commit}")
+	if err != nil {
+		return fmt.Errorf("unknown commit %q", flags.Arg(0))
+	}
+	message, err := revertMessage(appCtx, config, strings.TrimSpace(sha), *reason)
+	if err != nil {
+		return err
+	}
+	if !*commit {
+		fmt.Println(message)
+		return nil
+	}
+	// --no-commit stages the revert so it is committed with our message
+	// instead of git's "Revert ..." one.
+	cmd := exec.Command("git", "revert", "--no-edit", "--no-commit", strings.TrimSpace(sha))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to execute 'git revert': %w", err)
+	}
+	return gitCommit(message, diffStaged)
+}
+
+// revertMessage writes the message for reverting the commit sha.
+func revertMessage(ctx context.Context, config *Config, sha, reason string) (string, error) {
+	original, err := runGit("log", "-1", "--format=%B", sha)
+	if err != nil {
+		return "", err
+	}
+	original = strings.TrimSpace(original)
+	subject, _, _ := strings.Cut(original, "\n")
+	diff, err := commitDiff(sha)
+	if err != nil {
+		return "", err
+	}
+	diff = prepareDiff(config, diff)
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	prompt, err := renderPrompt(revertPrompt, &revertData{
+		promptData: newPromptData(config, diff, summaries),
+		SHA:        sha,
+		Original:   original,
+		Reason:     reason,
+	})
+	if err != nil {
+		return "", err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	message := cleanMultilineMessage("revert: " + subject + "\n\n" + config.runCleaners(reply))
+	return finishMessage(config, addFooter(message, "Refs", sha))
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// reviewMessagePrompt asks the model to critique an existing commit message.
+const reviewMessagePrompt = "You are reviewing a git commit message for quality. Compare it with the diff it describes and judge whether it is accurate, complete and specific, explains why the change was made, and has a subject line {{.Convention}}.\n\nReply in exactly this format:\nScore: <number from 1 to 10>/10\nSuggestions:\n- <concrete improvement>\nImproved message:\n<a better commit message>\n\n{{.LanguageInstructions}}\n\n" + systemSeparator + "{{if .Problems}}An automatic check already found these problems:\n{{range .Problems}}- {{.}}\n{{end}}\n{{end}}Commit message:\n```\n{{.Message}}\n```\n\n" + changesSection
+
+// reviewMessageData is what reviewMessagePrompt is executed against.
+type reviewMessageData struct {
+	*promptData
+	// Message is the commit message under review.
+	Message string
+	// Problems are the lint violations found in Message.
+	Problems []string
+}
+
+// reviewCommitMessage asks the model to critique commit's message against its diff.
+func reviewCommitMessage(ctx context.Context, config *Config, commit logCommit) (string, error) {
+	diff, err := commitDiff(commit.SHA)
+	if err != nil {
+		return "", err
+	}
+	diff = prepareDiff(config, diff)
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	message := commit.Subject
+	if commit.Body != "" {
+		message += "\n\n" + commit.Body
+	}
+	prompt, err := renderPrompt(reviewMessagePrompt, &reviewMessageData{
+		promptData: newPromptData(config, diff, summaries),
+		Message:    message,
+		Problems:   lintMessage(config, message),
+	})
+	if err != nil {
+		return "", err
+	}
+	review, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	return stripOuterFence(review), nil
+}
+
+// runReviewMessage implements `git-commit-message review-message <sha|rev-range>`.
+func runReviewMessage(args []string) error {
+	flags := flag.NewFlagSet("review-message", flag.ExitOnError)
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message review-message [flags] <sha|rev-range>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	target := flags.Arg(0)
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	revs := []string{target}
+	if !strings.Contains(target, "..") {
+		revs = []string{"-1", target}
+	}
+	commits, err := rangeCommits(revs...)
+	if err != nil {
+		return fmt.Errorf("invalid revision %q: %w", target, err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits in %s", target)
+	}
+
+	ctx := appCtx
+	for i, commit := range commits {
+		fmt.Fprintf(os.Stderr, "🤖 Reviewing %s...\n", commit.SHA[:7])
+		review, err := reviewCommitMessage(ctx, config, commit)
+		if err != nil {
+			return fmt.Errorf("could not review %s: %w", commit.SHA[:7], err)
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("🔎 %s %s\n\n%s\n", commit.SHA[:7], commit.Subject, review)
+	}
+	return nil
+}
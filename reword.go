@@ -0,0 +1,189 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// rewordCommit is one commit of a reword run with its old and new message.
+type rewordCommit struct {
+	SHA     string
+	Subject string
+	Message string
+}
+
+// rewordRange lists the commits in revRange, oldest first, and the base to
+// rebase onto ("" for the root). The range must be a linear run of
+// commits ending at HEAD, since that is what `git rebase -i` can rewrite.
+func rewordRange(revRange string) ([]string, string, error) {
+	output, err := runGit("rev-list", "--reverse", revRange)
+	if err != nil {
+		return nil, "", fmt.Errorf("invalid revision range %q: %w", revRange, err)
+	}
+	commits := strings.Fields(output)
+	if len(commits) == 0 {
+		return nil, "", fmt.Errorf("no commits in %s", revRange)
+	}
+	if merges, _ := runGit("rev-list", "--merges", revRange); strings.TrimSpace(merges) != "" {
+		return nil, "", fmt.Errorf("%s contains merge commits, which cannot be reworded", revRange)
+	}
+
+	base := ""
+	onto := []string{"rev-list", "--reverse", "HEAD"}
+	if parent, err := runGit("rev-parse", "--verify", "--quiet", commits[0]+"~1"); err == nil {
+		base = strings.TrimSpace(parent)
+		onto = []string{"rev-list", "--reverse", base + "..HEAD"}
+	}
+	output, err = runGit(onto...)
+	if err != nil {
+		return nil, "", err
+	}
+	if !slices.Equal(strings.Fields(output), commits) {
+		return nil, "", fmt.Errorf("%s must be a linear run of commits ending at HEAD", revRange)
+	}
+	return commits, base, nil
+}
+
+// rebaseTodo returns a `git rebase -i` todo list that replays each commit
+// and then replaces its message with the one in files[i].
+func rebaseTodo(commits []rewordCommit, files []string) string {
+	var b strings.Builder
+	for i, commit := range commits {
+		fmt.Fprintf(&b, "pick %s %s\n", commit.SHA, commit.Subject)
+		fmt.Fprintf(&b, "exec git commit --amend --only --no-verify --allow-empty -F %s\n", shellQuote(files[i]))
+	}
+	return b.String()
+}
+
+// rebaseInProgress reports whether a `git rebase -i` has stopped and is
+// waiting for `git rebase --continue` or `--abort`.
+func rebaseInProgress() bool {
+	path, err := runGit("rev-parse", "--git-path", "rebase-merge")
+	if err != nil {
+		return false
+	}
+	_, err = os.Stat(strings.TrimSpace(path))
+	return err == nil
+}
+
+// applyReword rewrites the commits with `git rebase -i`, supplying the
+// todo list through GIT_SEQUENCE_EDITOR instead of opening an editor. If
+// the rebase stops, the message files are kept, since the rest of the todo
+// list still reads them.
+func applyReword(commits []rewordCommit, base string) error {
+	dir, err := os.MkdirTemp("", "git-commit-message-reword-")
+	if err != nil {
+		return err
+	}
+	keep := false
+	defer func() {
+		if !keep {
+			os.RemoveAll(dir)
+		}
+	}()
+
+	files := make([]string, len(commits))
+	for i, commit := range commits {
+		files[i] = filepath.Join(dir, fmt.Sprintf("msg-%d", i))
+		if err := os.WriteFile(files[i], []byte(commit.Message+"\n"), 0o600); err != nil {
+			return err
+		}
+	}
+	todo := filepath.Join(dir, "todo")
+	if err := os.WriteFile(todo, []byte(rebaseTodo(commits, files)), 0o600); err != nil {
+		return err
+	}
+
+	args := []string{"rebase", "-i"}
+	if base == "" {
+		args = append(args, "--root")
+	} else {
+		args = append(args, base)
+	}
+	cmd := exec.Command("git", args...)
+	cmd.Env = append(os.Environ(), "GIT_SEQUENCE_EDITOR=cp "+shellQuote(todo))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err = cmd.Run()
+	if keep = rebaseInProgress(); keep {
+		fmt.Fprintf(os.Stderr, "⚠️  The rebase stopped; the new messages are kept in %s for `git rebase --continue`. Remove it once the rebase is done or aborted.\n", dir)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to execute 'git rebase': %w", err)
+	}
+	return nil
+}
+
+// runReword implements `git-commit-message reword <rev-range>`: generate a
+// new message for every commit in the range from its own diff, preview
+// them and rewrite the history once confirmed.
+func runReword(args []string) error {
+	flags := flag.NewFlagSet("reword", flag.ExitOnError)
+	yes := flags.Bool("yes", false, "rewrite without asking for confirmation")
+	dryRun := flags.Bool("dry-run", false, "only show the preview, do not rewrite anything")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message reword [flags] <rev-range>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	shas, base, err := rewordRange(flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	if dirty, _ := runGit("status", "--porcelain", "--untracked-files=no"); strings.TrimSpace(dirty) != "" && !*dryRun {
+		return fmt.Errorf("the working tree has uncommitted changes; commit or stash them first")
+	}
+
+	ctx := appCtx
+	commits := make([]rewordCommit, len(shas))
+	for i, sha := range shas {
+		subject, err := runGit("log", "-1", "--format=%s", sha)
+		if err != nil {
+			return err
+		}
+		diff, err := commitDiff(sha)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(os.Stderr, "🤖 [%d/%d] %s %s\n", i+1, len(shas), sha[:7], strings.TrimSpace(subject))
+		message, err := cachedSuggestion(ctx, config, prepareDiff(config, diff))
+		if err != nil {
+			return fmt.Errorf("could not generate a message for %s: %w", sha[:7], err)
+		}
+		commits[i] = rewordCommit{SHA: sha, Subject: strings.TrimSpace(subject), Message: message}
+	}
+
+	fmt.Println("\n📝 Proposed messages:")
+	for _, commit := range commits {
+		fmt.Printf("  %s  %s\n           → %s\n", commit.SHA[:7], commit.Subject, subjectLine(commit.Message))
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes {
+		ok, err := confirm(fmt.Sprintf("\nRewrite %d commit(s)?", len(commits)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Reword aborted.")
+			return nil
+		}
+	}
+	return applyReword(commits, base)
+}
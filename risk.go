@@ -0,0 +1,265 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// riskLevels are the ratings `risk` gives, least risky first.
+var riskLevels = []string{"low", "medium", "high"}
+
+// Changes with more added and removed lines than these are rated at least
+// medium and high risk.
+const (
+	riskMediumLines = 300
+	riskHighLines   = 1000
+)
+
+// defaultRiskAreas are glob patterns, by area, of the files where a change
+// is riskier than its size suggests. risk_areas adds to or replaces them.
+var defaultRiskAreas = map[string][]string{
+	"migrations": {"**/migrations/**", "**/migrate/**", "**/alembic/**", "*.sql", "db/schema.rb", "**/liquibase/**", "**/flyway/**"},
+	"auth":       {"**/auth/**", "**/security/**", "*auth*", "*login*", "*session*", "*permission*", "*password*", "*oauth*", "*rbac*"},
+	"infra": {"Dockerfile*", "docker-compose*", "*.tf", "*.tfvars", "**/terraform/**", "**/k8s/**", "**/kubernetes/**", "**/helm/**",
+		"**/ansible/**", ".github/workflows/**", ".gitlab-ci.yml", "Jenkinsfile", ".circleci/**", "**/deploy/**"},
+}
+
+// riskPrompt asks the model to rate a change, given what measureRisk found.
+const riskPrompt = "Rate how risky it is to ship the {{.Target}} below: how likely they are to break something for users and how hard that would be to undo. Judge what the code does, not only its size: data migrations, authentication and permissions, infrastructure and deployment, concurrency, public APIs and anything irreversible are riskier than tests, docs or isolated additions. {{.LanguageInstructions}}Reply with only a JSON object with these keys:\n- \"level\": one of low, medium, high\n- \"reasons\": one to three short reasons for the rating\n\n" + systemSeparator + "{{if .Signals}}Automatic checks found:\n{{range .Signals}}- {{.}}\n{{end}}\n{{end}}" + changesSection
+
+// riskData is what riskPrompt is executed against.
+type riskData struct {
+	*promptData
+	// Target describes the diff, e.g. "staged changes".
+	Target string
+	// Signals are measureRisk's reasons.
+	Signals []string
+}
+
+// riskAssessment is a change's rating and the reasons for it. It is also
+// the JSON object riskPrompt asks for.
+type riskAssessment struct {
+	Level   string   `json:"level"`
+	Reasons []string `json:"reasons"`
+}
+
+// riskArea is a sensitive area a diff touches and the files in it.
+type riskArea struct {
+	Name  string
+	Paths []string
+}
+
+// riskSignals is what measureRisk finds in a diff without the model.
+type riskSignals struct {
+	Areas []riskArea
+	Files int
+	// Lines is the number of added and removed lines.
+	Lines int
+}
+
+// riskAreas returns the default risk areas with the configured ones
+// merged in. An area configured with no patterns is turned off.
+func (c *Config) riskAreas() map[string][]string {
+	areas := maps.Clone(defaultRiskAreas)
+	for name, patterns := range c.RiskAreas {
+		if len(patterns) == 0 {
+			delete(areas, name)
+			continue
+		}
+		areas[name] = patterns
+	}
+	return areas
+}
+
+// measureRisk finds the risk areas diff touches and how big it is.
+func measureRisk(config *Config, diff string) riskSignals {
+	var s riskSignals
+	areas := config.riskAreas()
+	touched := make(map[string][]string)
+	for _, f := range splitDiff(diff) {
+		s.Files++
+		for _, line := range strings.Split(f.Text, "\n") {
+			if (strings.HasPrefix(line, "+") || strings.HasPrefix(line, "-")) &&
+				!strings.HasPrefix(line, "+++ ") && !strings.HasPrefix(line, "--- ") {
+				s.Lines++
+			}
+		}
+		for name, patterns := range areas {
+			if matchesAny(patterns, f.Path) {
+				touched[name] = append(touched[name], f.Path)
+			}
+		}
+	}
+	for _, name := range slices.Sorted(maps.Keys(touched)) {
+		s.Areas = append(s.Areas, riskArea{Name: name, Paths: touched[name]})
+	}
+	return s
+}
+
+// level rates the signals alone: touching a risk area or changing more
+// than riskMediumLines lines is medium, and touching several areas or
+// changing more than riskHighLines lines is high.
+func (s riskSignals) level() string {
+	switch {
+	case len(s.Areas) > 1 || s.Lines > riskHighLines:
+		return "high"
+	case len(s.Areas) == 1 || s.Lines > riskMediumLines:
+		return "medium"
+	}
+	return "low"
+}
+
+// reasons describes the signals for the prompt and the report.
+func (s riskSignals) reasons() []string {
+	var reasons []string
+	for _, area := range s.Areas {
+		paths := area.Paths
+		if len(paths) > 3 {
+			paths = append(slices.Clip(paths[:3]), fmt.Sprintf("%d more", len(area.Paths)-3))
+		}
+		reasons = append(reasons, fmt.Sprintf("touches %s: %s", area.Name, strings.Join(paths, ", ")))
+	}
+	if s.Lines > riskMediumLines {
+		reasons = append(reasons, fmt.Sprintf("large change: %d lines across %d files", s.Lines, s.Files))
+	}
+	return reasons
+}
+
+// riskSchema is the JSON schema of riskAssessment, for providers that can
+// constrain their output to one.
+func riskSchema() json.RawMessage {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"level":   map[string]any{"type": "string", "enum": riskLevels},
+			"reasons": map[string]any{"type": "array", "items": map[string]any{"type": "string"}},
+		},
+		"required":             []string{"level", "reasons"},
+		"additionalProperties": false,
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+// assessRisk rates diff, described by label, from the areas it touches,
+// its size and the model's judgment. The rating is never lower than the
+// areas and size alone give; if the model's reply can't be read, it is
+// all there is.
+func assessRisk(ctx context.Context, config *Config, diff, label string) (riskAssessment, error) {
+	signals := measureRisk(config, diff)
+	assessment := riskAssessment{Level: signals.level(), Reasons: signals.reasons()}
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return riskAssessment{}, err
+	}
+	prompt, err := renderPrompt(riskPrompt, &riskData{
+		promptData: newPromptData(config, diff, summaries),
+		Target:     label,
+		Signals:    assessment.Reasons,
+	})
+	if err != nil {
+		return riskAssessment{}, err
+	}
+	reply, err := generateJSON(ctx, config, prompt, riskSchema())
+	if err != nil {
+		return riskAssessment{}, err
+	}
+	judged, ok := parseRisk(reply)
+	if !ok {
+		fmt.Fprintln(os.Stderr, "⚠️  Could not read the model's risk rating, so only the touched areas and size are rated")
+		return assessment, nil
+	}
+	if slices.Index(riskLevels, judged.Level) > slices.Index(riskLevels, assessment.Level) {
+		assessment.Level = judged.Level
+	}
+	for _, reason := range judged.Reasons {
+		if reason = strings.TrimSpace(reason); reason != "" {
+			assessment.Reasons = append(assessment.Reasons, reason)
+		}
+	}
+	return assessment, nil
+}
+
+// parseRisk decodes the JSON object in reply. ok is false when there is
+// none or its level isn't one of riskLevels.
+func parseRisk(reply string) (r riskAssessment, ok bool) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return riskAssessment{}, false
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &r); err != nil {
+		return riskAssessment{}, false
+	}
+	r.Level = strings.ToLower(strings.TrimSpace(r.Level))
+	return r, slices.Contains(riskLevels, r.Level)
+}
+
+// withRisk returns config with the level of the Risk trailer for diff
+// filled in, when risk_trailer is on, for addTrailers to add.
+func (c *Config) withRisk(ctx context.Context, diff string) (*Config, error) {
+	if !c.RiskTrailer {
+		return c, nil
+	}
+	assessment, err := assessRisk(ctx, c, diff, "changes")
+	if err != nil {
+		return nil, err
+	}
+	rated := *c
+	rated.riskLevel = assessment.Level
+	return &rated, nil
+}
+
+// runRisk implements `git-commit-message risk [<commit>]`: a low, medium
+// or high rating of the staged changes, a range or a commit, with the
+// reasons for it.
+func runRisk(args []string) error {
+	flags := flag.NewFlagSet("risk", flag.ExitOnError)
+	target := registerDiffTarget(flags)
+	jsonOutput := flags.Bool("json", false, "print the rating and reasons as JSON")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message risk [flags] [<commit>]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	diff, label, err := target.collect(config, flags.Arg(0))
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🤖 Assessing the risk of the %s...\n", label)
+	assessment, err := assessRisk(appCtx, config, diff, label)
+	if err != nil {
+		return err
+	}
+	if *jsonOutput {
+		if assessment.Reasons == nil {
+			assessment.Reasons = []string{}
+		}
+		data, err := json.MarshalIndent(assessment, "", "  ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(data))
+		return nil
+	}
+	fmt.Println("Risk: " + assessment.Level)
+	for _, reason := range assessment.Reasons {
+		fmt.Println("  - " + reason)
+	}
+	return nil
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	// defaultServeAddress keeps the API on this machine unless asked otherwise.
+	defaultServeAddress = "127.0.0.1:8080"
+	// maxServeRequest caps request bodies; diffs are redacted and cut to the
+	// token budget anyway.
+	maxServeRequest = 16 << 20
+	// maxServeCandidates caps "count", since each candidate is a model call.
+	maxServeCandidates = 5
+)
+
+// serveRequest is the body of POST /generate. Options left out keep the
+// server's config.
+type serveRequest struct {
+	// Diff is the unified diff to describe, e.g. `git diff --staged`.
+	Diff       string `json:"diff"`
+	Body       *bool  `json:"body"`
+	Language   string `json:"language"`
+	Convention string `json:"convention"`
+	// Count asks for several candidate messages.
+	Count int `json:"count"`
+}
+
+// serveError is the body of every failed response.
+type serveError struct {
+	Error string `json:"error"`
+}
+
+// apiServer is the HTTP API started by `git-commit-message serve`.
+type apiServer struct {
+	config *Config
+	// token, when set, must be sent as "Authorization: Bearer <token>".
+	token string
+}
+
+// runServe implements `git-commit-message serve`: an HTTP API for editor
+// plugins and web UIs that would otherwise shell out to the binary.
+func runServe(args []string) error {
+	flags := flag.NewFlagSet("serve", flag.ExitOnError)
+	listen := flags.String("listen", defaultServeAddress, "address to listen on, e.g. :8080 for every interface")
+	token := flags.String("token", os.Getenv("GCM_SERVE_TOKEN"), "require this bearer token on /generate (default $GCM_SERVE_TOKEN)")
+	insecure := flags.Bool("insecure-no-token", false, "allow serving a non-loopback address without --token")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message serve [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	// Without a token, only the Host header tells local requests apart,
+	// and any client but a browser can set it.
+	if host, _, _ := net.SplitHostPort(*listen); *token == "" && !isLoopback(host) {
+		if !*insecure {
+			return fmt.Errorf("%s is reachable from other machines; set --token (or GCM_SERVE_TOKEN), or pass --insecure-no-token to serve it to anyone", *listen)
+		}
+		fmt.Fprintln(os.Stderr, "⚠️  Serving without --token on a public address: anyone who can reach it can use your model and API keys")
+	}
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	listener, err := net.Listen("tcp", *listen)
+	if err != nil {
+		return err
+	}
+	// Progress banners from the generator must not end up mixed into logs.
+	statusOut = os.Stderr
+
+	s := &apiServer{config: config, token: *token}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /healthz", s.healthz)
+	mux.HandleFunc("POST /generate", s.generate)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		<-appCtx.Done()
+		server.Close()
+	}()
+
+	fmt.Fprintf(os.Stderr, "🚀 Serving %s on http://%s (Ctrl-C to stop)\n", providerLabel(config.providerConfig()), listener.Addr())
+	if err := server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
+
+// isLoopback reports whether host only accepts connections from this machine.
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// healthz reports that the server is up and which model it uses.
+func (s *apiServer) healthz(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]string{"status": "ok", "provider": providerLabel(s.config.providerConfig())})
+}
+
+// generate writes a commit message for the diff in the request.
+func (s *apiServer) generate(w http.ResponseWriter, r *http.Request) {
+	if !s.authorized(r) {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		writeJSON(w, http.StatusUnauthorized, serveError{"missing or wrong bearer token"})
+		return
+	}
+	if status, problem := s.checkBrowser(r); problem != "" {
+		writeJSON(w, status, serveError{problem})
+		return
+	}
+	var req serveRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxServeRequest)).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, serveError{"invalid request body: " + err.Error()})
+		return
+	}
+	config, err := s.requestConfig(req)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, serveError{err.Error()})
+		return
+	}
+	diff := prepareDiff(config, req.Diff)
+	if strings.TrimSpace(diff) == "" {
+		writeJSON(w, http.StatusBadRequest, serveError{"diff is empty"})
+		return
+	}
+
+	started := time.Now()
+	var message string
+	var candidates []string
+	if req.Count > 1 {
+		candidates, err = suggestMessages(r.Context(), config, diff, req.Count)
+		if err == nil {
+			message = candidates[0]
+		}
+	} else {
+		message, err = cachedSuggestion(r.Context(), config, diff)
+	}
+	slog.Info("generate request", "diff_bytes", len(req.Diff), "duration", time.Since(started), "err", err)
+	if err != nil {
+		writeJSON(w, http.StatusBadGateway, serveError{err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, newJSONResult(config, message, candidates, time.Since(started)))
+}
+
+// authorized checks the bearer token, if one is required.
+func (s *apiServer) authorized(r *http.Request) bool {
+	if s.token == "" {
+		return true
+	}
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	return ok && subtle.ConstantTimeCompare([]byte(token), []byte(s.token)) == 1
+}
+
+// checkBrowser keeps web pages from using a server that has no token.
+// Browsers send cross-site posts without a preflight only with form
+// content types, and DNS rebinding reaches the server under the page's
+// host name, so both a JSON body and a loopback Host are required. It
+// returns the status and problem to reply with, or "" if r may go ahead.
+func (s *apiServer) checkBrowser(r *http.Request) (int, string) {
+	if s.token != "" {
+		return 0, ""
+	}
+	if mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type")); err != nil || mediaType != "application/json" {
+		return http.StatusUnsupportedMediaType, "the request body must be sent as Content-Type: application/json"
+	}
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if !isLoopback(strings.Trim(host, "[]")) {
+		return http.StatusForbidden, fmt.Sprintf("host %q is not allowed without --token; use localhost or a loopback address", r.Host)
+	}
+	return 0, ""
+}
+
+// requestConfig returns a copy of the server's config with the request's
+// options applied.
+func (s *apiServer) requestConfig(req serveRequest) (*Config, error) {
+	config := *s.config
+	if req.Body != nil {
+		config.Body = *req.Body
+	}
+	if req.Language != "" {
+		config.Language = req.Language
+	}
+	switch convention := strings.ToLower(req.Convention); convention {
+	case "":
+	case conventionConventional, conventionGitmoji:
+		config.Convention = convention
+	default:
+		return nil, fmt.Errorf("unknown convention %q (expected conventional or gitmoji)", req.Convention)
+	}
+	if req.Count < 0 || req.Count > maxServeCandidates {
+		return nil, fmt.Errorf("count must be between 1 and %d, or left out for one message", maxServeCandidates)
+	}
+	return &config, nil
+}
+
+// writeJSON writes v as the response with the given status.
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(v); err != nil {
+		slog.Warn("could not write response", "err", err)
+	}
+}
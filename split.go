@@ -0,0 +1,219 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// splitPrompt asks the model to group the staged files into logical commits.
+const splitPrompt = "The staged changes below may mix several unrelated changes. Group the files into logical commits, each covering one coherent change, and write a commit message for each: a single line {{.Convention}}. Every file must be in exactly one group; use a single group if the changes belong together. {{.LanguageInstructions}}Reply with only a JSON array, no markdown, in this form:\n[{\"files\": [\"path/one\", \"path/two\"], \"message\": \"feat: ...\"}]\n\n" + systemSeparator + "Files:\n{{range .Files}}- {{.}}\n{{end}}\n" + changesSection
+
+// splitData is what splitPrompt is executed against.
+type splitData struct {
+	*promptData
+	// Files are the staged paths to distribute.
+	Files []string
+}
+
+// splitGroup is one proposed commit.
+type splitGroup struct {
+	Files   []string `json:"files"`
+	Message string   `json:"message"`
+}
+
+// parseSplitGroups extracts the JSON array from the model's reply and makes
+// sure every staged file ends up in exactly one group: unknown paths are
+// dropped, duplicates keep their first group and files the model forgot
+// are added to the last group.
+func parseSplitGroups(reply string, files []string) ([]splitGroup, error) {
+	start, end := strings.Index(reply, "["), strings.LastIndex(reply, "]")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("the model did not reply with a JSON array")
+	}
+	var raw []splitGroup
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &raw); err != nil {
+		return nil, fmt.Errorf("could not parse the model's grouping: %w", err)
+	}
+
+	assigned := make(map[string]bool)
+	var groups []splitGroup
+	for _, group := range raw {
+		var kept []string
+		for _, file := range group.Files {
+			if slices.Contains(files, file) && !assigned[file] {
+				assigned[file] = true
+				kept = append(kept, file)
+			}
+		}
+		if len(kept) > 0 && strings.TrimSpace(group.Message) != "" {
+			groups = append(groups, splitGroup{Files: kept, Message: group.Message})
+		}
+	}
+	if len(groups) == 0 {
+		return nil, fmt.Errorf("the model's grouping did not match any staged file")
+	}
+	for _, file := range files {
+		if !assigned[file] {
+			last := &groups[len(groups)-1]
+			last.Files = append(last.Files, file)
+		}
+	}
+	return groups, nil
+}
+
+// proposeSplit asks the model how to split the staged changes.
+func proposeSplit(ctx context.Context, config *Config, files []string) ([]splitGroup, error) {
+	diff, err := collectDiff(config, diffStaged)
+	if err != nil {
+		return nil, err
+	}
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return nil, err
+	}
+	prompt, err := renderPrompt(splitPrompt, &splitData{
+		promptData: newPromptData(config, diff, summaries),
+		Files:      files,
+	})
+	if err != nil {
+		return nil, err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return nil, err
+	}
+	groups, err := parseSplitGroups(reply, files)
+	if err != nil {
+		return nil, err
+	}
+	for i := range groups {
+		message := config.clean(groups[i].Message)
+		if config.Convention == conventionGitmoji {
+			message = toGitmoji(message)
+		}
+		if groups[i].Message, err = decorateMessage(config, message); err != nil {
+			return nil, err
+		}
+	}
+	return groups, nil
+}
+
+// stageFrom sets the index entries for files to their content in tree,
+// leaving the working tree alone. Files absent from tree are unstaged.
+func stageFrom(tree string, files []string) error {
+	_, err := runGit(append([]string{"restore", "--staged", "--source=" + tree, "--"}, files...)...)
+	return err
+}
+
+// commitGroups commits each group in turn from the staged snapshot tree.
+// With interactive set, every group can be committed, edited or skipped;
+// skipped files are staged again at the end.
+func commitGroups(groups []splitGroup, tree string, interactive bool) error {
+	// Start from HEAD's index and add one group at a time.
+	if _, err := runGit("reset", "-q"); err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "ℹ️  If anything goes wrong, restore the original index with: git read-tree %s\n", tree)
+
+	var skipped []string
+	for i, group := range groups {
+		message := group.Message
+		if interactive {
+			fmt.Printf("\n[%d/%d] %s\n  %s\n", i+1, len(groups), message, strings.Join(group.Files, "\n  "))
+			fmt.Print("[c]ommit, [e]dit message, [s]kip: ")
+			answer, err := readLine()
+			if err != nil {
+				return err
+			}
+			switch strings.ToLower(answer) {
+			case "s", "skip":
+				skipped = append(skipped, group.Files...)
+				continue
+			case "e", "edit":
+				edited, err := editMessage(message, "")
+				if err != nil {
+					return err
+				}
+				if edited != "" {
+					message = edited
+				}
+			}
+		}
+		if err := stageFrom(tree, group.Files); err != nil {
+			return err
+		}
+		if err := gitCommit(message, diffStaged); err != nil {
+			return err
+		}
+	}
+	if len(skipped) > 0 {
+		return stageFrom(tree, skipped)
+	}
+	return nil
+}
+
+// runSplit implements `git-commit-message split`: propose how to split the
+// staged changes into several commits and create them.
+func runSplit(args []string) error {
+	flags := flag.NewFlagSet("split", flag.ExitOnError)
+	yes := flags.Bool("yes", false, "create the commits without asking for confirmation")
+	dryRun := flags.Bool("dry-run", false, "only show the proposed commits")
+	interactive := flags.Bool("i", false, "confirm, edit or skip each commit in turn")
+	overrides := registerConfigFlags(flags)
+	flags.Parse(args)
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	if !hasHead() {
+		return fmt.Errorf("split needs an existing commit to build on")
+	}
+	output, err := runGit("diff", "--staged", "--name-only", "--no-renames")
+	if err != nil {
+		return err
+	}
+	files := strings.Fields(output)
+	if len(files) < 2 {
+		return fmt.Errorf("split needs at least two staged files")
+	}
+	// Snapshot the index so partially staged files are committed as staged.
+	tree, err := runGit("write-tree")
+	if err != nil {
+		return err
+	}
+	tree = strings.TrimSpace(tree)
+
+	fmt.Fprintf(os.Stderr, "🤖 Grouping %d staged files...\n", len(files))
+	groups, err := proposeSplit(appCtx, config, files)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("\n✂️  Proposed %d commit(s):\n", len(groups))
+	for i, group := range groups {
+		fmt.Printf("\n  %d) %s\n", i+1, subjectLine(group.Message))
+		for _, file := range group.Files {
+			fmt.Printf("       %s\n", file)
+		}
+	}
+	if *dryRun {
+		return nil
+	}
+	if !*yes && !*interactive {
+		ok, err := confirm(fmt.Sprintf("\nCreate these %d commits?", len(groups)))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Split aborted.")
+			return nil
+		}
+	}
+	return commitGroups(groups, tree, *interactive)
+}
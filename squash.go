@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// squashPrompt asks for one message describing a branch's commits as a
+// single change.
+const squashPrompt = "The commits below, made on branch '{{.Branch}}', are being squashed into a single commit on '{{.Base}}'. Write one git commit message for the combined change. Start with a concise subject line{{if .SubjectLength}} of at most {{.SubjectLength}} characters{{end}} {{.Convention}} that sums up the branch as a whole, then a blank line, then a short body in plain prose covering the notable changes. Describe the end result of the diff, not the history: leave out work that a later commit reverted or replaced, merge fixups and review follow-ups into the change they amend, and mention each change once even if several commits touched it. Keep any 'BREAKING CHANGE:' footers and issue references from the commits. {{.LanguageInstructions}}{{.TypeInstructions}}Do not include any explanation, preamble, or markdown formatting. Just the commit message itself.\n\n" + systemSeparator + "Commits, oldest first:\n{{range .Commits}}---\n{{.}}\n{{end}}---\n\n" + changesSection
+
+// squashData is what squashPrompt is executed against.
+type squashData struct {
+	*promptData
+	// Base is the branch the commits are squashed onto.
+	Base string
+	// Commits are the full messages of the branch's commits, oldest first.
+	Commits []string
+}
+
+// runSquash implements `git-commit-message squash [base]`: one message for
+// a squash merge of the current branch, written from every commit message
+// on it and the branch's combined diff.
+func runSquash(args []string) error {
+	flags := flag.NewFlagSet("squash", flag.ExitOnError)
+	noCoAuthors := flags.Bool("no-co-authors", false, "don't credit the branch's other commit authors with Co-authored-by trailers")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message squash [flags] [base-branch]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() > 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	base := flags.Arg(0)
+	if base == "" {
+		if base, err = defaultBaseBranch(); err != nil {
+			return err
+		}
+	} else if _, err := runGit("rev-parse", "--verify", "--quiet", base); err != nil {
+		return fmt.Errorf("unknown base branch %q", base)
+	}
+	if !*noCoAuthors {
+		authors, err := branchCoAuthors(base + "..HEAD")
+		if err != nil {
+			return err
+		}
+		config.CoAuthors = append(slices.Clone(config.CoAuthors), authors...)
+	}
+
+	message, err := squashMessage(appCtx, config, base)
+	if err != nil {
+		return err
+	}
+	fmt.Println(message)
+	return nil
+}
+
+// squashMessage writes the message for squashing the commits between base
+// and HEAD.
+func squashMessage(ctx context.Context, config *Config, base string) (string, error) {
+	commits, err := rangeCommits("--reverse", base+"..HEAD")
+	if err != nil {
+		return "", err
+	}
+	if len(commits) == 0 {
+		return "", fmt.Errorf("no commits between %s and HEAD", base)
+	}
+	// Three dots: only what the branch changed since it forked from base.
+	diff, err := rangeDiff(base + "...HEAD")
+	if err != nil {
+		return "", err
+	}
+	diff = prepareDiff(config, diff)
+	if strings.TrimSpace(diff) == "" {
+		return "", fmt.Errorf("the commits between %s and HEAD change nothing", base)
+	}
+
+	fmt.Fprintf(os.Stderr, "🤖 Squashing %d commit(s) onto %s...\n", len(commits), base)
+	// The prompt asks for a body in plain text, whatever the config says.
+	squashed := *config.forPackage(diff)
+	squashed.Body, squashed.StructuredOutput = true, false
+	config = &squashed
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	data := &squashData{promptData: newPromptData(config, diff, summaries), Base: base}
+	for _, commit := range commits {
+		data.Commits = append(data.Commits, strings.TrimSpace(commit.Subject+"\n\n"+commit.Body))
+	}
+	hint := changeHintFor(config, diff)
+	if config.Convention != conventionGitmoji {
+		data.hint = hint
+	}
+	prompt, err := renderPrompt(squashPrompt, data)
+	if err != nil {
+		return "", err
+	}
+	return suggestFromPrompt(ctx, config, diff, prompt, hint)
+}
+
+// branchCoAuthors returns "Name <email>" for each author of the commits in
+// revRange other than the current user, in order of their first commit.
+func branchCoAuthors(revRange string) ([]string, error) {
+	output, err := runGit("log", "--reverse", "--no-merges", "--format=%an <%ae>", revRange)
+	if err != nil {
+		return nil, err
+	}
+	self, _ := runGit("config", "user.email")
+	self = strings.TrimSpace(self)
+	var authors []string
+	for _, author := range strings.Split(strings.TrimSpace(output), "\n") {
+		if author == "" || slices.Contains(authors, author) || self != "" && strings.HasSuffix(author, "<"+self+">") {
+			continue
+		}
+		authors = append(authors, author)
+	}
+	return authors, nil
+}
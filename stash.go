@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// stashPrompt asks for a one-line description of work in progress.
+const stashPrompt = "Describe the unfinished work in the changes below in one short line of at most 60 characters, for a git stash entry, e.g. 'retry logic for webhook delivery, tests missing'. Say what is being worked on, not that it is a work in progress. Use no type prefix, emoji or trailing period. {{.LanguageInstructions}}Do not include any explanation, preamble, or markdown formatting. Just the line itself.\n\n" + systemSeparator + "{{if .Untracked}}New untracked files: {{.Untracked}}\n\n{{end}}" + changesSection
+
+// stashData is what stashPrompt is executed against.
+type stashData struct {
+	*promptData
+	// Untracked lists the untracked files stashed with --include-untracked.
+	Untracked string
+}
+
+// runStash implements `git-commit-message stash`: it describes the working
+// tree changes and stashes them under that description with `git stash
+// push -m`, instead of git's "WIP on <branch>".
+func runStash(args []string) error {
+	flags := flag.NewFlagSet("stash", flag.ExitOnError)
+	untracked := flags.Bool("include-untracked", false, "stash untracked files too, like `git stash push -u`")
+	flags.BoolVar(untracked, "u", false, "same as --include-untracked")
+	dryRun := flags.Bool("dry-run", false, "print the stash message without stashing")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message stash [flags]")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 0 {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	diff, err := collectDiff(config, diffAll)
+	if err != nil {
+		return err
+	}
+	var files []string
+	if *untracked {
+		output, err := runGit("ls-files", "--others", "--exclude-standard")
+		if err != nil {
+			return err
+		}
+		files = strings.Fields(output)
+	}
+	if strings.TrimSpace(diff) == "" && len(files) == 0 {
+		fmt.Println("No local changes to stash. 🤔")
+		return nil
+	}
+
+	message, err := stashMessage(appCtx, config, diff, files)
+	if err != nil {
+		return err
+	}
+	if *dryRun {
+		fmt.Println(message)
+		return nil
+	}
+	stashArgs := []string{"stash", "push", "-m", message}
+	if *untracked {
+		stashArgs = append(stashArgs, "--include-untracked")
+	}
+	cmd := exec.Command("git", stashArgs...)
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("failed to execute 'git stash': %w", err)
+	}
+	return nil
+}
+
+// stashMessage writes the stash description for diff and the untracked files.
+func stashMessage(ctx context.Context, config *Config, diff string, untracked []string) (string, error) {
+	summaries, err := summarizeDiff(ctx, config, diff)
+	if err != nil {
+		return "", err
+	}
+	prompt, err := renderPrompt(stashPrompt, &stashData{
+		promptData: newPromptData(config, diff, summaries),
+		Untracked:  strings.Join(untracked, ", "),
+	})
+	if err != nil {
+		return "", err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	message := strings.TrimSuffix(cleanMessage(config.runCleaners(reply)), ".")
+	if message == "" {
+		return "", fmt.Errorf("the model returned an empty stash message")
+	}
+	return message, nil
+}
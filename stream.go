@@ -0,0 +1,100 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+// spinnerFrames are drawn in turn while waiting for the first token.
+var spinnerFrames = []rune("⠋⠙⠹⠸⠼⠴⠦⠧⠇⠏")
+
+// liveOutput shows a reply as it is generated: a spinner until the first
+// token arrives, then the tokens themselves.
+type liveOutput struct {
+	w  io.Writer
+	mu sync.Mutex
+	// stop ends the spinner; it is nil when no spinner is running.
+	stop chan struct{}
+	done chan struct{}
+	// wrote is set once a token has been shown.
+	wrote bool
+}
+
+// newLiveOutput returns a liveOutput drawing on w.
+func newLiveOutput(w io.Writer) *liveOutput {
+	return &liveOutput{w: w}
+}
+
+// start shows the spinner until the first token or finish.
+func (l *liveOutput) start() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.wrote = false
+	l.stop, l.done = make(chan struct{}), make(chan struct{})
+	go func(stop, done chan struct{}) {
+		defer close(done)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			l.mu.Lock()
+			fmt.Fprintf(l.w, "\r%c Waiting for the model...", spinnerFrames[i%len(spinnerFrames)])
+			l.mu.Unlock()
+			select {
+			case <-stop:
+				return
+			case <-ticker.C:
+			}
+		}
+	}(l.stop, l.done)
+}
+
+// stopSpinner ends the spinner and clears its line. l.mu must not be held.
+func (l *liveOutput) stopSpinner() {
+	l.mu.Lock()
+	stop, done := l.stop, l.done
+	l.stop = nil
+	l.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+	fmt.Fprint(l.w, "\r\033[K")
+}
+
+// token shows the next piece of the reply.
+func (l *liveOutput) token(token string) {
+	l.stopSpinner()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.wrote = true
+	fmt.Fprint(l.w, token)
+}
+
+// finish ends the live output, leaving the cursor on a fresh line.
+func (l *liveOutput) finish() {
+	l.stopSpinner()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.wrote {
+		fmt.Fprintln(l.w)
+	}
+}
+
+// liveOutputKey is the context key for withLiveOutput.
+type liveOutputKey struct{}
+
+// withLiveOutput returns a context whose commit message requests are
+// streamed to live.
+func withLiveOutput(ctx context.Context, live *liveOutput) context.Context {
+	return context.WithValue(ctx, liveOutputKey{}, live)
+}
+
+// liveOutputFrom returns the liveOutput set by withLiveOutput, or nil.
+func liveOutputFrom(ctx context.Context) *liveOutput {
+	live, _ := ctx.Value(liveOutputKey{}).(*liveOutput)
+	return live
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// structuredPrompt asks for the commit message as a JSON object, for
+// structured_output. The message is put together from it in Go.
+const structuredPrompt = "Based on the following git diff, describe the change as a conventional commit. {{.LanguageInstructions}}{{.TypeInstructions}}Reply with only a JSON object with these keys:\n" +
+	"- \"type\": one of %s\n" +
+	"- \"scope\": a short noun for the part of the code affected, or \"\"\n" +
+	"- \"subject\": what the change does, in the imperative mood, lower case, without a trailing period, {{if .SubjectLength}}short enough that type, scope and subject together fit in {{.SubjectLength}} characters{{else}}concise{{end}}\n" +
+	"- \"body\": %s\n" +
+	"- \"breaking\": true only if the change breaks existing users\n\n{{.StyleExamples}}" + systemSeparator + changesSection
+
+// structuredBodies fill in structuredPrompt's "body" key without and with --body.
+var structuredBodies = [2]string{
+	`always ""`,
+	"a short plain-prose explanation of what changed and why",
+}
+
+// structuredTemplate returns structuredPrompt for the configured message shape.
+func structuredTemplate(body bool) string {
+	description := structuredBodies[0]
+	if body {
+		description = structuredBodies[1]
+	}
+	return fmt.Sprintf(structuredPrompt, strings.Join(conventionalTypes, ", "), description)
+}
+
+// structuredReply is the JSON object structuredPrompt asks for.
+type structuredReply struct {
+	Type     string `json:"type"`
+	Scope    string `json:"scope"`
+	Subject  string `json:"subject"`
+	Body     string `json:"body"`
+	Breaking bool   `json:"breaking"`
+}
+
+// structuredSchema is the JSON schema of structuredReply, for providers
+// that can constrain their output to one.
+func structuredSchema() json.RawMessage {
+	schema := map[string]any{
+		"type": "object",
+		"properties": map[string]any{
+			"type":     map[string]any{"type": "string", "enum": conventionalTypes},
+			"scope":    map[string]any{"type": "string"},
+			"subject":  map[string]any{"type": "string"},
+			"body":     map[string]any{"type": "string"},
+			"breaking": map[string]any{"type": "boolean"},
+		},
+		"required":             []string{"type", "scope", "subject", "body", "breaking"},
+		"additionalProperties": false,
+	}
+	data, _ := json.Marshal(schema)
+	return data
+}
+
+// parseStructured decodes the JSON object in reply. ok is false when there
+// is none or it lacks a type or subject.
+func parseStructured(reply string) (r structuredReply, ok bool) {
+	start, end := strings.Index(reply, "{"), strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return structuredReply{}, false
+	}
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &r); err != nil {
+		return structuredReply{}, false
+	}
+	if strings.TrimSpace(r.Type) == "" || strings.TrimSpace(r.Subject) == "" {
+		return structuredReply{}, false
+	}
+	return r, true
+}
+
+// message assembles the commit message, with the body only if body is set.
+func (r structuredReply) message(body bool) string {
+	subject := strings.TrimSpace(r.Subject)
+	first, size := utf8.DecodeRuneInString(subject)
+	subject = string(unicode.ToLower(first)) + subject[size:]
+	header := conventionalCommit{
+		Type:     strings.ToLower(strings.TrimSpace(r.Type)),
+		Scope:    strings.TrimSpace(r.Scope),
+		Breaking: r.Breaking,
+		Subject:  strings.TrimRight(subject, "."),
+	}.String()
+	if !body {
+		return header
+	}
+	return commitMessage{Subject: header, Body: strings.TrimSpace(r.Body)}.String()
+}
+
+// structuredExample turns a configured example message into the JSON reply
+// the model is expected to give for it.
+func structuredExample(message string) string {
+	parsed := parseCommitMessage(message)
+	reply := structuredReply{Subject: parsed.Subject, Body: parsed.Body}
+	if commit, ok := parseConventional(parsed.Subject); ok {
+		reply.Type, reply.Scope, reply.Subject, reply.Breaking = commit.Type, commit.Scope, commit.Subject, commit.Breaking
+	}
+	data, _ := json.Marshal(reply)
+	return string(data)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"path/filepath"
+	"strings"
+)
+
+// maxSubmoduleCommits caps how many commits of a bumped submodule are
+// listed in the prompt.
+const maxSubmoduleCommits = 20
+
+// zeroSHA is the object name git diffs show for a side that doesn't exist.
+var zeroSHA = strings.Repeat("0", 40)
+
+// submoduleBump is a change of the commit a submodule points at.
+type submoduleBump struct {
+	Path     string
+	Old, New string
+}
+
+// submoduleBumps returns the submodule pointer changes in diff, read from
+// their "Subproject commit" lines.
+func submoduleBumps(diff string) []submoduleBump {
+	var bumps []submoduleBump
+	for _, f := range splitDiff(diff) {
+		bump := submoduleBump{Path: f.Path, Old: zeroSHA, New: zeroSHA}
+		found := false
+		for _, line := range strings.Split(f.Text, "\n") {
+			if sha, ok := strings.CutPrefix(line, "-Subproject commit "); ok {
+				bump.Old, found = strings.TrimSuffix(sha, "-dirty"), true
+			} else if sha, ok := strings.CutPrefix(line, "+Subproject commit "); ok {
+				bump.New, found = strings.TrimSuffix(sha, "-dirty"), true
+			}
+		}
+		if found && bump.Old != bump.New {
+			bumps = append(bumps, bump)
+		}
+	}
+	return bumps
+}
+
+// describe returns a line about the bump followed by the subjects of the
+// commits it brings in, read from the submodule's own history. Submodules
+// that aren't checked out, or lack the commits, just get the line.
+func (b submoduleBump) describe(top string) string {
+	switch {
+	case b.Old == zeroSHA:
+		return fmt.Sprintf("%s: added at %s\n", b.Path, shortSHA(b.New))
+	case b.New == zeroSHA:
+		return fmt.Sprintf("%s: removed\n", b.Path)
+	}
+	header := fmt.Sprintf("%s: %s..%s", b.Path, shortSHA(b.Old), shortSHA(b.New))
+	dir := filepath.Join(top, filepath.FromSlash(b.Path))
+	log, err := runGit("-C", dir, "log", "--no-merges", "--format=%s", fmt.Sprintf("--max-count=%d", maxSubmoduleCommits+1), b.Old+".."+b.New)
+	if err != nil {
+		slog.Info("submodule log unavailable", "path", b.Path, "err", err)
+		return header + "\n"
+	}
+	if log = strings.TrimSpace(log); log == "" {
+		// The old commit is ahead of the new one: the submodule went back.
+		return header + " (moved back to an older commit)\n"
+	}
+	subjects := strings.Split(log, "\n")
+	more := ""
+	if len(subjects) > maxSubmoduleCommits {
+		subjects = subjects[:maxSubmoduleCommits]
+		more = "  - ...and more\n"
+	}
+	return header + "\n  - " + strings.Join(subjects, "\n  - ") + "\n" + more
+}
+
+// shortSHA abbreviates an object name for display.
+func shortSHA(sha string) string {
+	if len(sha) > 12 {
+		return sha[:12]
+	}
+	return sha
+}
+
+// Submodules returns a prompt section listing the commits each bumped
+// submodule brings in, so the message can say what changed rather than
+// "update submodule". It is "" when no submodule changed.
+func (p *promptData) Submodules() string {
+	bumps := submoduleBumps(p.full)
+	if len(bumps) == 0 {
+		return ""
+	}
+	top, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Submodule updates (commits brought in):\n")
+	for _, bump := range bumps {
+		b.WriteString(bump.describe(strings.TrimSpace(top)))
+	}
+	return b.String() + "\n"
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+const (
+	// summaryConcurrency caps how many per-file summaries run at once.
+	summaryConcurrency = 4
+	// maxSummarizedFiles caps the number of model calls for huge changes;
+	// files past it are only listed by name.
+	maxSummarizedFiles = 40
+)
+
+// summaryPrompt asks for a one-line summary of a single file's diff.
+const summaryPrompt = "Summarize the following change to %s in a single short sentence, focusing on what changed in behaviour. Do not include any preamble or markdown formatting.\n\n" + systemSeparator + "```diff\n%s\n```"
+
+// estimateTokens gives a rough token count for text (about four bytes per token).
+func estimateTokens(text string) int {
+	return (len(text) + 3) / 4
+}
+
+// truncateToTokens cuts text to roughly budget tokens at a line boundary
+// and notes how much was dropped.
+func truncateToTokens(text string, budget int) string {
+	limit := budget * 4
+	if len(text) <= limit {
+		return text
+	}
+	cut := strings.LastIndex(text[:limit], "\n")
+	if cut <= 0 {
+		// No line to cut at, as in a long description: back up to the
+		// start of a rune so the text stays valid UTF-8.
+		cut = limit
+		for cut > 0 && !utf8.RuneStart(text[cut]) {
+			cut--
+		}
+	}
+	dropped := strings.Count(text[cut:], "\n")
+	return fmt.Sprintf("%s\n[... %d more lines truncated ...]\n", text[:cut], dropped)
+}
+
+// overBudget reports whether diff is too large to send as it is.
+func overBudget(config *Config, diff string) bool {
+	budget := config.diffBudget()
+	return budget > 0 && estimateTokens(diff) > budget
+}
+
+// summarizeDiff returns "" for diffs within config.diffBudget(). Larger ones
+// are split per file and each file is summarised by the model in parallel
+// (the "map" step); the summaries then stand in for the diff when the
+// commit message is written (the "reduce" step). newPromptData truncates
+// the diff itself for templates that still reference it.
+func summarizeDiff(ctx context.Context, config *Config, diff string) (string, error) {
+	if !overBudget(config, diff) {
+		return "", nil
+	}
+
+	files := splitDiff(diff)
+	if len(files) == 0 {
+		return "", nil
+	}
+	summarized := files
+	if len(summarized) > maxSummarizedFiles {
+		summarized = summarized[:maxSummarizedFiles]
+	}
+
+	summaries := make([]string, len(summarized))
+	errs := make([]error, len(summarized))
+	sem := make(chan struct{}, summaryConcurrency)
+	var wg sync.WaitGroup
+	for i, f := range summarized {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sem <- struct{}{}
+			defer func() { <-sem }()
+			prompt := fmt.Sprintf(summaryPrompt, f.Path, truncateToTokens(f.Text, config.diffBudget()))
+			summary, err := generateCommitMessage(ctx, config, prompt)
+			if err != nil {
+				errs[i] = fmt.Errorf("could not summarize %s: %w", f.Path, err)
+				return
+			}
+			summaries[i] = cleanMessage(summary)
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return "", err
+		}
+	}
+
+	var b strings.Builder
+	for i, f := range summarized {
+		fmt.Fprintf(&b, "- %s: %s\n", f.Path, summaries[i])
+	}
+	for _, f := range files[len(summarized):] {
+		fmt.Fprintf(&b, "- %s: (not summarized)\n", f.Path)
+	}
+	return b.String(), nil
+}
+
+// placeholderSummaries mirrors summarizeDiff without calling the model: the
+// per-file summaries are replaced by a marker so --dry-run can show the
+// shape of the final prompt.
+func placeholderSummaries(config *Config, diff string) string {
+	if !overBudget(config, diff) {
+		return ""
+	}
+	var b strings.Builder
+	for i, f := range splitDiff(diff) {
+		if i < maxSummarizedFiles {
+			fmt.Fprintf(&b, "- %s: <summary from the model>\n", f.Path)
+		} else {
+			fmt.Fprintf(&b, "- %s: (not summarized)\n", f.Path)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// tagMessagePrompt asks for an annotated tag message summarising commits.
+const tagMessagePrompt = "Write the annotation for a git tag{{if .Name}} named {{.Name}}{{end}} that covers the commits below. Start with a one-line summary of at most 72 characters, then a blank line, then a short plain-text bullet list ('- ') of the notable changes, merging related commits. Use no markdown headings. {{.LanguageInstructions}}Do not include any preamble or explanation, just the tag message.\n\n" + systemSeparator + "Commits:\n{{range .Commits}}- {{.}}\n{{end}}"
+
+// tagMessageData is what tagMessagePrompt is executed against.
+type tagMessageData struct {
+	*promptData
+	// Name is the tag being created, if known.
+	Name string
+	// Commits are the subjects in the range, oldest first.
+	Commits []string
+}
+
+// runTagMessage implements `git-commit-message tag-message <from>..<to>`.
+func runTagMessage(args []string) error {
+	flags := flag.NewFlagSet("tag-message", flag.ExitOnError)
+	tag := flags.String("tag", "", "create this annotated tag at <to> with the message")
+	yes := flags.Bool("yes", false, "with --tag, skip the confirmation prompt")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message tag-message [flags] <from>..<to>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 {
+		flags.Usage()
+		os.Exit(2)
+	}
+	revRange := flags.Arg(0)
+	_, to, ok := strings.Cut(revRange, "..")
+	if !ok {
+		return fmt.Errorf("expected a range such as v1.2.0..HEAD, got %q", revRange)
+	}
+	if to == "" {
+		to = "HEAD"
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	commits, err := rangeSubjects(revRange)
+	if err != nil {
+		return fmt.Errorf("invalid revision range %q: %w", revRange, err)
+	}
+	if len(commits) == 0 {
+		return fmt.Errorf("no commits in %s", revRange)
+	}
+
+	prompt, err := renderPrompt(tagMessagePrompt, &tagMessageData{
+		promptData: newPromptData(config, "", ""),
+		Name:       *tag,
+		Commits:    commits,
+	})
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "🤖 Summarizing %d commit(s)...\n", len(commits))
+	reply, err := generateCommitMessage(appCtx, config, prompt)
+	if err != nil {
+		return err
+	}
+	message := cleanMultilineMessage(reply)
+	fmt.Println(message)
+
+	if *tag == "" {
+		return nil
+	}
+	if !*yes {
+		ok, err := confirm(fmt.Sprintf("\nCreate tag %s at %s with this message?", *tag, to))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Tag aborted.")
+			return nil
+		}
+	}
+	if _, err := runGit("tag", "-a", *tag, "-m", message, to); err != nil {
+		return fmt.Errorf("could not create tag %s: %w", *tag, err)
+	}
+	fmt.Fprintf(os.Stderr, "🏷️  Created tag %s\n", *tag)
+	return nil
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// defaultTicketPattern matches Jira-style keys such as JIRA-1234.
+const defaultTicketPattern = `[A-Z][A-Z0-9]+-[0-9]+`
+
+// Supported values for Config.TicketPosition.
+const (
+	ticketPrefix = "prefix"
+	ticketSuffix = "suffix"
+	ticketFooter = "footer"
+)
+
+// ticketFromBranch extracts the ticket key from branch using pattern. If the
+// pattern has a capture group, the first group is the key; otherwise the
+// whole match is.
+func ticketFromBranch(branch, pattern string) (string, error) {
+	if pattern == "" {
+		pattern = defaultTicketPattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return "", fmt.Errorf("invalid ticket_pattern %q: %w", pattern, err)
+	}
+	m := re.FindStringSubmatch(branch)
+	switch {
+	case m == nil:
+		return "", nil
+	case len(m) > 1:
+		return m[1], nil
+	default:
+		return m[0], nil
+	}
+}
+
+// addTicket places ticket in message according to config.TicketPosition.
+// Messages that already mention the ticket are returned unchanged.
+func addTicket(config *Config, message, ticket string) string {
+	if ticket == "" || strings.Contains(message, ticket) {
+		return message
+	}
+	subject, rest, hasRest := strings.Cut(message, "\n")
+	switch config.TicketPosition {
+	case ticketPrefix:
+		subject = ticket + ": " + subject
+	case ticketSuffix:
+		subject = subject + " (" + ticket + ")"
+	case ticketFooter:
+		token := config.TicketFooter
+		if token == "" {
+			token = "Refs"
+		}
+		return addFooter(message, token, ticket)
+	default:
+		return message
+	}
+	if hasRest {
+		return subject + "\n" + rest
+	}
+	return subject
+}
@@ -0,0 +1,180 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+)
+
+const (
+	// ollamaDefaultContext is the context Ollama gives a model unless
+	// num_ctx or OLLAMA_CONTEXT_LENGTH raise it. Longer prompts are cut
+	// from the start without any error.
+	ollamaDefaultContext = 4096
+	// minDiffBudget keeps a small context window from squeezing the diff
+	// to nothing; below it the diff is summarised per file anyway.
+	minDiffBudget = 500
+)
+
+// contextWindows lists the context size, in tokens, of well-known model
+// families. The model name is matched by prefix, also after a vendor
+// prefix such as Bedrock's "anthropic.", so more specific entries come first.
+var contextWindows = []struct {
+	prefix string
+	tokens int
+}{
+	{"gpt-4o", 128000},
+	{"gpt-4.1", 1047576},
+	{"gpt-4-turbo", 128000},
+	{"gpt-4", 8192},
+	{"gpt-3.5-turbo", 16385},
+	{"o1", 200000},
+	{"o3", 200000},
+	{"o4", 200000},
+	{"claude", 200000},
+	{"gemini-1.5", 1048576},
+	{"gemini-2", 1048576},
+	{"gemini", 32768},
+	{"llama3.1", 131072},
+	{"llama3.2", 131072},
+	{"llama3.3", 131072},
+	{"llama-3.1", 131072},
+	{"llama3", 8192},
+	{"codellama", 16384},
+	{"mistral-nemo", 131072},
+	{"mistral", 32768},
+	{"mixtral", 32768},
+	{"qwen2.5", 32768},
+	{"qwen3", 40960},
+	{"gemma3", 131072},
+	{"gemma2", 8192},
+	{"gemma", 8192},
+	{"phi4", 16384},
+	{"phi3", 131072},
+	{"deepseek-coder-v2", 163840},
+	{"deepseek-coder", 16384},
+	{"deepseek-r1", 131072},
+	{"starcoder2", 16384},
+}
+
+// BudgetConfig holds the `budget:` keys that split the context window
+// between the parts of the prompt. Keys left out of the config keep the
+// values from defaultBudgetConfig.
+type BudgetConfig struct {
+	// Diff is the share of the context window, in percent, the diff may
+	// take before it is summarised per file.
+	Diff int `yaml:"diff"`
+	// History is the share, in percent, for the recent commit subjects
+	// shown as style examples. The rest is left to the instructions.
+	History int `yaml:"history"`
+	// Reply is how many tokens are kept free for the model's answer when
+	// max_tokens isn't set.
+	Reply int `yaml:"reply"`
+}
+
+// defaultBudgetConfig returns the split used for keys missing from the config.
+func defaultBudgetConfig() BudgetConfig {
+	return BudgetConfig{Diff: 70, History: 5, Reply: 512}
+}
+
+// contextWindow returns how many tokens the configured model accepts,
+// or 0 when it isn't known. context_window always wins; Ollama serves
+// num_ctx, or ollamaDefaultContext without it, whatever the model supports.
+func (c *Config) contextWindow() int {
+	if c.ContextWindow > 0 {
+		return c.ContextWindow
+	}
+	model := strings.ToLower(c.Model)
+	if i := strings.LastIndex(model, "/"); i >= 0 {
+		model = model[i+1:]
+	}
+	window := 0
+	for _, known := range contextWindows {
+		if strings.HasPrefix(model, known.prefix) || strings.Contains(model, "."+known.prefix) {
+			window = known.tokens
+			break
+		}
+	}
+	if c.Provider == "" || strings.EqualFold(c.Provider, "ollama") {
+		if c.NumCtx > 0 {
+			return c.NumCtx
+		}
+		if window == 0 || window > ollamaDefaultContext {
+			window = ollamaDefaultContext
+		}
+	}
+	return window
+}
+
+// replyTokens is the part of the context window kept for the answer.
+func (c *Config) replyTokens() int {
+	if c.MaxTokens > 0 {
+		return c.MaxTokens
+	}
+	return c.Budget.Reply
+}
+
+// promptWindow is the part of the context window the prompt may fill, or
+// 0 when the window isn't known.
+func (c *Config) promptWindow() int {
+	window := c.contextWindow()
+	if window == 0 {
+		return 0
+	}
+	return max(window-c.replyTokens(), 0)
+}
+
+// diffBudget is the diff size, in tokens, above which files are summarised:
+// token_budget, lowered to the diff's share of the context window. 0 means
+// the diff is always sent as it is.
+func (c *Config) diffBudget() int {
+	if c.TokenBudget <= 0 {
+		return 0
+	}
+	window := c.promptWindow()
+	if window == 0 {
+		return c.TokenBudget
+	}
+	return min(c.TokenBudget, max(window*c.Budget.Diff/100, minDiffBudget))
+}
+
+// historyBudget caps the style examples, in tokens, or is 0 for no cap.
+func (c *Config) historyBudget() int {
+	return c.promptWindow() * c.Budget.History / 100
+}
+
+// budgetProblems reports a budget split that can't work.
+func budgetProblems(config *Config) []string {
+	var problems []string
+	b := config.Budget
+	if b.Diff < 0 || b.History < 0 || b.Reply < 0 {
+		problems = append(problems, "budget values must not be negative")
+	}
+	if b.Diff+b.History > 100 {
+		problems = append(problems, fmt.Sprintf("budget.diff and budget.history add up to %d%%, leaving nothing for the instructions", b.Diff+b.History))
+	}
+	if config.ContextWindow < 0 {
+		problems = append(problems, "context_window must not be negative")
+	}
+	return problems
+}
+
+// contextWarning makes sure the overflow warning is shown once per run,
+// however many prompts are sent.
+var contextWarning sync.Once
+
+// checkContext warns when prompt won't fit the model's context window
+// together with the reply, since models drop the overflow silently.
+func checkContext(config *Config, prompt string) {
+	window := config.contextWindow()
+	tokens := estimateTokens(prompt)
+	slog.Debug("context", "prompt_tokens", tokens, "reply_tokens", config.replyTokens(), "context_window", window)
+	if window == 0 || tokens+config.replyTokens() <= window {
+		return
+	}
+	contextWarning.Do(func() {
+		fmt.Fprintf(os.Stderr, "⚠️  The prompt is ~%d tokens but %s only takes ~%d including the reply, so the model will truncate it. Lower token_budget or set context_window.\n", tokens, config.Model, window)
+	})
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// footerToken matches a trailer token that footerLine can parse back.
+var footerToken = regexp.MustCompile(`^[A-Za-z][A-Za-z-]*$`)
+
+// TrailerConfig describes one entry of the `trailers:` list. Value is a
+// template executed against trailerData. Command, when set, is run as it
+// is, with trailerData in its environment (see env) and the message on
+// stdin, and its trimmed output is the value. Trailers whose value comes
+// out empty are left out.
+type TrailerConfig struct {
+	Token   string `yaml:"token"`
+	Value   string `yaml:"value"`
+	Command string `yaml:"command"`
+}
+
+// trailerData is what trailer values are executed against and commands
+// are given.
+type trailerData struct {
+	// Message is the message the trailer is added to, and Subject its first line.
+	Message string
+	Subject string
+	// Type and Scope come from a conventional commit subject.
+	Type  string
+	Scope string
+	// Branch is the current branch and Ticket the key found in it.
+	Branch string
+	Ticket string
+	// Author is the committer's "Name <email>".
+	Author string
+	// Date is the current time in RFC 3339 format.
+	Date string
+}
+
+// newTrailerData collects the template variables for message.
+func newTrailerData(config *Config, message string) (*trailerData, error) {
+	data := &trailerData{
+		Message: message,
+		Subject: subjectLine(message),
+		Branch:  currentBranch(),
+		Date:    time.Now().Format(time.RFC3339),
+	}
+	if commit, ok := parseConventional(data.Subject); ok {
+		data.Type, data.Scope = commit.Type, commit.Scope
+	}
+	ticket, err := ticketFromBranch(data.Branch, config.TicketPattern)
+	if err != nil {
+		return nil, err
+	}
+	data.Ticket = ticket
+	// The identity is optional here; templates that need it see "".
+	data.Author, _ = committerIdentity()
+	return data, nil
+}
+
+// env returns data as environment variables for a trailer command. The
+// values come from the model and the branch name, so they are never put
+// into the command line itself.
+func (d *trailerData) env() []string {
+	return []string{
+		"GCM_SUBJECT=" + d.Subject,
+		"GCM_TYPE=" + d.Type,
+		"GCM_SCOPE=" + d.Scope,
+		"GCM_BRANCH=" + d.Branch,
+		"GCM_TICKET=" + d.Ticket,
+		"GCM_AUTHOR=" + d.Author,
+		"GCM_DATE=" + d.Date,
+	}
+}
+
+// expandTrailer executes source as a template against data.
+func expandTrailer(token, source string, data *trailerData) (string, error) {
+	tmpl, err := template.New(token).Parse(source)
+	if err != nil {
+		return "", fmt.Errorf("could not parse trailer %s: %w", token, err)
+	}
+	var value strings.Builder
+	if err := tmpl.Execute(&value, data); err != nil {
+		return "", fmt.Errorf("could not render trailer %s: %w", token, err)
+	}
+	return value.String(), nil
+}
+
+// trailerValue works out the value of trailer for data.
+func trailerValue(trailer TrailerConfig, data *trailerData) (string, error) {
+	if trailer.Command == "" {
+		value, err := expandTrailer(trailer.Token, trailer.Value, data)
+		return strings.TrimSpace(value), err
+	}
+	cmd := exec.Command("sh", "-c", trailer.Command)
+	cmd.Env = append(os.Environ(), data.env()...)
+	cmd.Stdin = strings.NewReader(data.Message)
+	output, err := cmd.Output()
+	if err != nil {
+		return "", fmt.Errorf("trailer %s: command %q failed: %w", trailer.Token, trailer.Command, err)
+	}
+	// Only the first line is used, trailers being single-line.
+	value, _, _ := strings.Cut(strings.TrimSpace(string(output)), "\n")
+	return strings.TrimSpace(value), nil
+}
+
+// identityPattern matches a git identity such as `Jane Doe <jane@example.com>`.
+var identityPattern = regexp.MustCompile(`^[^<>]+ <[^<>@\s]+@[^<>\s]+>$`)
+
+// validIdentity reports an error unless value looks like `Name <email>`.
+func validIdentity(value string) error {
+	if !identityPattern.MatchString(strings.TrimSpace(value)) {
+		return fmt.Errorf("%q is not of the form \"Name <email>\"", value)
+	}
+	return nil
+}
+
+// committerIdentity returns the configured `Name <email>` that
+// Signed-off-by uses, as `git commit --signoff` would.
+func committerIdentity() (string, error) {
+	ident, err := runGit("var", "GIT_COMMITTER_IDENT")
+	if err != nil {
+		return "", fmt.Errorf("could not determine your git identity for Signed-off-by: %w", err)
+	}
+	// The ident ends with a timestamp and time zone.
+	fields := strings.Fields(ident)
+	if len(fields) < 3 {
+		return "", fmt.Errorf("unexpected git identity %q", strings.TrimSpace(ident))
+	}
+	return strings.Join(fields[:len(fields)-2], " "), nil
+}
+
+// addTrailers appends the configured trailers to message: co-authors
+// first, then the custom trailers and the Risk trailer, and the sign-off
+// last, as `git commit --signoff` would put it.
+func addTrailers(config *Config, message string) (string, error) {
+	for _, coAuthor := range config.CoAuthors {
+		if err := validIdentity(coAuthor); err != nil {
+			return "", fmt.Errorf("invalid co-author: %w", err)
+		}
+		message = addFooter(message, "Co-authored-by", strings.TrimSpace(coAuthor))
+	}
+	if len(config.Trailers) > 0 {
+		data, err := newTrailerData(config, message)
+		if err != nil {
+			return "", err
+		}
+		for _, trailer := range config.Trailers {
+			if !footerToken.MatchString(trailer.Token) {
+				return "", fmt.Errorf("invalid trailer token %q", trailer.Token)
+			}
+			value, err := trailerValue(trailer, data)
+			if err != nil {
+				return "", err
+			}
+			if value != "" {
+				message = addFooter(message, trailer.Token, value)
+			}
+		}
+	}
+	if config.riskLevel != "" {
+		message = addFooter(message, "Risk", config.riskLevel)
+	}
+	if config.Signoff {
+		ident, err := committerIdentity()
+		if err != nil {
+			return "", err
+		}
+		message = addFooter(message, "Signed-off-by", ident)
+	}
+	return message, nil
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+)
+
+// translatePrompt asks for a commit message in another language, with the
+// parts tools read left as they are.
+const translatePrompt = "Translate the git commit message below into {{.Language}}. Keep its structure: the subject line, blank lines, paragraphs and lists stay where they are. Keep conventional commit types and scopes, gitmoji, footer keys such as 'BREAKING CHANGE' or 'Signed-off-by', names and email addresses, issue and ticket references, code identifiers, file paths, commands and URLs exactly as they are. Write the subject line in the imperative mood if the language has one. If the message is already in {{.Language}}, reply with it unchanged. Do not include any explanation, preamble, or markdown formatting. Just the translated commit message itself.\n\n" + systemSeparator + "Commit message:\n```\n{{.Message}}\n```\n"
+
+// translateData is what translatePrompt is executed against.
+type translateData struct {
+	// Language is the name of the language to translate into.
+	Language string
+	// Message is the commit message to translate.
+	Message string
+}
+
+// runTranslate implements `git-commit-message translate <rev-range>`: the
+// messages of the commits in the range in another language, printed as a
+// report or, with --reword, written back with `git rebase -i`.
+func runTranslate(args []string) error {
+	flags := flag.NewFlagSet("translate", flag.ExitOnError)
+	to := flags.String("to", "en", "the language to translate into, as a code (de, ja, pt-BR) or a name")
+	reword := flags.Bool("reword", false, "rewrite the commits with the translated messages")
+	yes := flags.Bool("yes", false, "with --reword, rewrite without asking for confirmation")
+	overrides := registerConfigFlags(flags)
+	flags.Usage = func() {
+		fmt.Fprintln(flags.Output(), "Usage: git-commit-message translate [flags] <rev-range>")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 1 || strings.TrimSpace(*to) == "" {
+		flags.Usage()
+		os.Exit(2)
+	}
+
+	config, err := resolveConfig(overrides)
+	if err != nil {
+		return err
+	}
+	revRange := flags.Arg(0)
+	var shas []string
+	base := ""
+	if *reword {
+		if shas, base, err = rewordRange(revRange); err != nil {
+			return err
+		}
+		if dirty, _ := runGit("status", "--porcelain", "--untracked-files=no"); strings.TrimSpace(dirty) != "" {
+			return fmt.Errorf("the working tree has uncommitted changes; commit or stash them first")
+		}
+	} else {
+		output, err := runGit("rev-list", "--reverse", revRange)
+		if err != nil {
+			return fmt.Errorf("invalid revision range %q: %w", revRange, err)
+		}
+		if shas = strings.Fields(output); len(shas) == 0 {
+			return fmt.Errorf("no commits in %s", revRange)
+		}
+	}
+
+	language := languageName(*to)
+	commits := make([]rewordCommit, len(shas))
+	unchanged := make([]bool, len(shas))
+	changed := 0
+	for i, sha := range shas {
+		original, err := runGit("log", "-1", "--format=%B", sha)
+		if err != nil {
+			return err
+		}
+		original = strings.TrimSpace(original)
+		subject, _, _ := strings.Cut(original, "\n")
+		fmt.Fprintf(os.Stderr, "🤖 [%d/%d] %s %s\n", i+1, len(shas), sha[:7], subject)
+		translated, err := translateMessage(appCtx, config, original, language)
+		if err != nil {
+			return fmt.Errorf("could not translate the message of %s: %w", sha[:7], err)
+		}
+		if translated == original {
+			// Already in the language; keep it byte for byte.
+			translated, unchanged[i] = original, true
+		} else {
+			changed++
+		}
+		commits[i] = rewordCommit{SHA: sha, Subject: subject, Message: translated}
+	}
+
+	if !*reword {
+		for i, commit := range commits {
+			if i > 0 {
+				fmt.Println()
+			}
+			fmt.Printf("commit %s\n", commit.SHA)
+			for _, line := range strings.Split(commit.Message, "\n") {
+				fmt.Println(strings.TrimRight("    "+line, " "))
+			}
+		}
+		return nil
+	}
+
+	fmt.Printf("\n📝 Translated messages into %s:\n", language)
+	for i, commit := range commits {
+		if unchanged[i] {
+			fmt.Printf("  %s  %s (unchanged)\n", commit.SHA[:7], commit.Subject)
+			continue
+		}
+		fmt.Printf("  %s  %s\n           → %s\n", commit.SHA[:7], commit.Subject, subjectLine(commit.Message))
+	}
+	if changed == 0 {
+		fmt.Printf("All messages are already in %s. 🎉\n", language)
+		return nil
+	}
+	if !*yes {
+		ok, err := confirm(fmt.Sprintf("\nRewrite %d commit message(s)?", changed))
+		if err != nil {
+			return err
+		}
+		if !ok {
+			fmt.Println("Translation aborted.")
+			return nil
+		}
+	}
+	return applyReword(commits, base)
+}
+
+// translateMessage returns message translated into language. The model's
+// line breaks are kept, since rewrapping would reflow lists and code.
+func translateMessage(ctx context.Context, config *Config, message, language string) (string, error) {
+	prompt, err := renderPrompt(translatePrompt, &translateData{Language: language, Message: message})
+	if err != nil {
+		return "", err
+	}
+	reply, err := generateCommitMessage(ctx, config, prompt)
+	if err != nil {
+		return "", err
+	}
+	// Only a fence around the whole reply is removed, so that fenced code
+	// in the message survives; the other cleaners still apply.
+	cleanup := *config
+	cleanup.Cleaners = slices.DeleteFunc(slices.Clone(config.Cleaners), func(name string) bool { return name == "fences" })
+	translated := cleanup.runCleaners(stripOuterFence(reply))
+	if translated == "" {
+		return "", fmt.Errorf("the model returned an empty message")
+	}
+	return translated, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+)
+
+// Build metadata, set at build time with
+//
+//	go build -ldflags "-X main.buildVersion=v1.2.3 -X main.buildCommit=$(git rev-parse HEAD) -X main.buildDate=$(date -u +%FT%TZ)"
+//
+// Anything left unset is filled in from the module and VCS information Go
+// embeds in the binary.
+var (
+	buildVersion = ""
+	buildCommit  = ""
+	buildDate    = ""
+)
+
+// buildInfo describes the running binary.
+type buildInfo struct {
+	Version string `json:"version"`
+	Commit  string `json:"commit,omitempty"`
+	Date    string `json:"date,omitempty"`
+	// Modified is set when the binary was built from a dirty work tree.
+	Modified bool `json:"modified,omitempty"`
+}
+
+// currentBuild returns the build metadata, preferring the ldflags values.
+func currentBuild() buildInfo {
+	build := buildInfo{Version: buildVersion, Commit: buildCommit, Date: buildDate}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		if build.Version == "" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+			build.Version = info.Main.Version
+		}
+		for _, setting := range info.Settings {
+			switch setting.Key {
+			case "vcs.revision":
+				if build.Commit == "" {
+					build.Commit = setting.Value
+				}
+			case "vcs.time":
+				if build.Date == "" {
+					build.Date = setting.Value
+				}
+			case "vcs.modified":
+				build.Modified = buildCommit == "" && setting.Value == "true"
+			}
+		}
+	}
+	if build.Version == "" {
+		build.Version = "dev"
+	}
+	return build
+}
+
+// String formats b for --version, e.g. "v1.2.3 (abc1234, 2024-05-01T10:00:00Z)".
+func (b buildInfo) String() string {
+	s := b.Version
+	if b.Commit != "" {
+		sha := b.Commit
+		if len(sha) > 12 {
+			sha = sha[:12]
+		}
+		if b.Modified {
+			sha += "-dirty"
+		}
+		s += " (" + sha
+		if b.Date != "" {
+			s += ", " + b.Date
+		}
+		s += ")"
+	}
+	return s
+}
+
+// printVersion implements `git-commit-message --version`.
+func printVersion() {
+	fmt.Printf("git-commit-message %s %s %s/%s\n", currentBuild(), runtime.Version(), runtime.GOOS, runtime.GOARCH)
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+// watchInterval is how often --watch checks the diff for changes.
+const watchInterval = time.Second
+
+// clearScreen moves the cursor home and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// watchSuggestions implements --watch: it regenerates the suggestion for
+// mode whenever the diff changes and redraws it, until interrupted. A diff
+// is only described once it has stayed the same for a whole interval, so
+// a burst of `git add`s costs one request.
+func watchSuggestions(config *Config, mode diffMode) error {
+	redraw := isTerminal(os.Stdout)
+	var shown, pending [sha256.Size]byte
+	first := true
+	for ; ; time.Sleep(watchInterval) {
+		if appCtx.Err() != nil {
+			return appCtx.Err()
+		}
+		diff, err := collectDiff(config, mode)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256([]byte(diff))
+		if !first && sum == shown {
+			continue
+		}
+		if !first && sum != pending {
+			pending = sum
+			continue
+		}
+		first, shown, pending = false, sum, sum
+
+		if redraw {
+			fmt.Print(clearScreen)
+		} else {
+			fmt.Println("---")
+		}
+		fmt.Printf("👀 Watching %s changes (Ctrl-C to stop), updated %s\n", mode, time.Now().Format("15:04:05"))
+		if strings.TrimSpace(diff) == "" {
+			fmt.Printf("\nNo %s changes yet.\n", mode)
+			continue
+		}
+		fmt.Println("🤖 Generating commit message from diff...")
+		message, err := cachedSuggestion(appCtx, config, diff)
+		if err != nil {
+			fmt.Printf("⚠️  %v\n", err)
+			continue
+		}
+		fmt.Println("\n✅ Suggested Commit Message:")
+		fmt.Println(message)
+	}
+}
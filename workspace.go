@@ -0,0 +1,290 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// PackageConfig overrides settings for commits that only touch one package
+// of a monorepo. Empty fields keep the repository-wide setting.
+type PackageConfig struct {
+	// Scope replaces the package name as the conventional commit scope.
+	Scope              string `yaml:"scope"`
+	Convention         string `yaml:"convention"`
+	PromptTemplate     string `yaml:"prompt_template"`
+	PromptTemplateFile string `yaml:"prompt_template_file"`
+}
+
+// workspacePackage is a package of a monorepo: its name and its directory
+// relative to the repository root.
+type workspacePackage struct {
+	Name string
+	Dir  string
+}
+
+// majorVersion matches the /v2 style suffix of Go module paths.
+var majorVersion = regexp.MustCompile(`^v[0-9]+$`)
+
+// workspacePackages returns the packages of the workspace at root, from
+// go.work, the workspaces of package.json and the members of a Cargo
+// workspace, along with the directories configured under packages:.
+// Packages at the root itself are left out, since every file is in them.
+func workspacePackages(config *Config, root string) []workspacePackage {
+	var packages []workspacePackage
+	add := func(dir, name string) {
+		dir = path.Clean(filepath.ToSlash(dir))
+		if dir == "." || name == "" || slices.ContainsFunc(packages, func(p workspacePackage) bool { return p.Dir == dir }) {
+			return
+		}
+		packages = append(packages, workspacePackage{Name: name, Dir: dir})
+	}
+	for _, dir := range goWorkModules(root) {
+		add(dir, goModuleName(filepath.Join(root, dir)))
+	}
+	for _, dir := range expandMembers(root, npmWorkspaces(root)) {
+		add(dir, npmPackageName(filepath.Join(root, dir)))
+	}
+	for _, dir := range expandMembers(root, cargoMembers(root)) {
+		add(dir, cargoPackageName(filepath.Join(root, dir)))
+	}
+	// Configured keys that aren't a detected package's name are directories.
+	for key := range config.Packages {
+		if !slices.ContainsFunc(packages, func(p workspacePackage) bool { return p.Name == key }) {
+			add(key, path.Base(key))
+		}
+	}
+	return packages
+}
+
+// goWorkModules returns the directories in the use directives of go.work.
+func goWorkModules(root string) []string {
+	file, err := os.Open(filepath.Join(root, "go.work"))
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+	var dirs []string
+	inUse := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line, _, _ := strings.Cut(scanner.Text(), "//")
+		fields := strings.Fields(line)
+		switch {
+		case len(fields) == 0:
+		case inUse && fields[0] == ")":
+			inUse = false
+		case inUse:
+			dirs = append(dirs, strings.Trim(fields[0], `"`))
+		case fields[0] == "use" && len(fields) > 1 && fields[1] == "(":
+			inUse = true
+		case fields[0] == "use" && len(fields) > 1:
+			dirs = append(dirs, strings.Trim(fields[1], `"`))
+		}
+	}
+	return dirs
+}
+
+// goModuleName returns the last element of the module path in dir/go.mod,
+// e.g. "api" for example.com/shop/api/v2.
+func goModuleName(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "go.mod"))
+	if err != nil {
+		return ""
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 || fields[0] != "module" {
+			continue
+		}
+		module := strings.Trim(fields[1], `"`)
+		if name := path.Base(module); !majorVersion.MatchString(name) || path.Dir(module) == "." {
+			return name
+		}
+		return path.Base(path.Dir(module))
+	}
+	return ""
+}
+
+// npmWorkspaces returns the workspace patterns of root/package.json, which
+// npm and Yarn accept as a list or as {"packages": [...]}.
+func npmWorkspaces(root string) []string {
+	data, err := os.ReadFile(filepath.Join(root, "package.json"))
+	if err != nil {
+		return nil
+	}
+	var manifest struct {
+		Workspaces json.RawMessage `json:"workspaces"`
+	}
+	if json.Unmarshal(data, &manifest) != nil || len(manifest.Workspaces) == 0 {
+		return nil
+	}
+	var patterns []string
+	if json.Unmarshal(manifest.Workspaces, &patterns) == nil {
+		return patterns
+	}
+	var yarn struct {
+		Packages []string `json:"packages"`
+	}
+	json.Unmarshal(manifest.Workspaces, &yarn)
+	return yarn.Packages
+}
+
+// npmPackageName returns the name in dir/package.json without its npm
+// scope, e.g. "web" for @shop/web.
+func npmPackageName(dir string) string {
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		return ""
+	}
+	var manifest struct {
+		Name string `json:"name"`
+	}
+	if json.Unmarshal(data, &manifest) != nil {
+		return ""
+	}
+	return path.Base(manifest.Name)
+}
+
+// cargoMembers returns the workspace members of root/Cargo.toml.
+func cargoMembers(root string) []string {
+	var manifest struct {
+		Workspace struct {
+			Members []string `toml:"members"`
+		} `toml:"workspace"`
+	}
+	if _, err := toml.DecodeFile(filepath.Join(root, "Cargo.toml"), &manifest); err != nil {
+		return nil
+	}
+	return manifest.Workspace.Members
+}
+
+// cargoPackageName returns the package name in dir/Cargo.toml.
+func cargoPackageName(dir string) string {
+	var manifest struct {
+		Package struct {
+			Name string `toml:"name"`
+		} `toml:"package"`
+	}
+	if _, err := toml.DecodeFile(filepath.Join(dir, "Cargo.toml"), &manifest); err != nil {
+		return ""
+	}
+	return manifest.Package.Name
+}
+
+// expandMembers expands workspace glob patterns such as "packages/*" into
+// the directories under root they match. Patterns starting with ! exclude
+// directories again.
+func expandMembers(root string, patterns []string) []string {
+	var dirs, excluded []string
+	for _, pattern := range patterns {
+		exclude := strings.HasPrefix(pattern, "!")
+		pattern = strings.TrimSuffix(strings.TrimPrefix(pattern, "!"), "/")
+		// "packages/**" means the same as "packages/*" for one level of packages.
+		pattern = strings.ReplaceAll(pattern, "**", "*")
+		matches, err := filepath.Glob(filepath.Join(root, filepath.FromSlash(pattern)))
+		if err != nil {
+			slog.Warn("invalid workspace pattern", "pattern", pattern, "err", err)
+			continue
+		}
+		for _, match := range matches {
+			if info, err := os.Stat(match); err != nil || !info.IsDir() {
+				continue
+			}
+			rel, err := filepath.Rel(root, match)
+			if err != nil {
+				continue
+			}
+			if exclude {
+				excluded = append(excluded, filepath.ToSlash(rel))
+			} else {
+				dirs = append(dirs, filepath.ToSlash(rel))
+			}
+		}
+	}
+	return slices.DeleteFunc(dirs, func(dir string) bool { return slices.Contains(excluded, dir) })
+}
+
+// packageOf returns the package containing the repository-relative file,
+// the innermost one when packages are nested.
+func packageOf(packages []workspacePackage, file string) (workspacePackage, bool) {
+	var found workspacePackage
+	for _, p := range packages {
+		if strings.HasPrefix(file, p.Dir+"/") && len(p.Dir) > len(found.Dir) {
+			found = p
+		}
+	}
+	return found, found.Dir != ""
+}
+
+// changedPackage returns the package every file in diff belongs to, if
+// there is exactly one.
+func changedPackage(config *Config, diff string) (workspacePackage, bool) {
+	if !config.WorkspaceScopes && len(config.Packages) == 0 {
+		return workspacePackage{}, false
+	}
+	files := splitDiff(diff)
+	if len(files) == 0 {
+		return workspacePackage{}, false
+	}
+	top, err := runGit("rev-parse", "--show-toplevel")
+	if err != nil {
+		return workspacePackage{}, false
+	}
+	packages := workspacePackages(config, strings.TrimSpace(top))
+	var changed workspacePackage
+	for i, f := range files {
+		p, ok := packageOf(packages, f.Path)
+		if !ok || i > 0 && p != changed {
+			return workspacePackage{}, false
+		}
+		changed = p
+	}
+	return changed, true
+}
+
+// override returns the packages: entry for p, keyed by its directory or name.
+func (c *Config) override(p workspacePackage) (PackageConfig, bool) {
+	if o, ok := c.Packages[p.Dir]; ok {
+		return o, true
+	}
+	o, ok := c.Packages[p.Name]
+	return o, ok
+}
+
+// forPackage returns config with the overrides of the package diff is
+// confined to applied and the package's scope recorded for changeHintFor.
+// It returns config itself when the diff spans packages or there are none.
+func (c *Config) forPackage(diff string) *Config {
+	p, ok := changedPackage(c, diff)
+	if !ok {
+		return c
+	}
+	config := *c
+	if c.WorkspaceScopes {
+		config.packageScope = p.Name
+	}
+	o, ok := c.override(p)
+	if !ok {
+		return &config
+	}
+	if o.Scope != "" {
+		config.packageScope = o.Scope
+	}
+	if o.Convention != "" {
+		config.Convention = strings.ToLower(o.Convention)
+	}
+	if o.PromptTemplate != "" || o.PromptTemplateFile != "" {
+		config.PromptTemplate, config.PromptTemplateFile = o.PromptTemplate, o.PromptTemplateFile
+	}
+	slog.Info("package overrides applied", "package", p.Name, "dir", p.Dir)
+	return &config
+}